@@ -0,0 +1,264 @@
+package gobtcsign
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/litecoin"
+)
+
+// ErrInvalidPaymentURI is matched by errors of parsing and converting PaymentURI, check it with errors.Is
+//
+// ErrInvalidPaymentURI 能匹配解析和转换 PaymentURI 的错误，使用 errors.Is 检查
+var ErrInvalidPaymentURI = errors.New("invalid payment uri")
+
+// PaymentURI represents BIP21 payment URI such as bitcoin:address?amount=0.001&label=shop&message=order-1
+// Scheme and parameter keys are case-insensitive as BIP321 allows, keys are stored in lower case
+// Parameters such as lightning, lno and sp are kept in Params but never acted on, unknown req- parameters are rejected
+//
+// PaymentURI 代表 BIP21 支付 URI，比如 bitcoin:address?amount=0.001&label=shop&message=order-1
+// 按照 BIP321 的允许，协议名和参数名不区分大小写，参数名以小写保存
+// lightning、lno 和 sp 等参数保存在 Params 中但不会被使用，未知的 req- 参数会被拒绝
+type PaymentURI struct {
+	Scheme  string            // URI scheme such as bitcoin, dogecoin, litecoin // URI 协议名，比如 bitcoin、dogecoin、litecoin
+	Address string            // On-chain address, empty when URI carries other payment instructions only // 链上地址，URI 只包含其它支付方式时为空
+	Amount  int64             // Amount in satoshis, 0 when not given // 聪的数量，没有给出时为 0
+	Label   string            // Label of receiver // 接收者的标签
+	Message string            // Message describing the payment // 描述这次支付的消息
+	Params  map[string]string // Other parameters kept as they are // 原样保存的其它参数
+}
+
+// PaymentURIScheme returns URI scheme of network, Dogecoin and Litecoin networks use their own schemes, others use bitcoin
+//
+// PaymentURIScheme 返回网络的 URI 协议名，狗狗币和莱特币网络使用各自的协议名，其它网络使用 bitcoin
+func PaymentURIScheme(netParams *chaincfg.Params) string {
+	switch {
+	case isDogecoinParams(netParams):
+		return "dogecoin"
+	case slices.Contains([]wire.BitcoinNet{litecoin.MainNetParams.Net, litecoin.TestNetParams.Net, litecoin.RegressionNetParams.Net}, netParams.Net):
+		return "litecoin"
+	default:
+		return "bitcoin"
+	}
+}
+
+// NewPaymentURI creates PaymentURI paying amount in satoshis to address, amount 0 leaves amount to the payer
+//
+// NewPaymentURI 创建向地址支付指定聪数的 PaymentURI，数量为 0 时由付款方决定数量
+func NewPaymentURI(address string, amount int64, netParams *chaincfg.Params) (*PaymentURI, error) {
+	if amount < 0 {
+		return nil, errors.WithMessagef(ErrInvalidPaymentURI, "wrong amount=%d", amount)
+	}
+	res, err := decodePaymentURIAddress(address, netParams)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentURI{
+		Scheme:  PaymentURIScheme(netParams),
+		Address: res.EncodeAddress(),
+		Amount:  amount,
+		Params:  map[string]string{},
+	}, nil
+}
+
+// ParsePaymentURI parses BIP21 payment URI and checks address against network, decimal amount converts to satoshis without float
+// Following BIP321, address may be empty when URI carries other payment instructions,
+// and segwit fallback keyed by network hrp, such as bc= or tb=, fills the empty address
+//
+// ParsePaymentURI 解析 BIP21 支付 URI 并根据网络检查地址，十进制数量不经过浮点数转换为聪
+// 按照 BIP321，当 URI 包含其它支付方式时地址可以为空，
+// 以网络 hrp 为参数名的 segwit 备用地址，比如 bc= 或 tb=，会填入空的地址
+func ParsePaymentURI(uri string, netParams *chaincfg.Params) (*PaymentURI, error) {
+	scheme := PaymentURIScheme(netParams)
+	prefix, rest, found := strings.Cut(uri, ":")
+	if !found || !strings.EqualFold(prefix, scheme) {
+		return nil, errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri scheme=%q, expected %s", prefix, scheme)
+	}
+	if strings.Contains(rest, "#") {
+		return nil, errors.WithMessage(ErrInvalidPaymentURI, "wrong uri with fragment")
+	}
+	addressText, query, _ := strings.Cut(rest, "?")
+
+	var res = &PaymentURI{Scheme: scheme, Params: map[string]string{}}
+	if addressText != "" {
+		address, err := decodePaymentURIAddress(addressText, netParams)
+		if err != nil {
+			return nil, err
+		}
+		res.Address = address.EncodeAddress()
+	}
+	if err := res.parseQuery(query, netParams); err != nil {
+		return nil, err
+	}
+	if res.Address == "" {
+		if len(res.Params) == 0 {
+			return nil, errors.WithMessage(ErrInvalidPaymentURI, "wrong uri without address or other payment instruction")
+		}
+		res.Address = res.Params[strings.ToLower(netParams.Bech32HRPSegwit)]
+	}
+	return res, nil
+}
+
+// parseQuery parses query parameters into PaymentURI, duplicated keys are rejected
+// parseQuery 把查询参数解析到 PaymentURI 中，拒绝重复的参数名
+func (u *PaymentURI) parseQuery(query string, netParams *chaincfg.Params) error {
+	if query == "" {
+		return nil
+	}
+	var seen = map[string]bool{}
+	for _, part := range strings.Split(query, "&") {
+		keyText, valueText, found := strings.Cut(part, "=")
+		if !found || keyText == "" {
+			return errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri param=%q, expected key=value", part)
+		}
+		key := strings.ToLower(keyText)
+		if seen[key] {
+			return errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri param=%s is duplicated", key)
+		}
+		seen[key] = true
+		value, err := url.PathUnescape(valueText)
+		if err != nil {
+			return errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri param=%s value=%q: %v", key, valueText, err)
+		}
+		switch {
+		case key == "amount":
+			amount, err := ParsePaymentURIAmount(value)
+			if err != nil {
+				return err
+			}
+			u.Amount = amount
+		case key == "label":
+			u.Label = value
+		case key == "message":
+			u.Message = value
+		case strings.HasPrefix(key, "req-"):
+			return errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri param=%s is required but not supported", key)
+		case key == strings.ToLower(netParams.Bech32HRPSegwit):
+			address, err := decodePaymentURIAddress(value, netParams)
+			if err != nil {
+				return err
+			}
+			if _, ok := address.(interface{ WitnessVersion() byte }); !ok {
+				return errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri param=%s address=%s is not segwit", key, value)
+			}
+			u.Params[key] = address.EncodeAddress()
+		default:
+			u.Params[key] = value
+		}
+	}
+	return nil
+}
+
+// decodePaymentURIAddress decodes address and checks it belongs to network
+// decodePaymentURIAddress 解析地址并检查它属于该网络
+func decodePaymentURIAddress(address string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	res, err := DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri address=%s: %v", address, err)
+	}
+	if !res.IsForNet(netParams) {
+		return nil, errors.WithMessagef(ErrInvalidPaymentURI, "wrong uri address=%s is not for network=%s", address, netParams.Name)
+	}
+	return res, nil
+}
+
+// paymentURIAmountRegexp matches BIP21 amount, digits with optional dot and fraction
+// paymentURIAmountRegexp 匹配 BIP21 的数量，即数字加可选的小数点和小数部分
+var paymentURIAmountRegexp = regexp.MustCompile(`^([0-9]*)(?:\.([0-9]*))?$`)
+
+// ParsePaymentURIAmount converts BIP21 decimal amount in coins into satoshis exactly
+// Amount must be positive with at most 8 decimals, exponents, signs and separators are rejected
+//
+// ParsePaymentURIAmount 把 BIP21 中以币为单位的十进制数量精确转换为聪
+// 数量必须为正数且最多 8 位小数，拒绝指数、正负号和分隔符
+func ParsePaymentURIAmount(text string) (int64, error) {
+	matches := paymentURIAmountRegexp.FindStringSubmatch(text)
+	if matches == nil || matches[1]+matches[2] == "" {
+		return 0, errors.WithMessagef(ErrInvalidPaymentURI, "wrong amount=%q, expected decimal coins", text)
+	}
+	if len(matches[2]) > 8 {
+		return 0, errors.WithMessagef(ErrInvalidPaymentURI, "wrong amount=%q has more than 8 decimals", text)
+	}
+	coinsText := matches[1]
+	if coinsText == "" {
+		coinsText = "0"
+	}
+	satoshi, ok := decimalCoinsToSatoshi(coinsText, matches[2])
+	if !ok {
+		return 0, errors.WithMessagef(ErrInvalidPaymentURI, "wrong amount=%q overflows", text)
+	}
+	if satoshi == 0 {
+		return 0, errors.WithMessagef(ErrInvalidPaymentURI, "wrong amount=%q is zero", text)
+	}
+	return satoshi, nil
+}
+
+// FormatPaymentURIAmount formats satoshis as BIP21 decimal amount in coins without trailing zeros, such as 0.001
+//
+// FormatPaymentURIAmount 把聪格式化为 BIP21 中以币为单位且没有末尾零的十进制数量，比如 0.001
+func FormatPaymentURIAmount(satoshi int64) string {
+	coins := strconv.FormatInt(satoshi/satoshiPerCoin, 10)
+	fraction := strings.TrimRight(strconv.FormatInt(satoshiPerCoin+satoshi%satoshiPerCoin, 10)[1:], "0")
+	if fraction == "" {
+		return coins
+	}
+	return coins + "." + fraction
+}
+
+// String encodes PaymentURI, amount, label and message come first and other params follow in key order
+//
+// String 编码 PaymentURI，amount、label 和 message 在前，其它参数按参数名排序在后
+func (u *PaymentURI) String() string {
+	var params []string
+	if u.Amount > 0 {
+		params = append(params, "amount="+FormatPaymentURIAmount(u.Amount))
+	}
+	if u.Label != "" {
+		params = append(params, "label="+escapePaymentURIValue(u.Label))
+	}
+	if u.Message != "" {
+		params = append(params, "message="+escapePaymentURIValue(u.Message))
+	}
+	var keys = make([]string, 0, len(u.Params))
+	for key := range u.Params {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		params = append(params, key+"="+escapePaymentURIValue(u.Params[key]))
+	}
+	var res = u.Scheme + ":" + u.Address
+	if len(params) > 0 {
+		res += "?" + strings.Join(params, "&")
+	}
+	return res
+}
+
+// escapePaymentURIValue percent-encodes param value, space becomes %20 rather than +
+// escapePaymentURIValue 对参数值做百分号编码，空格编码为 %20 而不是 +
+func escapePaymentURIValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// ToOutType converts PaymentURI into OutType, address and amount are both required
+//
+// ToOutType 把 PaymentURI 转换为 OutType，地址和数量都必须存在
+func (u *PaymentURI) ToOutType() (*OutType, error) {
+	if u.Address == "" {
+		return nil, errors.WithMessage(ErrInvalidPaymentURI, "wrong uri without on-chain address")
+	}
+	if u.Amount <= 0 {
+		return nil, errors.WithMessage(ErrInvalidPaymentURI, "wrong uri without amount")
+	}
+	return &OutType{
+		Target: *NewAddressTuple(u.Address),
+		Amount: u.Amount,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package gobtcsign
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign/dogecoin"
+	"github.com/yyle88/gobtcsign/litecoin"
+)
+
+func TestParsePaymentURI(t *testing.T) {
+	uri, err := ParsePaymentURI("bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa?amount=50&label=Luke-Jr&message=Donation%20for%20project%20xyz&lightning=lnbc1invoice", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, &PaymentURI{
+		Scheme:  "bitcoin",
+		Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		Amount:  5000000000,
+		Label:   "Luke-Jr",
+		Message: "Donation for project xyz",
+		Params:  map[string]string{"lightning": "lnbc1invoice"},
+	}, uri)
+
+	output, err := uri.ToOutType()
+	require.NoError(t, err)
+	require.Equal(t, &OutType{Target: *NewAddressTuple("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"), Amount: 5000000000}, output)
+
+	again, err := ParsePaymentURI(uri.String(), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, uri, again)
+}
+
+func TestParsePaymentURI_DOGE(t *testing.T) {
+	uri, err := ParsePaymentURI("dogecoin:nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC?amount=12.5", &dogecoin.TestNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "dogecoin", uri.Scheme)
+	require.Equal(t, int64(1250000000), uri.Amount)
+
+	_, err = ParsePaymentURI("bitcoin:nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC?amount=12.5", &dogecoin.TestNetParams)
+	require.ErrorIs(t, err, ErrInvalidPaymentURI)
+}
+
+func TestParsePaymentURI_BIP321(t *testing.T) {
+	// Scheme, keys and bech32 address in upper case, as QR codes prefer
+	uri, err := ParsePaymentURI("BITCOIN:TB1QVG2JKSXCKT96CDV9G8V9PSREAGGDZSRLM6ARAP?AMOUNT=0.000049", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", uri.Address)
+	require.Equal(t, int64(4900), uri.Amount)
+
+	// Segwit fallback keyed by hrp fills empty address
+	uri, err = ParsePaymentURI("bitcoin:?tb=tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap&amount=0.001", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", uri.Address)
+
+	// Lightning only has no on-chain output
+	uri, err = ParsePaymentURI("bitcoin:?lightning=lntb1invoice&amount=0.001", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Empty(t, uri.Address)
+	_, err = uri.ToOutType()
+	require.ErrorIs(t, err, ErrInvalidPaymentURI)
+}
+
+func TestParsePaymentURI_Invalid(t *testing.T) {
+	for name, text := range map[string]string{
+		"scheme":         "litecoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap",
+		"network":        "bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		"empty":          "bitcoin:",
+		"req-param":      "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap?req-somethingyoudontunderstand=50",
+		"req-pop":        "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap?req-pop=callback%3a",
+		"duplicate":      "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap?amount=1&AMOUNT=2",
+		"no-value":       "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap?amount",
+		"bad-escape":     "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap?label=%zz",
+		"fragment":       "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap#amount=1",
+		"fallback-p2pkh": "bitcoin:?tb=mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePaymentURI(text, &chaincfg.TestNet3Params)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrInvalidPaymentURI))
+		})
+	}
+}
+
+func TestParsePaymentURIAmount(t *testing.T) {
+	for text, satoshi := range map[string]int64{
+		"50":                  5000000000,
+		"0.001":               100000,
+		"0.00000001":          1,
+		"1.":                  100000000,
+		".5":                  50000000,
+		"92233720368.5477580": 9223372036854775800,
+	} {
+		amount, err := ParsePaymentURIAmount(text)
+		require.NoError(t, err, text)
+		require.Equal(t, satoshi, amount, text)
+	}
+
+	for _, text := range []string{"", ".", "0", "0.000000001", "1e3", "-1", "+1", "1,5", "0x10", "92233720368.54775808"} {
+		_, err := ParsePaymentURIAmount(text)
+		require.ErrorIs(t, err, ErrInvalidPaymentURI, text)
+	}
+}
+
+func TestFormatPaymentURIAmount(t *testing.T) {
+	require.Equal(t, "50", FormatPaymentURIAmount(5000000000))
+	require.Equal(t, "0.001", FormatPaymentURIAmount(100000))
+	require.Equal(t, "0.00000001", FormatPaymentURIAmount(1))
+	require.Equal(t, "12.5", FormatPaymentURIAmount(1250000000))
+}
+
+func TestNewPaymentURI(t *testing.T) {
+	uri, err := NewPaymentURI("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", 4900, &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	uri.Label = "shop"
+	uri.Message = "order 1&2"
+	require.Equal(t, "bitcoin:tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap?amount=0.000049&label=shop&message=order%201%262", uri.String())
+
+	again, err := ParsePaymentURI(uri.String(), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, uri, again)
+
+	uri, err = NewPaymentURI("nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", 0, &dogecoin.TestNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "dogecoin:nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", uri.String())
+
+	_, err = NewPaymentURI("nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", 0, &chaincfg.MainNetParams)
+	require.ErrorIs(t, err, ErrInvalidPaymentURI)
+}
+
+func TestPaymentURIScheme(t *testing.T) {
+	require.Equal(t, "bitcoin", PaymentURIScheme(&chaincfg.SigNetParams))
+	require.Equal(t, "dogecoin", PaymentURIScheme(&dogecoin.RegressionNetParams))
+	require.Equal(t, "litecoin", PaymentURIScheme(&litecoin.TestNetParams))
+
+	// Params sharing magic with Dogecoin regtest, such as Bitcoin Cash regtest, are not taken as Dogecoin
+	netParams := dogecoin.RegressionNetParams
+	netParams.Bech32HRPSegwit = ""
+	require.NotEqual(t, "dogecoin", PaymentURIScheme(&netParams))
+}
@@ -0,0 +1,168 @@
+package bitcoincash
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign"
+)
+
+// SigHashForkID is the SIGHASH_FORKID flag, signatures without it are rejected on Bitcoin Cash
+// The fork id of Bitcoin Cash is 0, so the upper 24 bits of sighash type stay 0
+//
+// SigHashForkID 是 SIGHASH_FORKID 标志，比特币现金上没有它的签名会被拒绝
+// 比特币现金的 fork id 是 0，因此 sighash 类型的高 24 位保持为 0
+const SigHashForkID txscript.SigHashType = 0x40
+
+// SigHashAllForkID is the default sighash type of Bitcoin Cash signatures
+//
+// SigHashAllForkID 是比特币现金签名默认的 sighash 类型
+const SigHashAllForkID = txscript.SigHashAll | SigHashForkID
+
+// CalcSignatureHash computes FORKID digest of input, which is BIP143 digest over pkScript with the input amount
+// Unlike legacy Bitcoin sighash it commits to the amount, so InputOuts must hold real amounts
+//
+// CalcSignatureHash 计算输入的 FORKID 摘要，就是使用 pkScript 和输入数量的 BIP143 摘要
+// 和比特币的传统 sighash 不同，它包含数量，因此 InputOuts 必须是真实的数量
+func CalcSignatureHash(msgTx *wire.MsgTx, idx int, inputOut *wire.TxOut, sigHashes *txscript.TxSigHashes, hashType txscript.SigHashType) ([]byte, error) {
+	if hashType&SigHashForkID == 0 {
+		return nil, errors.Errorf("wrong sighash-type=%#x without SIGHASH_FORKID", uint32(hashType))
+	}
+	hash, err := txscript.CalcWitnessSigHash(inputOut.PkScript, sigHashes, hashType, msgTx, idx, inputOut.Value)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "wrong calc-sig-hash. index=%d", idx)
+	}
+	return hash, nil
+}
+
+// Sign signs P2PKH inputs with SIGHASH_ALL|SIGHASH_FORKID, the sender can be CashAddr or legacy address
+// Build the SignParam with gobtcsign.BitcoinTxParams and bitcoincash params, and use NewRBFNotUse since BCH has no RBF
+//
+// Sign 使用 SIGHASH_ALL|SIGHASH_FORKID 签名 P2PKH 输入，发送者可以是 CashAddr 或传统地址
+// 使用 gobtcsign.BitcoinTxParams 和 bitcoincash 参数构建 SignParam，由于 BCH 没有 RBF，因此使用 NewRBFNotUse
+func Sign(senderAddress string, privateKeyHex string, param *gobtcsign.SignParam) error {
+	privKeyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return errors.WithMessage(err, "wrong decode private key string")
+	}
+	privKey, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+
+	walletAddress, err := DecodeAddress(senderAddress, param.NetParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong from_address")
+	}
+	if _, ok := walletAddress.(*btcutil.AddressPubKeyHash); !ok {
+		return &gobtcsign.UnsupportedAddressTypeError{Address: senderAddress, AddressType: "p2sh"}
+	}
+	compress, err := gobtcsign.CheckPKHAddressIsCompress(param.NetParams, pubKey, walletAddress.EncodeAddress())
+	if err != nil {
+		return errors.WithMessage(err, "wrong sign check_from_address_is_compress")
+	}
+	if err := SignP2PKH(param, privKey, compress); err != nil {
+		return errors.WithMessage(err, "wrong sign")
+	}
+	return nil
+}
+
+// SignP2PKH signs every input with SIGHASH_ALL|SIGHASH_FORKID and verifies the result
+//
+// SignP2PKH 使用 SIGHASH_ALL|SIGHASH_FORKID 签名每个输入并验证结果
+func SignP2PKH(signParam *gobtcsign.SignParam, privKey *btcec.PrivateKey, compress bool) error {
+	var msgTx = signParam.MsgTx
+
+	prevOutFetcher, err := gobtcsign.NewPrevOutFetcherFromInputOuts(msgTx, signParam.InputOuts)
+	if err != nil {
+		return errors.WithMessage(err, "wrong new-prev-out-fetcher")
+	}
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+
+	var pubKeyBytes []byte
+	if compress {
+		pubKeyBytes = privKey.PubKey().SerializeCompressed()
+	} else {
+		pubKeyBytes = privKey.PubKey().SerializeUncompressed()
+	}
+	for idx := range msgTx.TxIn {
+		hash, err := CalcSignatureHash(msgTx, idx, signParam.InputOuts[idx], sigHashes, SigHashAllForkID)
+		if err != nil {
+			return err
+		}
+		signature := ecdsa.Sign(privKey, hash)
+		sig := append(signature.Serialize(), byte(SigHashAllForkID))
+
+		signatureScript, err := txscript.NewScriptBuilder().AddData(sig).AddData(pubKeyBytes).Script()
+		if err != nil {
+			return errors.WithMessagef(err, "wrong signature_script. index=%d", idx)
+		}
+		msgTx.TxIn[idx].SignatureScript = signatureScript
+	}
+	return VerifySign(msgTx, signParam.InputOuts)
+}
+
+// VerifySign verifies FORKID signatures of P2PKH inputs, inputOuts are prevouts in the same order as tx inputs
+// Returns gobtcsign.SignatureInvalidError of the first input not valid
+//
+// VerifySign 验证 P2PKH 输入的 FORKID 签名，inputOuts 是和交易输入顺序相同的前置输出
+// 返回第一个无效输入的 gobtcsign.SignatureInvalidError
+func VerifySign(msgTx *wire.MsgTx, inputOuts []*wire.TxOut) error {
+	prevOutFetcher, err := gobtcsign.NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+	if err != nil {
+		return err
+	}
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+	for idx := range msgTx.TxIn {
+		if err := verifyP2PKHInput(msgTx, idx, inputOuts[idx], sigHashes); err != nil {
+			return &gobtcsign.SignatureInvalidError{Index: idx, Err: err}
+		}
+	}
+	return nil
+}
+
+// verifyP2PKHInput checks signature script <sig> <pubkey> against P2PKH prevout
+// verifyP2PKHInput 根据 P2PKH 前置输出检查签名脚本 <sig> <pubkey>
+func verifyP2PKHInput(msgTx *wire.MsgTx, idx int, inputOut *wire.TxOut, sigHashes *txscript.TxSigHashes) error {
+	if !txscript.IsPayToPubKeyHash(inputOut.PkScript) {
+		return errors.WithMessagef(gobtcsign.ErrUnsupportedAddressType, "wrong pk-script=%x is not p2pkh", inputOut.PkScript)
+	}
+	signatureScript := msgTx.TxIn[idx].SignatureScript
+	if !txscript.IsPushOnlyScript(signatureScript) {
+		return errors.New("wrong signature-script is not push only")
+	}
+	pushes, err := txscript.PushedData(signatureScript)
+	if err != nil {
+		return errors.WithMessage(err, "wrong signature-script")
+	}
+	if len(pushes) != 2 || len(pushes[0]) == 0 {
+		return errors.Errorf("wrong signature-script push count=%d, expected 2", len(pushes))
+	}
+	sig, pubKeyBytes := pushes[0], pushes[1]
+
+	// P2PKH script is OP_DUP OP_HASH160 <20 bytes> OP_EQUALVERIFY OP_CHECKSIG
+	// P2PKH 脚本是 OP_DUP OP_HASH160 <20 字节> OP_EQUALVERIFY OP_CHECKSIG
+	if !bytes.Equal(btcutil.Hash160(pubKeyBytes), inputOut.PkScript[3:23]) {
+		return errors.New("wrong pubkey does not match pk-script hash")
+	}
+	hashType := txscript.SigHashType(sig[len(sig)-1])
+	hash, err := CalcSignatureHash(msgTx, idx, inputOut, sigHashes, hashType)
+	if err != nil {
+		return err
+	}
+	signature, err := ecdsa.ParseDERSignature(sig[:len(sig)-1])
+	if err != nil {
+		return errors.WithMessage(err, "wrong parse-signature")
+	}
+	pubKey, err := btcec.ParsePubKey(pubKeyBytes)
+	if err != nil {
+		return errors.WithMessage(err, "wrong parse-pubkey")
+	}
+	if !signature.Verify(hash, pubKey) {
+		return errors.New("wrong signature verify failed")
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package bitcoincash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign"
+)
+
+const (
+	testPrivateKeyHex = "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"
+	testSenderAddress = "bchtest:qp3p226qmzevhtp4s4qas5xq084pp52q0ulk0ra253"
+	testTargetAddress = "bchtest:qz6dmwwmdqrp5rlvjzjtethjr7pv3napav56hjt6pc"
+)
+
+// newTestSignParam builds fixture tx on Bitcoin Cash testnet: one P2PKH input, payment and change outputs
+// newTestSignParam 在比特币现金测试网构建固定交易：一个 P2PKH 输入，付款和找零两个输出
+func newTestSignParam(t *testing.T) (*gobtcsign.BitcoinTxParams, *gobtcsign.SignParam) {
+	sender, err := NewAddressTuple(testSenderAddress, &TestNetParams)
+	require.NoError(t, err)
+	target, err := NewAddressTuple(testTargetAddress, &TestNetParams)
+	require.NoError(t, err)
+
+	param := &gobtcsign.BitcoinTxParams{
+		VinList: []gobtcsign.VinType{
+			{
+				OutPoint: *gobtcsign.MustNewOutPoint("57a3514865d3f4c5cbd49270204aaf4928c4c10651430dcd0cb79b80cda5ef0b", 1),
+				Sender:   *sender,
+				Amount:   1000000,
+				RBFInfo:  *gobtcsign.NewRBFNotUse(),
+			},
+		},
+		OutList: []gobtcsign.OutType{
+			{Target: *target, Amount: 600000},
+			{Target: *sender, Amount: 1000000 - 600000 - 226},
+		},
+		RBFInfo: *gobtcsign.NewRBFNotUse(),
+	}
+	signParam, err := param.CreateTxSignParams(&TestNetParams)
+	require.NoError(t, err)
+	return param, signParam
+}
+
+// TestSign validates FORKID signing of fixture tx and pins the signed result
+//
+// TestSign 验证固定交易的 FORKID 签名并固定签名结果
+func TestSign(t *testing.T) {
+	param, signParam := newTestSignParam(t)
+	require.Equal(t, int64(226), int64(param.GetFee()))
+
+	require.NoError(t, Sign(testSenderAddress, testPrivateKeyHex, signParam))
+	msgTx := signParam.MsgTx
+	require.NoError(t, VerifySign(msgTx, signParam.InputOuts))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, &TestNetParams))
+
+	pushes, err := txscript.PushedData(msgTx.TxIn[0].SignatureScript)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x41), pushes[0][len(pushes[0])-1])
+
+	txHash := gobtcsign.GetTxHash(msgTx)
+	t.Log("msg-tx-hash:->", txHash, "<-")
+	require.Equal(t, "d75d82a57e63d6e509dcc3b35f70da81d22298b3dca8dd9c8b7a646f987de310", txHash)
+
+	signedHex, err := gobtcsign.CvtMsgTxToHex(msgTx)
+	require.NoError(t, err)
+	t.Log("raw-tx-data:->", signedHex, "<-")
+	require.Equal(t, "01000000010befa5cd809bb70ccd0d435106c1c42849af4a207092d4cbc5f4d3654851a357010000006b483045022100f9f4e6ede6efcc95d4a643663ba53a03d74c22d37be72c4a56020f65c21386e902207576f213e9a119773ae71ab64aa90ffb046b1c5e10d2efaccafecc26a894a5fa412102407ea64d7a9e992028a94481af95ea7d8f54870bd73e5878a014da594335ba32ffffffff02c0270900000000001976a914b4ddb9db68061a0fec90a4bcaef21f82c8cfa1eb88ac9e190600000000001976a91462152b40d8b2cbac358541d850c079ea10d1407f88ac00000000", signedHex)
+}
+
+// TestSign_LegacySender validates legacy sender address signs the same as CashAddr
+//
+// TestSign_LegacySender 验证传统格式的发送者地址和 CashAddr 签名结果相同
+func TestSign_LegacySender(t *testing.T) {
+	_, signParam1 := newTestSignParam(t)
+	require.NoError(t, Sign(testSenderAddress, testPrivateKeyHex, signParam1))
+
+	_, signParam2 := newTestSignParam(t)
+	require.NoError(t, Sign("mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb", testPrivateKeyHex, signParam2))
+
+	require.Equal(t, signParam1.MsgTx.TxHash(), signParam2.MsgTx.TxHash())
+}
+
+// TestVerifySign_WrongAmount validates signature commits to input amount
+//
+// TestVerifySign_WrongAmount 验证签名包含输入的数量
+func TestVerifySign_WrongAmount(t *testing.T) {
+	_, signParam := newTestSignParam(t)
+	require.NoError(t, Sign(testSenderAddress, testPrivateKeyHex, signParam))
+
+	inputOuts := []*wire.TxOut{wire.NewTxOut(signParam.InputOuts[0].Value+1, signParam.InputOuts[0].PkScript)}
+	err := VerifySign(signParam.MsgTx, inputOuts)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, gobtcsign.ErrSignatureInvalid))
+}
+
+// TestVerifySign_WithoutForkID validates Bitcoin legacy signature is rejected
+//
+// TestVerifySign_WithoutForkID 验证比特币的传统签名会被拒绝
+func TestVerifySign_WithoutForkID(t *testing.T) {
+	_, signParam := newTestSignParam(t)
+	require.NoError(t, gobtcsign.Sign("mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb", testPrivateKeyHex, signParam))
+
+	err := VerifySign(signParam.MsgTx, signParam.InputOuts)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, gobtcsign.ErrSignatureInvalid))
+}
+
+// TestSign_P2SHSender validates P2SH sender is rejected as unsupported
+//
+// TestSign_P2SHSender 验证 P2SH 发送者作为不支持的类型被拒绝
+func TestSign_P2SHSender(t *testing.T) {
+	_, signParam := newTestSignParam(t)
+	address, err := btcutil.NewAddressScriptHashFromHash(make([]byte, 20), &TestNetParams)
+	require.NoError(t, err)
+	cashAddr, err := EncodeCashAddress(address, &TestNetParams)
+	require.NoError(t, err)
+
+	err = Sign(cashAddr, testPrivateKeyHex, signParam)
+	require.True(t, errors.Is(err, gobtcsign.ErrUnsupportedAddressType))
+}
+
+// TestCalcSignatureHash_BIP143Vector checks FORKID digest against the native P2WPKH example of BIP143
+// FORKID with fork id 0 hashes the same BIP143 preimage, only the trailing sighash type becomes 0x41
+//
+// TestCalcSignatureHash_BIP143Vector 使用 BIP143 的原生 P2WPKH 示例检查 FORKID 摘要
+// fork id 为 0 的 FORKID 对同一个 BIP143 原像做哈希，只是末尾的 sighash 类型变为 0x41
+func TestCalcSignatureHash_BIP143Vector(t *testing.T) {
+	const (
+		unsignedTxHex = "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000"
+		preimageHex   = "0100000096b827c8483d4e9b96712b6713a7b68d6e8003a781feba36c31143470b4efd3752b0a642eea2fb7ae638c36f6252b6750293dbe574a806984b8e4d8548339a3bef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a010000001976a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac0046c32300000000ffffffff863ef3e1a92afbfdb97f31ad0fc7683ee943e9abcf2501590ff8f6551f47e5e51100000001000000"
+		sigHashHex    = "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670"
+	)
+	txBytes, err := hex.DecodeString(unsignedTxHex)
+	require.NoError(t, err)
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	require.NoError(t, msgTx.Deserialize(bytes.NewReader(txBytes)))
+
+	preimage, err := hex.DecodeString(preimageHex)
+	require.NoError(t, err)
+	require.Equal(t, sigHashHex, hex.EncodeToString(chainhash.DoubleHashB(preimage)))
+
+	pkScript0, err := hex.DecodeString("2103c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432ac")
+	require.NoError(t, err)
+	pkScript1, err := hex.DecodeString("76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+	require.NoError(t, err)
+	inputOuts := []*wire.TxOut{wire.NewTxOut(625000000, pkScript0), wire.NewTxOut(600000000, pkScript1)}
+
+	prevOutFetcher, err := gobtcsign.NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+	require.NoError(t, err)
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+
+	hash, err := CalcSignatureHash(msgTx, 1, inputOuts[1], sigHashes, SigHashAllForkID)
+	require.NoError(t, err)
+	forkIDPreimage := append(preimage[:len(preimage)-4:len(preimage)-4], byte(SigHashAllForkID), 0, 0, 0)
+	require.Equal(t, chainhash.DoubleHashB(forkIDPreimage), hash)
+	require.Equal(t, "467f411d178762db122a6aced76370a1c8324355bf0796502bf82eeaeda86a35", hex.EncodeToString(hash))
+
+	_, err = CalcSignatureHash(msgTx, 1, inputOuts[1], sigHashes, txscript.SigHashAll)
+	require.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Package bitcoincash provides Bitcoin Cash network parameters, CashAddr encoding and SIGHASH_FORKID signing
+// BCH signs every input with BIP143-style digest and SIGHASH_FORKID, which does not fit the Bitcoin signing logic
+//
+// bitcoincash 包提供比特币现金的网络参数、CashAddr 编码和 SIGHASH_FORKID 签名
+// BCH 的每个输入都使用 BIP143 风格的摘要和 SIGHASH_FORKID 签名，不适用比特币的签名逻辑
+package bitcoincash
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+)
+
+// The params are not registered into chaincfg: legacy addresses decode against params directly,
+// and CashAddr addresses are decoded by this package, so no global registration is needed
+//
+// 这些参数不会注册到 chaincfg：传统地址直接根据参数解析，
+// CashAddr 地址由本包解析，因此不需要全局注册
+
+// MainNetParams represents chain configuration for Bitcoin Cash mainnet
+// Legacy addresses share version bytes with Bitcoin, CashAddr prefix is "bitcoincash"
+//
+// MainNetParams 代表比特币现金主网的链配置
+// 传统地址和比特币使用相同的版本字节，CashAddr 前缀是 "bitcoincash"
+var MainNetParams = chaincfg.Params{
+	Name: "mainnet",
+	Net:  0xe8f3e1e3,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 0x00, // starts with 1
+	ScriptHashAddrID: 0x05, // starts with 3
+	PrivateKeyID:     0x80, // starts with 5 (uncompressed) or K/L (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x88, 0xad, 0xe4}, // starts with xprv
+	HDPublicKeyID:  [4]byte{0x04, 0x88, 0xb2, 0x1e}, // starts with xpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 145,
+}
+
+// TestNetParams represents chain configuration for Bitcoin Cash testnet3, CashAddr prefix is "bchtest"
+//
+// TestNetParams 代表比特币现金测试网 testnet3 的链配置，CashAddr 前缀是 "bchtest"
+var TestNetParams = chaincfg.Params{
+	Name: "testnet3",
+	Net:  0xf4f3e5f4,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 0x6f, // starts with m or n
+	ScriptHashAddrID: 0xc4, // starts with 2
+	PrivateKeyID:     0xef, // starts with 9 (uncompressed) or c (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
+
+// RegressionNetParams represents chain configuration for Bitcoin Cash regression testing network, CashAddr prefix is "bchreg"
+// Network magic equals dogecoin.RegressionNetParams, so params are never told apart by magic alone
+//
+// RegressionNetParams 代表比特币现金回归测试网络的链配置，CashAddr 前缀是 "bchreg"
+// 网络标识和 dogecoin.RegressionNetParams 相同，因此绝不能只根据网络标识区分参数
+var RegressionNetParams = chaincfg.Params{
+	Name: "regtest",
+	Net:  0xfabfb5da,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 0x6f,
+	ScriptHashAddrID: 0xc4,
+	PrivateKeyID:     0xef,
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
+
+const (
+	MainNetCashAddrPrefix = "bitcoincash" // CashAddr prefix of mainnet // 主网的 CashAddr 前缀
+	TestNetCashAddrPrefix = "bchtest"     // CashAddr prefix of testnet // 测试网的 CashAddr 前缀
+	RegTestCashAddrPrefix = "bchreg"      // CashAddr prefix of regtest // 回归测试网的 CashAddr 前缀
+)
+
+// GetCashAddrPrefix returns CashAddr prefix of network, matched by name, magic and address version bytes
+// Magic alone is not enough since Dogecoin regtest shares magic with Bitcoin Cash regtest
+//
+// GetCashAddrPrefix 根据名称、网络标识和地址版本字节返回网络的 CashAddr 前缀
+// 只看网络标识是不够的，因为狗狗币回归测试网和比特币现金回归测试网的网络标识相同
+func GetCashAddrPrefix(netParams *chaincfg.Params) (string, error) {
+	switch {
+	case isSameParams(netParams, &MainNetParams):
+		return MainNetCashAddrPrefix, nil
+	case isSameParams(netParams, &TestNetParams):
+		return TestNetCashAddrPrefix, nil
+	case isSameParams(netParams, &RegressionNetParams):
+		return RegTestCashAddrPrefix, nil
+	default:
+		return "", errors.Errorf("wrong network=%s has no cash-addr prefix", netParams.Name)
+	}
+}
+
+// isSameParams checks whether netParams is the Bitcoin Cash network of expected, copies of the params also match
+// isSameParams 检查 netParams 是否是 expected 对应的比特币现金网络，参数的副本也能匹配
+func isSameParams(netParams *chaincfg.Params, expected *chaincfg.Params) bool {
+	return netParams.Net == expected.Net &&
+		netParams.Name == expected.Name &&
+		netParams.PubKeyHashAddrID == expected.PubKeyHashAddrID &&
+		netParams.ScriptHashAddrID == expected.ScriptHashAddrID &&
+		netParams.Bech32HRPSegwit == expected.Bech32HRPSegwit
+}
@@ -0,0 +1,44 @@
+package bitcoincash
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign/dogecoin"
+)
+
+// TestMainNetParams validates Bitcoin Cash MainNet network configuration parameters
+//
+// TestMainNetParams 验证比特币现金主网网络配置参数
+func TestMainNetParams(t *testing.T) {
+	require.Equal(t, "mainnet", MainNetParams.Name)
+	require.EqualValues(t, 0xe8f3e1e3, MainNetParams.Net)
+	require.Equal(t, uint8(0), MainNetParams.PubKeyHashAddrID)
+	require.Equal(t, uint8(5), MainNetParams.ScriptHashAddrID)
+	require.Equal(t, uint32(145), MainNetParams.HDCoinType)
+	require.Empty(t, MainNetParams.Bech32HRPSegwit)
+}
+
+// TestGetCashAddrPrefix validates prefix lookup of each network
+//
+// TestGetCashAddrPrefix 验证每个网络的前缀查询
+func TestGetCashAddrPrefix(t *testing.T) {
+	for netParams, expected := range map[*chaincfg.Params]string{
+		&MainNetParams:       "bitcoincash",
+		&TestNetParams:       "bchtest",
+		&RegressionNetParams: "bchreg",
+	} {
+		prefix, err := GetCashAddrPrefix(netParams)
+		require.NoError(t, err)
+		require.Equal(t, expected, prefix)
+	}
+
+	_, err := GetCashAddrPrefix(&chaincfg.MainNetParams)
+	require.Error(t, err)
+
+	// Dogecoin regtest shares network magic with Bitcoin Cash regtest
+	require.Equal(t, RegressionNetParams.Net, dogecoin.RegressionNetParams.Net)
+	_, err = GetCashAddrPrefix(&dogecoin.RegressionNetParams)
+	require.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package bitcoincash
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign"
+)
+
+// CashAddr type bits in the version byte
+// CashAddr 版本字节中的类型位
+const (
+	cashAddrTypeP2PKH = 0
+	cashAddrTypeP2SH  = 1
+)
+
+// cashAddrCharset is the base32 alphabet of CashAddr, same as bech32
+// cashAddrCharset 是 CashAddr 的 base32 字母表，和 bech32 相同
+const cashAddrCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// EncodeCashAddress encodes P2PKH or P2SH address into CashAddr with network prefix, such as bitcoincash:qp...
+//
+// EncodeCashAddress 把 P2PKH 或 P2SH 地址编码为带网络前缀的 CashAddr，比如 bitcoincash:qp...
+func EncodeCashAddress(address btcutil.Address, netParams *chaincfg.Params) (string, error) {
+	prefix, err := GetCashAddrPrefix(netParams)
+	if err != nil {
+		return "", err
+	}
+	var addrType byte
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		addrType = cashAddrTypeP2PKH
+	case *btcutil.AddressScriptHash:
+		addrType = cashAddrTypeP2SH
+	default:
+		return "", errors.WithMessagef(gobtcsign.ErrUnsupportedAddressType, "wrong address=%s cannot be encoded as cash-addr", address.String())
+	}
+	// Version byte has size bits 0 which means 160-bit hash
+	// 版本字节的长度位是 0，表示 160 位的哈希
+	payload, err := bech32.ConvertBits(append([]byte{addrType << 3}, address.ScriptAddress()...), 8, 5, true)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong convert-bits")
+	}
+	checksum := cashAddrPolyMod(append(append(cashAddrPrefixData(prefix), payload...), 0, 0, 0, 0, 0, 0, 0, 0))
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteByte(':')
+	for _, v := range payload {
+		sb.WriteByte(cashAddrCharset[v])
+	}
+	for i := 0; i < 8; i++ {
+		sb.WriteByte(cashAddrCharset[(checksum>>uint(5*(7-i)))&0x1f])
+	}
+	return sb.String(), nil
+}
+
+// DecodeCashAddress decodes CashAddr into P2PKH or P2SH address on network, the prefix can be omitted
+//
+// DecodeCashAddress 把 CashAddr 解析为网络上的 P2PKH 或 P2SH 地址，前缀可以省略
+func DecodeCashAddress(address string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	prefix, err := GetCashAddrPrefix(netParams)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return nil, errors.Errorf("wrong cash-addr=%s has mixed case", address)
+	}
+	lower := strings.ToLower(address)
+	payloadString := lower
+	if pos := strings.LastIndexByte(lower, ':'); pos >= 0 {
+		if lower[:pos] != prefix {
+			return nil, errors.Errorf("wrong cash-addr=%s prefix, expected %s", address, prefix)
+		}
+		payloadString = lower[pos+1:]
+	}
+	if len(payloadString) <= 8 {
+		return nil, errors.Errorf("wrong cash-addr=%s is too short", address)
+	}
+	payload := make([]byte, 0, len(payloadString))
+	for _, c := range payloadString {
+		v := strings.IndexRune(cashAddrCharset, c)
+		if v < 0 {
+			return nil, errors.Errorf("wrong cash-addr=%s has invalid character %q", address, c)
+		}
+		payload = append(payload, byte(v))
+	}
+	if cashAddrPolyMod(append(cashAddrPrefixData(prefix), payload...)) != 0 {
+		return nil, errors.Errorf("wrong cash-addr=%s checksum", address)
+	}
+	data, err := bech32.ConvertBits(payload[:len(payload)-8], 5, 8, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong convert-bits")
+	}
+	if len(data) != 21 || data[0]&0x07 != 0 {
+		return nil, errors.Errorf("wrong cash-addr=%s only 160-bit hash is supported", address)
+	}
+	switch data[0] >> 3 {
+	case cashAddrTypeP2PKH:
+		return btcutil.NewAddressPubKeyHash(data[1:], netParams)
+	case cashAddrTypeP2SH:
+		return btcutil.NewAddressScriptHashFromHash(data[1:], netParams)
+	default:
+		return nil, errors.WithMessagef(gobtcsign.ErrUnsupportedAddressType, "wrong cash-addr=%s type=%d", address, data[0]>>3)
+	}
+}
+
+// DecodeAddress decodes CashAddr or legacy base58 address, only P2PKH and P2SH exist on Bitcoin Cash
+//
+// DecodeAddress 解析 CashAddr 或传统的 base58 地址，比特币现金上只有 P2PKH 和 P2SH
+func DecodeAddress(address string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	if res, err := DecodeCashAddress(address, netParams); err == nil {
+		return res, nil
+	} else if strings.Contains(address, ":") {
+		return nil, err
+	}
+	res, err := btcutil.DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode-address")
+	}
+	switch res.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressScriptHash:
+	default:
+		return nil, errors.WithMessagef(gobtcsign.ErrUnsupportedAddressType, "wrong address=%s on bitcoin cash", address)
+	}
+	if !res.IsForNet(netParams) {
+		return nil, errors.Errorf("wrong address=%s is not for network=%s", address, netParams.Name)
+	}
+	return res, nil
+}
+
+// NewAddressTuple decodes CashAddr or legacy address into AddressTuple
+// Address holds the legacy form, since gobtcsign decodes addresses with btcutil, PkScript holds the script
+//
+// NewAddressTuple 把 CashAddr 或传统地址解析为 AddressTuple
+// 由于 gobtcsign 使用 btcutil 解析地址，因此 Address 存放传统格式，PkScript 存放脚本
+func NewAddressTuple(address string, netParams *chaincfg.Params) (*gobtcsign.AddressTuple, error) {
+	res, err := DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(res)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong get-pk-script")
+	}
+	return &gobtcsign.AddressTuple{Address: res.EncodeAddress(), PkScript: pkScript}, nil
+}
+
+// cashAddrPrefixData returns lower 5 bits of each prefix character followed by separator zero
+// cashAddrPrefixData 返回前缀每个字符的低 5 位，最后是分隔符 0
+func cashAddrPrefixData(prefix string) []byte {
+	data := make([]byte, 0, len(prefix)+1)
+	for i := 0; i < len(prefix); i++ {
+		data = append(data, prefix[i]&0x1f)
+	}
+	return append(data, 0)
+}
+
+// cashAddrPolyMod computes 40-bit BCH checksum defined by CashAddr spec
+// cashAddrPolyMod 计算 CashAddr 规范定义的 40 位 BCH 校验和
+func cashAddrPolyMod(values []byte) uint64 {
+	var c uint64 = 1
+	for _, d := range values {
+		c0 := byte(c >> 35)
+		c = ((c & 0x07ffffffff) << 5) ^ uint64(d)
+		if c0&0x01 != 0 {
+			c ^= 0x98f2bc8e61
+		}
+		if c0&0x02 != 0 {
+			c ^= 0x79b76d99e2
+		}
+		if c0&0x04 != 0 {
+			c ^= 0xf33e5fb3c4
+		}
+		if c0&0x08 != 0 {
+			c ^= 0xae2eabe2a8
+		}
+		if c0&0x10 != 0 {
+			c ^= 0x1e4f43e470
+		}
+	}
+	return c ^ 1
+}
@@ -0,0 +1,111 @@
+package bitcoincash
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEncodeCashAddress_SpecVectors validates encoding against test vectors of the CashAddr spec
+//
+// TestEncodeCashAddress_SpecVectors 使用 CashAddr 规范的测试向量验证编码
+func TestEncodeCashAddress_SpecVectors(t *testing.T) {
+	hash, err := hex.DecodeString("f5bf48b397dae70be82b3cca4793f8eb2b6cdac9")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		netParams *chaincfg.Params
+		isP2SH    bool
+		expected  string
+	}{
+		{&MainNetParams, false, "bitcoincash:qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg2"},
+		{&TestNetParams, true, "bchtest:pr6m7j9njldwwzlg9v7v53unlr4jkmx6eyvwc0uz5t"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			var address btcutil.Address
+			if tc.isP2SH {
+				address, err = btcutil.NewAddressScriptHashFromHash(hash, tc.netParams)
+			} else {
+				address, err = btcutil.NewAddressPubKeyHash(hash, tc.netParams)
+			}
+			require.NoError(t, err)
+
+			res, err := EncodeCashAddress(address, tc.netParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res)
+
+			decoded, err := DecodeCashAddress(tc.expected, tc.netParams)
+			require.NoError(t, err)
+			require.Equal(t, hash, decoded.ScriptAddress())
+		})
+	}
+}
+
+// TestDecodeAddress_LegacyConversion validates CashAddr and legacy forms decode into the same address
+//
+// TestDecodeAddress_LegacyConversion 验证 CashAddr 和传统格式解析为同一个地址
+func TestDecodeAddress_LegacyConversion(t *testing.T) {
+	testCases := []struct {
+		legacy   string
+		cashAddr string
+	}{
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"1KXrWXciRDZUpQwQmuM1DbwsKDLYAYsVLR", "bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy"},
+		{"16w1D5WRVKJuZUsSRzdLp9w3YGcgoxDXb", "bitcoincash:qqq3728yw0y47sqn6l2na30mcw6zm78dzqre909m2r"},
+		{"3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.legacy, func(t *testing.T) {
+			fromCash, err := DecodeAddress(tc.cashAddr, &MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.legacy, fromCash.EncodeAddress())
+
+			fromLegacy, err := DecodeAddress(tc.legacy, &MainNetParams)
+			require.NoError(t, err)
+			cashAddr, err := EncodeCashAddress(fromLegacy, &MainNetParams)
+			require.NoError(t, err)
+			require.Equal(t, tc.cashAddr, cashAddr)
+		})
+	}
+}
+
+// TestDecodeCashAddress_Forms validates prefix-less and upper case forms, and rejects bad input
+//
+// TestDecodeCashAddress_Forms 验证无前缀和大写的格式，并拒绝错误的输入
+func TestDecodeCashAddress_Forms(t *testing.T) {
+	const expected = "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu"
+
+	for _, address := range []string{
+		"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		"BITCOINCASH:QPM2QSZNHKS23Z7629MMS6S4CWEF74VCWVY22GDX6A",
+	} {
+		res, err := DecodeCashAddress(address, &MainNetParams)
+		require.NoError(t, err)
+		require.Equal(t, expected, res.EncodeAddress())
+	}
+
+	for _, address := range []string{
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b",  // bad checksum
+		"bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",      // wrong prefix
+		"bitcoincash:Qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",  // mixed case
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6ab", // bad length
+	} {
+		_, err := DecodeCashAddress(address, &MainNetParams)
+		require.Error(t, err, address)
+	}
+}
+
+// TestNewAddressTuple validates tuple holds legacy address and matching pk-script
+//
+// TestNewAddressTuple 验证元组包含传统地址和匹配的公钥脚本
+func TestNewAddressTuple(t *testing.T) {
+	tuple, err := NewAddressTuple("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", &MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", tuple.Address)
+	require.Equal(t, "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac", hex.EncodeToString(tuple.PkScript))
+	require.NoError(t, tuple.VerifyMatch(&MainNetParams))
+}
@@ -0,0 +1,278 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// Broadcaster defines interface to send signed transaction to the network
+// Implemented by BitcoindBroadcaster, EsploraClient, ElectrumClient and FakeBroadcaster
+//
+// Broadcaster 定义把已签名交易发送到网络的接口
+// 由 BitcoindBroadcaster、EsploraClient、ElectrumClient 和 FakeBroadcaster 实现
+type Broadcaster interface {
+	Broadcast(ctx context.Context, msgTx *wire.MsgTx) (string, error)
+}
+
+// Typed reject reasons, check them with errors.Is on the error returned by Broadcast
+//
+// 类型化的拒绝原因，使用 errors.Is 检查 Broadcast 返回的错误
+var (
+	ErrRejectMinRelayFee     = errors.New("min relay fee not met") // Fee rate below node min relay fee // 费率低于节点的最低转发费率
+	ErrRejectDust            = errors.New("dust")                  // Output below dust limit // 输出低于粉尘限制
+	ErrRejectMempoolConflict = errors.New("txn-mempool-conflict")  // Input spent by another mempool tx without RBF // 输入已被内存池里另一个不可替换的交易花费
+	ErrRejectNonBIP68Final   = errors.New("non-BIP68-final")       // Relative lock time not reached // 相对锁定时间尚未达到
+)
+
+// BroadcastRejectError represents transaction rejected by node with reject reason
+// Unwraps to one of the ErrReject* values when the reason is known, otherwise to nil
+//
+// BroadcastRejectError 代表被节点拒绝的交易以及拒绝原因
+// 当原因已知时解包为某个 ErrReject* 值，否则解包为 nil
+type BroadcastRejectError struct {
+	Txid   string // Rejected tx hash // 被拒绝的交易哈希
+	Reason string // Reject reason given by node // 节点给出的拒绝原因
+	Err    error  // Typed reason, nil when unknown // 类型化的原因，未知时为 nil
+}
+
+// NewBroadcastRejectError creates BroadcastRejectError and matches reason with typed errors
+//
+// NewBroadcastRejectError 创建 BroadcastRejectError 并把原因匹配到类型化的错误
+func NewBroadcastRejectError(txid string, reason string) *BroadcastRejectError {
+	var typed error
+	for _, one := range []error{ErrRejectMinRelayFee, ErrRejectMempoolConflict, ErrRejectNonBIP68Final, ErrRejectDust} {
+		if strings.Contains(reason, one.Error()) {
+			typed = one
+			break
+		}
+	}
+	return &BroadcastRejectError{Txid: txid, Reason: reason, Err: typed}
+}
+
+func (e *BroadcastRejectError) Error() string {
+	return fmt.Sprintf("wrong broadcast txid=%s reject-reason=%s", e.Txid, e.Reason)
+}
+
+func (e *BroadcastRejectError) Unwrap() error {
+	return e.Err
+}
+
+// isAlreadyKnownReason tells whether the node already has the tx, broadcasting again is then a success
+// isAlreadyKnownReason 判断节点是否已经有该交易，此时再次广播视为成功
+func isAlreadyKnownReason(reason string) bool {
+	for _, one := range []string{"txn-already-in-mempool", "txn-already-known", "Transaction already in block chain", "Transaction outputs already in utxo set"} {
+		if strings.Contains(reason, one) {
+			return true
+		}
+	}
+	return false
+}
+
+// BitcoindBroadcaster implements Broadcaster using bitcoind RPC client
+// Runs testmempoolaccept first so reject reasons come back typed without touching the mempool
+//
+// BitcoindBroadcaster 使用 bitcoind RPC 客户端实现 Broadcaster
+// 先执行 testmempoolaccept，在不改动内存池的情况下返回类型化的拒绝原因
+type BitcoindBroadcaster struct {
+	client   *rpcclient.Client // Bitcoin RPC client // 比特币 RPC 客户端
+	preCheck bool              // Run testmempoolaccept before sending // 发送前执行 testmempoolaccept
+}
+
+// NewBitcoindBroadcaster creates BitcoindBroadcaster with RPC client
+// Set preCheck false for nodes without testmempoolaccept (added in Bitcoin Core 0.17, absent in Dogecoin Core 1.14)
+//
+// NewBitcoindBroadcaster 使用 RPC 客户端创建 BitcoindBroadcaster
+// 对于不支持 testmempoolaccept 的节点（比特币 Core 0.17 引入，狗狗币 Core 1.14 不支持）需要把 preCheck 设为 false
+func NewBitcoindBroadcaster(client *rpcclient.Client, preCheck bool) *BitcoindBroadcaster {
+	return &BitcoindBroadcaster{client: client, preCheck: preCheck}
+}
+
+// TestMempoolAccept asks node whether tx would be accepted, returns BroadcastRejectError when not
+//
+// TestMempoolAccept 询问节点是否会接受交易，不接受时返回 BroadcastRejectError
+func (b *BitcoindBroadcaster) TestMempoolAccept(ctx context.Context, msgTx *wire.MsgTx) error {
+	txHex, err := CvtMsgTxToHex(msgTx)
+	if err != nil {
+		return errors.WithMessage(err, "wrong cvt-msg-tx-to-hex")
+	}
+	param, err := json.Marshal([]string{txHex})
+	if err != nil {
+		return errors.WithMessage(err, "wrong marshal-param")
+	}
+	data, err := receiveWithContext(ctx, b.client.RawRequestAsync("testmempoolaccept", []json.RawMessage{param}).Receive)
+	if err != nil {
+		return errors.WithMessage(err, "wrong test-mempool-accept")
+	}
+	var results []*btcjson.TestMempoolAcceptResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return errors.WithMessage(err, "wrong unmarshal-result")
+	}
+	if len(results) != 1 {
+		return errors.Errorf("wrong test-mempool-accept result count=%d", len(results))
+	}
+	if !results[0].Allowed {
+		return NewBroadcastRejectError(GetTxHash(msgTx), results[0].RejectReason)
+	}
+	return nil
+}
+
+// Broadcast runs the pre-check and then sendrawtransaction, returns txid
+// Broadcasting a tx the node already has is treated as success
+//
+// Broadcast 执行预检后再执行 sendrawtransaction，返回交易哈希
+// 广播节点已经有的交易视为成功
+func (b *BitcoindBroadcaster) Broadcast(ctx context.Context, msgTx *wire.MsgTx) (string, error) {
+	txid := GetTxHash(msgTx)
+	if b.preCheck {
+		if err := b.TestMempoolAccept(ctx, msgTx); err != nil {
+			var rejectErr *BroadcastRejectError
+			if errors.As(err, &rejectErr) && isAlreadyKnownReason(rejectErr.Reason) {
+				return txid, nil
+			}
+			return "", err
+		}
+	}
+
+	txHex, err := CvtMsgTxToHex(msgTx)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong cvt-msg-tx-to-hex")
+	}
+	param, err := json.Marshal(txHex)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong marshal-param")
+	}
+	data, err := receiveWithContext(ctx, b.client.RawRequestAsync("sendrawtransaction", []json.RawMessage{param}).Receive)
+	if err != nil {
+		var rpcErr *btcjson.RPCError
+		if errors.As(err, &rpcErr) {
+			if isAlreadyKnownReason(rpcErr.Message) {
+				return txid, nil
+			}
+			return "", NewBroadcastRejectError(txid, rpcErr.Message)
+		}
+		return "", errors.WithMessage(err, "wrong send-raw-transaction")
+	}
+	var res string
+	if err := json.Unmarshal(data, &res); err != nil {
+		return "", errors.WithMessage(err, "wrong unmarshal-result")
+	}
+	if res != txid {
+		return "", errors.Errorf("wrong broadcast txid mismatch: got %s, expected %s", res, txid)
+	}
+	return txid, nil
+}
+
+// FakeBroadcaster implements Broadcaster in memory, used in tests instead of a node
+// Rejects txs spending inputs already spent by another accepted tx, like a mempool without RBF
+//
+// FakeBroadcaster 在内存中实现 Broadcaster，在测试中代替节点使用
+// 像不支持 RBF 的内存池一样，拒绝花费已被其它已接受交易花费的输入的交易
+type FakeBroadcaster struct {
+	mutex   sync.Mutex               // Protects fields below // 保护以下字段
+	txs     map[string]*wire.MsgTx   // Accepted txs // 已接受的交易
+	txids   []string                 // Accepted order // 接受的顺序
+	spentBy map[wire.OutPoint]string // Spent outpoints -> txid // 已花费的输出 -> 交易哈希
+	err     error                    // Error returned by next broadcast // 下次广播返回的错误
+}
+
+// NewFakeBroadcaster creates empty FakeBroadcaster
+//
+// NewFakeBroadcaster 创建空的 FakeBroadcaster
+func NewFakeBroadcaster() *FakeBroadcaster {
+	return &FakeBroadcaster{
+		txs:     map[string]*wire.MsgTx{},
+		spentBy: map[wire.OutPoint]string{},
+	}
+}
+
+// SetNextError makes the next broadcast fail with err, such as NewBroadcastRejectError result
+//
+// SetNextError 让下次广播以 err 失败，比如 NewBroadcastRejectError 的结果
+func (f *FakeBroadcaster) SetNextError(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.err = err
+}
+
+// Broadcast accepts tx into memory and returns txid
+//
+// Broadcast 把交易接受到内存中并返回交易哈希
+func (f *FakeBroadcaster) Broadcast(ctx context.Context, msgTx *wire.MsgTx) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	txid := GetTxHash(msgTx)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.err; err != nil {
+		f.err = nil
+		return "", err
+	}
+	if _, ok := f.txs[txid]; ok {
+		return txid, nil
+	}
+	for _, txIn := range msgTx.TxIn {
+		if _, ok := f.spentBy[txIn.PreviousOutPoint]; ok {
+			return "", NewBroadcastRejectError(txid, ErrRejectMempoolConflict.Error())
+		}
+	}
+	for _, txIn := range msgTx.TxIn {
+		f.spentBy[txIn.PreviousOutPoint] = txid
+	}
+	f.txs[txid] = msgTx.Copy()
+	f.txids = append(f.txids, txid)
+	return txid, nil
+}
+
+// GetTx returns accepted tx by txid
+//
+// GetTx 根据交易哈希返回已接受的交易
+func (f *FakeBroadcaster) GetTx(txid string) (*wire.MsgTx, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	msgTx, ok := f.txs[txid]
+	if !ok {
+		return nil, false
+	}
+	return msgTx.Copy(), true
+}
+
+// ListTxids returns txids of accepted txs in broadcast order
+//
+// ListTxids 按广播顺序返回已接受交易的哈希
+func (f *FakeBroadcaster) ListTxids() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.txids...)
+}
+
+// Remove drops accepted tx and frees its inputs, simulating mempool eviction
+//
+// Remove 删除已接受的交易并释放其输入，用于模拟被内存池驱逐
+func (f *FakeBroadcaster) Remove(txid string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	msgTx, ok := f.txs[txid]
+	if !ok {
+		return
+	}
+	for _, txIn := range msgTx.TxIn {
+		delete(f.spentBy, txIn.PreviousOutPoint)
+	}
+	delete(f.txs, txid)
+	for idx, one := range f.txids {
+		if one == txid {
+			f.txids = append(f.txids[:idx], f.txids[idx+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMempoolBitcoind serves testmempoolaccept and sendrawtransaction with configured reject reason
+type fakeMempoolBitcoind struct {
+	mutex        sync.Mutex
+	rejectReason string   // reject reason of testmempoolaccept and sendrawtransaction, empty means accepted
+	methods      []string // methods called in order
+}
+
+func (f *fakeMempoolBitcoind) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request fakeRpcRequest
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.methods = append(f.methods, request.Method)
+
+	var txid string
+	switch request.Method {
+	case "testmempoolaccept":
+		var txHexes []string
+		_ = json.Unmarshal(request.Params[0], &txHexes)
+		msgTx, _ := NewMsgTxFromHex(txHexes[0])
+		txid = GetTxHash(msgTx)
+		result := &btcjson.TestMempoolAcceptResult{Txid: txid, Allowed: f.rejectReason == "", RejectReason: f.rejectReason}
+		_ = json.NewEncoder(w).Encode(&fakeRpcResponse{ID: request.ID, Result: []*btcjson.TestMempoolAcceptResult{result}})
+	case "sendrawtransaction":
+		var txHex string
+		_ = json.Unmarshal(request.Params[0], &txHex)
+		msgTx, _ := NewMsgTxFromHex(txHex)
+		if f.rejectReason != "" {
+			_ = json.NewEncoder(w).Encode(&fakeRpcResponse{ID: request.ID, Error: &btcjson.RPCError{Code: btcjson.ErrRPCVerifyRejected, Message: f.rejectReason}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&fakeRpcResponse{ID: request.ID, Result: GetTxHash(msgTx)})
+	}
+}
+
+func (f *fakeMempoolBitcoind) setRejectReason(reason string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.rejectReason = reason
+	f.methods = nil
+}
+
+func caseBroadcastMsgTx(t *testing.T) *wire.MsgTx {
+	const txHex = "010000000001011939727ec645869768167683487829f437cc37c664938345426d0df14e5df0e10200000000fdffffff02d204000000000000160014b3c4715e00a5ff9707ec7be2586e62d286ae4a18e80200000000000016001462152b40d8b2cbac358541d850c079ea10d1407f02483045022100e8269080acc14fd24ee13cbbdaa5ea34192f090c917b4ca3da44eda25badd58e02206813da9023bebd556a95e04e6a55c9a5fdf5dfb19746c896d7fd7f26aaa58878012102407ea64d7a9e992028a94481af95ea7d8f54870bd73e5878a014da594335ba3200000000"
+	msgTx, err := NewMsgTxFromHex(txHex)
+	require.NoError(t, err)
+	return msgTx
+}
+
+func TestBitcoindBroadcaster_Broadcast(t *testing.T) {
+	var _ Broadcaster = &BitcoindBroadcaster{}
+	var _ Broadcaster = &EsploraClient{}
+	var _ Broadcaster = &ElectrumClient{}
+	var _ Broadcaster = &FakeBroadcaster{}
+
+	fake := &fakeMempoolBitcoind{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := rpcclient.New(newFakeBitcoindConfig(server), nil)
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	msgTx := caseBroadcastMsgTx(t)
+	broadcaster := NewBitcoindBroadcaster(client, true)
+
+	txid, err := broadcaster.Broadcast(context.Background(), msgTx)
+	require.NoError(t, err)
+	require.Equal(t, GetTxHash(msgTx), txid)
+	require.Equal(t, []string{"testmempoolaccept", "sendrawtransaction"}, fake.methods)
+
+	for reason, expected := range map[string]error{
+		"min relay fee not met, 110 < 141": ErrRejectMinRelayFee,
+		"dust":                             ErrRejectDust,
+		"txn-mempool-conflict":             ErrRejectMempoolConflict,
+		"non-BIP68-final":                  ErrRejectNonBIP68Final,
+	} {
+		fake.setRejectReason(reason)
+		_, err := broadcaster.Broadcast(context.Background(), msgTx)
+		require.ErrorIs(t, err, expected)
+		var rejectErr *BroadcastRejectError
+		require.ErrorAs(t, err, &rejectErr)
+		require.Equal(t, reason, rejectErr.Reason)
+		// Rejected by the pre-check, the tx is never sent
+		require.Equal(t, []string{"testmempoolaccept"}, fake.methods)
+	}
+
+	// Unknown reasons are still reject errors, without typed reason
+	fake.setRejectReason("bad-txns-inputs-missingorspent")
+	_, err = broadcaster.Broadcast(context.Background(), msgTx)
+	var rejectErr *BroadcastRejectError
+	require.ErrorAs(t, err, &rejectErr)
+	require.Nil(t, rejectErr.Unwrap())
+
+	// Already known tx is a success
+	fake.setRejectReason("txn-already-in-mempool")
+	txid, err = broadcaster.Broadcast(context.Background(), msgTx)
+	require.NoError(t, err)
+	require.Equal(t, GetTxHash(msgTx), txid)
+
+	// Without pre-check the send error is mapped the same way
+	fake.setRejectReason("min relay fee not met")
+	_, err = NewBitcoindBroadcaster(client, false).Broadcast(context.Background(), msgTx)
+	require.ErrorIs(t, err, ErrRejectMinRelayFee)
+	require.Equal(t, []string{"sendrawtransaction"}, fake.methods)
+}
+
+func TestFakeBroadcaster_Broadcast(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	broadcaster := NewFakeBroadcaster()
+
+	txid, err := broadcaster.Broadcast(context.Background(), msgTx)
+	require.NoError(t, err)
+	txid2, err := broadcaster.Broadcast(context.Background(), msgTx)
+	require.NoError(t, err)
+	require.Equal(t, txid, txid2)
+	require.Equal(t, []string{txid}, broadcaster.ListTxids())
+
+	// Another tx spending the same input conflicts
+	conflictTx := msgTx.Copy()
+	conflictTx.TxOut[0].Value--
+	_, err = broadcaster.Broadcast(context.Background(), conflictTx)
+	require.ErrorIs(t, err, ErrRejectMempoolConflict)
+
+	// After eviction the input is free again
+	broadcaster.Remove(txid)
+	_, ok := broadcaster.GetTx(txid)
+	require.False(t, ok)
+	_, err = broadcaster.Broadcast(context.Background(), conflictTx)
+	require.NoError(t, err)
+
+	broadcaster.SetNextError(NewBroadcastRejectError(GetTxHash(msgTx), "dust"))
+	_, err = broadcaster.Broadcast(context.Background(), msgTx)
+	require.ErrorIs(t, err, ErrRejectDust)
+}
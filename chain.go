@@ -0,0 +1,291 @@
+package gobtcsign
+
+import (
+	"slices"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/dogecoin"
+	"github.com/yyle88/gobtcsign/litecoin"
+)
+
+// FeeUnit describes how chain charges fee rate against tx size
+//
+// FeeUnit 描述链如何根据交易大小收取费率
+type FeeUnit string
+
+const (
+	FeeUnitPerKvB       FeeUnit = "per-kvB"        // Charged per virtual byte, rate given per 1000 vbytes // 按虚拟字节收费，费率按每 1000 虚拟字节给出
+	FeeUnitPerStartedKB FeeUnit = "per-started-kB" // Charged per started 1000 bytes // 按每个开始的 1000 字节收费
+)
+
+// Chain bundles everything chain specific: params, dust rules, fee rules, supported address types and SegWit availability
+// One withdrawal service holds one Chain per network and calls its methods, instead of branching on chains
+// Use the constructors such as NewBitcoinMainNetChain, or fill the fields to support other chains
+//
+// Chain 打包链相关的全部内容：参数、灰尘规则、费用规则、支持的地址类型和 SegWit 可用性
+// 一个提现服务为每个网络持有一个 Chain 并调用它的方法，而不用按链分支处理
+// 使用 NewBitcoinMainNetChain 等构造函数，或者填写字段以支持其它链
+type Chain struct {
+	Name            string                                            // Unique name such as bitcoin-mainnet // 唯一名称，比如 bitcoin-mainnet
+	NetParams       *chaincfg.Params                                  // Network parameters // 网络参数
+	SegwitSupported bool                                              // Whether witness inputs and outputs are allowed // 是否允许见证输入和输出
+	AddressTypes    []txscript.ScriptClass                            // Script classes allowed in inputs and outputs // 输入和输出允许的脚本类型
+	FeeUnit         FeeUnit                                           // How fee rate is charged // 费率的收费方式
+	RelayFeePerKb   btcutil.Amount                                    // Min relay fee rate used by dust rules // 灰尘规则使用的最低转发费率
+	FeeRateBounds   FeeRateBounds                                     // Floor and ceiling of fee rate // 费率的下限和上限
+	DustLimit       *DustLimit                                        // Hard dust rule // 硬灰尘规则
+	DustFee         DustFee                                           // Soft dust surcharge // 软灰尘附加费
+	FeeCalculator   func(feeRatePerKb btcutil.Amount) TxFeeCalculator // Creates fee calculator with fee rate // 使用费率创建费用计算器
+	AddressDecoder  func(address string) (btcutil.Address, error)     // Chain specific address decoding, nil uses DecodeAddress // 链相关的地址解析，为 nil 时使用 DecodeAddress
+}
+
+// bitcoinAddressTypes lists script classes standard on Bitcoin
+// bitcoinAddressTypes 列出比特币上标准的脚本类型
+var bitcoinAddressTypes = []txscript.ScriptClass{
+	txscript.PubKeyHashTy,
+	txscript.ScriptHashTy,
+	txscript.WitnessV0PubKeyHashTy,
+	txscript.WitnessV0ScriptHashTy,
+	txscript.WitnessV1TaprootTy,
+	txscript.NullDataTy,
+}
+
+// NewBitcoinChain creates Chain with Bitcoin rules on network, such as chaincfg.TestNet3Params or a custom signet
+//
+// NewBitcoinChain 使用比特币规则在网络上创建 Chain，网络比如 chaincfg.TestNet3Params 或自定义的 signet
+func NewBitcoinChain(name string, netParams *chaincfg.Params) *Chain {
+	dustFee := NewDustFee()
+	return &Chain{
+		Name:            name,
+		NetParams:       netParams,
+		SegwitSupported: true,
+		AddressTypes:    bitcoinAddressTypes,
+		FeeUnit:         FeeUnitPerKvB,
+		RelayFeePerKb:   txrules.DefaultRelayFeePerKb,
+		FeeRateBounds:   NewFeeRateBounds(),
+		DustLimit:       NewDustLimit(),
+		DustFee:         dustFee,
+		FeeCalculator: func(feeRatePerKb btcutil.Amount) TxFeeCalculator {
+			return NewBitcoinFeeCalculator(feeRatePerKb, dustFee)
+		},
+	}
+}
+
+// NewBitcoinMainNetChain creates Chain of Bitcoin mainnet
+//
+// NewBitcoinMainNetChain 创建比特币主网的 Chain
+func NewBitcoinMainNetChain() *Chain {
+	return NewBitcoinChain("bitcoin-mainnet", &chaincfg.MainNetParams)
+}
+
+// NewBitcoinTestNetChain creates Chain of Bitcoin testnet3
+//
+// NewBitcoinTestNetChain 创建比特币测试网 testnet3 的 Chain
+func NewBitcoinTestNetChain() *Chain {
+	return NewBitcoinChain("bitcoin-testnet3", &chaincfg.TestNet3Params)
+}
+
+// NewBitcoinSigNetChain creates Chain of Bitcoin default signet
+//
+// NewBitcoinSigNetChain 创建比特币默认 signet 的 Chain
+func NewBitcoinSigNetChain() *Chain {
+	return NewBitcoinChain("bitcoin-signet", &chaincfg.SigNetParams)
+}
+
+// NewBitcoinRegTestChain creates Chain of Bitcoin regtest
+//
+// NewBitcoinRegTestChain 创建比特币回归测试网的 Chain
+func NewBitcoinRegTestChain() *Chain {
+	return NewBitcoinChain("bitcoin-regtest", &chaincfg.RegressionNetParams)
+}
+
+// NewDogecoinChain creates Chain with Dogecoin relay policy on network
+// Dogecoin has no SegWit and charges fee per started kB plus soft dust surcharge
+//
+// NewDogecoinChain 使用狗狗币转发策略在网络上创建 Chain
+// 狗狗币没有 SegWit，按每个开始的 kB 收费并加上软灰尘附加费
+func NewDogecoinChain(name string, netParams *chaincfg.Params, policy *dogecoin.RelayPolicy) *Chain {
+	return &Chain{
+		Name:            name,
+		NetParams:       netParams,
+		SegwitSupported: false,
+		AddressTypes:    []txscript.ScriptClass{txscript.PubKeyHashTy, txscript.ScriptHashTy, txscript.NullDataTy},
+		FeeUnit:         FeeUnitPerStartedKB,
+		RelayFeePerKb:   policy.MinRelayFeePerKb,
+		FeeRateBounds:   dogecoin.NewDogeFeeRateBoundsV2(policy),
+		DustLimit:       dogecoin.NewDogeDustLimitV2(policy),
+		DustFee:         dogecoin.NewDogeDustFeeV2(policy),
+		FeeCalculator: func(feeRatePerKb btcutil.Amount) TxFeeCalculator {
+			return dogecoin.NewDogeFeeCalculatorV2(policy, feeRatePerKb)
+		},
+	}
+}
+
+// NewDogecoinMainNetChain creates Chain of Dogecoin mainnet with default relay policy
+//
+// NewDogecoinMainNetChain 使用默认转发策略创建狗狗币主网的 Chain
+func NewDogecoinMainNetChain() *Chain {
+	return NewDogecoinChain("dogecoin-mainnet", &dogecoin.MainNetParams, dogecoin.DefaultRelayPolicy())
+}
+
+// NewDogecoinTestNetChain creates Chain of Dogecoin testnet with default relay policy
+//
+// NewDogecoinTestNetChain 使用默认转发策略创建狗狗币测试网的 Chain
+func NewDogecoinTestNetChain() *Chain {
+	return NewDogecoinChain("dogecoin-testnet", &dogecoin.TestNetParams, dogecoin.DefaultRelayPolicy())
+}
+
+// NewLitecoinChain creates Chain with Litecoin rules on network
+// Addresses decode with litecoin.DecodeAddress, so MWEB destinations are rejected with ErrUnsupportedAddressType
+//
+// NewLitecoinChain 使用莱特币规则在网络上创建 Chain
+// 地址使用 litecoin.DecodeAddress 解析，因此 MWEB 目标地址会以 ErrUnsupportedAddressType 拒绝
+func NewLitecoinChain(name string, netParams *chaincfg.Params) *Chain {
+	dustFee := litecoin.NewLtcDustFee()
+	return &Chain{
+		Name:            name,
+		NetParams:       netParams,
+		SegwitSupported: true,
+		AddressTypes:    bitcoinAddressTypes,
+		FeeUnit:         FeeUnitPerKvB,
+		RelayFeePerKb:   litecoin.MinRelayFeePerKb,
+		FeeRateBounds:   litecoin.NewLtcFeeRateBounds(),
+		DustLimit:       litecoin.NewLtcDustLimit(),
+		DustFee:         dustFee,
+		FeeCalculator: func(feeRatePerKb btcutil.Amount) TxFeeCalculator {
+			return NewBitcoinFeeCalculator(feeRatePerKb, dustFee)
+		},
+		AddressDecoder: func(address string) (btcutil.Address, error) {
+			res, err := litecoin.DecodeAddress(address, netParams)
+			if errors.Is(err, litecoin.ErrMwebAddress) {
+				return nil, errors.WithMessagef(ErrUnsupportedAddressType, "wrong address=%s on %s: %v", address, name, err)
+			}
+			return res, err
+		},
+	}
+}
+
+// NewLitecoinMainNetChain creates Chain of Litecoin mainnet
+//
+// NewLitecoinMainNetChain 创建莱特币主网的 Chain
+func NewLitecoinMainNetChain() *Chain {
+	return NewLitecoinChain("litecoin-mainnet", &litecoin.MainNetParams)
+}
+
+// NewLitecoinTestNetChain creates Chain of Litecoin testnet4
+//
+// NewLitecoinTestNetChain 创建莱特币测试网 testnet4 的 Chain
+func NewLitecoinTestNetChain() *Chain {
+	return NewLitecoinChain("litecoin-testnet4", &litecoin.TestNetParams)
+}
+
+// DecodeAddress decodes address on the chain network, with AddressDecoder when set
+//
+// DecodeAddress 在链的网络上解析地址，设置了 AddressDecoder 时使用它
+func (c *Chain) DecodeAddress(address string) (btcutil.Address, error) {
+	if c.AddressDecoder != nil {
+		return c.AddressDecoder(address)
+	}
+	return DecodeAddress(address, c.NetParams)
+}
+
+// CheckPolicy checks address types, SegWit availability and hard dust of tx params against the chain
+//
+// CheckPolicy 根据链检查交易参数的地址类型、SegWit 可用性和硬灰尘
+func (c *Chain) CheckPolicy(param *BitcoinTxParams) error {
+	for idx, input := range param.VinList {
+		if err := c.checkAddress(input.Sender.Address); err != nil {
+			return errors.WithMessagef(err, "wrong sender.address. index=%d", idx)
+		}
+		pkScript, err := input.Sender.GetPkScript(c.NetParams)
+		if err != nil {
+			return errors.WithMessagef(err, "wrong sender.address->pk-script. index=%d", idx)
+		}
+		if err := c.checkPkScript(pkScript); err != nil {
+			return errors.WithMessagef(err, "wrong input. index=%d", idx)
+		}
+	}
+	for idx, output := range param.OutList {
+		if err := c.checkAddress(output.Target.Address); err != nil {
+			return errors.WithMessagef(err, "wrong target.address. index=%d", idx)
+		}
+	}
+	outputs, err := param.GetOutputs(c.NetParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong get-outputs")
+	}
+	for idx, output := range outputs {
+		if err := c.checkPkScript(output.PkScript); err != nil {
+			return errors.WithMessagef(err, "wrong output. index=%d", idx)
+		}
+	}
+	return param.CheckDustOutputs(c.NetParams, c.DustLimit, c.RelayFeePerKb)
+}
+
+// checkAddress rejects address the chain cannot decode, empty address is left to the pk-script checks
+// checkAddress 拒绝链无法解析的地址，空地址留给公钥脚本的检查
+func (c *Chain) checkAddress(address string) error {
+	if address == "" {
+		return nil
+	}
+	if _, err := c.DecodeAddress(address); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkPkScript rejects script classes the chain does not support
+// checkPkScript 拒绝链不支持的脚本类型
+func (c *Chain) checkPkScript(pkScript []byte) error {
+	if !c.SegwitSupported && txscript.IsWitnessProgram(pkScript) {
+		return errors.WithMessagef(ErrUnsupportedAddressType, "wrong witness script=%x on %s without segwit", pkScript, c.Name)
+	}
+	if scriptClass := txscript.GetScriptClass(pkScript); !slices.Contains(c.AddressTypes, scriptClass) {
+		return errors.WithMessagef(ErrUnsupportedAddressType, "wrong script=%x script_class=%s on %s", pkScript, scriptClass, c.Name)
+	}
+	return nil
+}
+
+// EstimateTxFee clamps fee rate into FeeRateBounds and estimates fee with the chain fee calculator
+//
+// EstimateTxFee 把费率限制在 FeeRateBounds 范围内，并使用链的费用计算器预估费用
+func (c *Chain) EstimateTxFee(param *BitcoinTxParams, change *ChangeTo, feeRatePerKb btcutil.Amount) (btcutil.Amount, error) {
+	return EstimateTxFeeWithCalculator(param, c.NetParams, change, c.FeeCalculator(c.FeeRateBounds.Clamp(feeRatePerKb)))
+}
+
+// CalcFee returns fee of tx with size and outputs under the chain fee rules, with fee rate clamped into FeeRateBounds
+//
+// CalcFee 按照链的费用规则返回指定大小和输出的交易的费用，费率会被限制在 FeeRateBounds 范围内
+func (c *Chain) CalcFee(txSize int, outputs []*wire.TxOut, feeRatePerKb btcutil.Amount) btcutil.Amount {
+	return c.FeeCalculator(c.FeeRateBounds.Clamp(feeRatePerKb)).CalcFee(txSize, outputs)
+}
+
+// CreateTxSignParams checks policy of the chain and creates SignParam on the chain network
+//
+// CreateTxSignParams 检查链的策略并在链的网络上创建 SignParam
+func (c *Chain) CreateTxSignParams(param *BitcoinTxParams) (*SignParam, error) {
+	if err := c.CheckPolicy(param); err != nil {
+		return nil, errors.WithMessage(err, "wrong check-policy")
+	}
+	return param.CreateTxSignParams(c.NetParams)
+}
+
+// Sign signs SignParam created on the chain network
+//
+// Sign 签名在链的网络上创建的 SignParam
+func (c *Chain) Sign(senderAddress string, privateKeyHex string, signParam *SignParam) error {
+	if signParam.NetParams.Net != c.NetParams.Net {
+		return errors.Errorf("wrong sign-param network=%s, expected chain=%s", signParam.NetParams.Name, c.Name)
+	}
+	if !c.SegwitSupported {
+		if err := checkNoWitness(signParam.MsgTx, signParam.InputOuts, c.Name); err != nil {
+			return err
+		}
+	}
+	return Sign(senderAddress, privateKeyHex, signParam)
+}
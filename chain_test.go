@@ -0,0 +1,120 @@
+package gobtcsign
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// caseChainDogeTxParams returns Dogecoin testnet tx params with one P2PKH input
+// caseChainDogeTxParams 返回狗狗币测试网上有一个 P2PKH 输入的交易参数
+func caseChainDogeTxParams() *BitcoinTxParams {
+	const senderAddress = "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC"
+	return &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("57a3514865d3f4c5cbd49270204aaf4928c4c10651430dcd0cb79b80cda5ef0b", 0),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   6799372,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("ng4P16anXNUrQw6VKHmoMW8NHsTkFBdNrn"),
+				Amount: 5000000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+}
+
+// TestChain_BuildAndSign validates one code path builds, signs and verifies txs on several chains
+//
+// TestChain_BuildAndSign 验证同一段代码能在多条链上构建、签名和验证交易
+func TestChain_BuildAndSign(t *testing.T) {
+	testCases := []struct {
+		chain         *Chain
+		param         *BitcoinTxParams
+		senderAddress string
+		privateKeyHex string
+	}{
+		{NewBitcoinTestNetChain(), caseErrsTxParams(), "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"},
+		{NewDogecoinTestNetChain(), caseChainDogeTxParams(), "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", "5f397bc72377b75db7b008a9c3fcd71651bfb138d6fc2458bb0279b9cfc8442a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.chain.Name, func(t *testing.T) {
+			signParam, err := tc.chain.CreateTxSignParams(tc.param)
+			require.NoError(t, err)
+			require.NoError(t, tc.chain.Sign(tc.senderAddress, tc.privateKeyHex, signParam))
+			require.NoError(t, tc.param.VerifyMsgTxSign(signParam.MsgTx, tc.chain.NetParams))
+
+			fee, err := tc.chain.EstimateTxFee(tc.param, NewNoChange(), 0)
+			require.NoError(t, err)
+			require.Positive(t, int64(fee))
+		})
+	}
+
+	// SignParam created on other network is rejected
+	// 在其它网络上创建的 SignParam 会被拒绝
+	signParam, err := NewBitcoinTestNetChain().CreateTxSignParams(caseErrsTxParams())
+	require.NoError(t, err)
+	err = NewDogecoinTestNetChain().Sign("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092", signParam)
+	require.Error(t, err)
+}
+
+// TestChain_CheckPolicy validates witness, taproot and dust outputs against chain rules
+//
+// TestChain_CheckPolicy 根据链的规则验证见证、taproot 和灰尘输出
+func TestChain_CheckPolicy(t *testing.T) {
+	dogeChain := NewDogecoinTestNetChain()
+
+	param := caseChainDogeTxParams()
+	require.NoError(t, dogeChain.CheckPolicy(param))
+
+	param.OutList[0].Target = *NewAddressTuple("doget1qknwmnkmgqcdqlmys5j72auslstyvlg0twvv68k")
+	require.True(t, errors.Is(dogeChain.CheckPolicy(param), ErrUnsupportedAddressType))
+
+	param = caseChainDogeTxParams()
+	param.OutList[0].Amount = 99999 // below hard dust 0.001 DOGE // 低于 0.001 DOGE 的硬灰尘
+	require.True(t, errors.Is(dogeChain.CheckPolicy(param), ErrDustOutput))
+
+	btcChain := NewBitcoinTestNetChain()
+	param = caseErrsTxParams()
+	taproot, err := btcutil.NewAddressTaproot(make([]byte, 32), btcChain.NetParams)
+	require.NoError(t, err)
+	param.OutList[0].Target = *NewAddressTuple(taproot.EncodeAddress())
+	require.NoError(t, btcChain.CheckPolicy(param))
+
+	param.OutList[0].Amount = 100 // below dust 330 of taproot output // 低于 taproot 输出的灰尘 330
+	require.True(t, errors.Is(btcChain.CheckPolicy(param), ErrDustOutput))
+
+	// Litecoin chain decodes with the litecoin package, which knows MWEB addresses
+	ltcChain := NewLitecoinMainNetChain()
+	_, err = ltcChain.DecodeAddress("ltcmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3")
+	require.True(t, errors.Is(err, ErrUnsupportedAddressType))
+}
+
+// TestChain_CalcFee validates fee units and fee rate clamping of chains
+//
+// TestChain_CalcFee 验证各条链的费用单位和费率限制
+func TestChain_CalcFee(t *testing.T) {
+	outputs := []*wire.TxOut{wire.NewTxOut(5000000, nil)}
+
+	btcChain := NewBitcoinMainNetChain()
+	require.Equal(t, FeeUnitPerKvB, btcChain.FeeUnit)
+	require.Equal(t, btcutil.Amount(2260), btcChain.CalcFee(226, outputs, 10000))
+	require.Equal(t, btcutil.Amount(226), btcChain.CalcFee(226, outputs, 1)) // raised to floor 1000 // 提高到下限 1000
+
+	dogeChain := NewDogecoinMainNetChain()
+	require.Equal(t, FeeUnitPerStartedKB, dogeChain.FeeUnit)
+	require.False(t, dogeChain.SegwitSupported)
+	require.Equal(t, btcutil.Amount(1000000), dogeChain.CalcFee(226, outputs, 1000000))
+	require.Equal(t, btcutil.Amount(2000000), dogeChain.CalcFee(1226, outputs, 1000000))
+
+	ltcChain := NewLitecoinMainNetChain()
+	require.Equal(t, btcutil.Amount(2260), ltcChain.CalcFee(226, outputs, 1000)) // raised to floor 10000 // 提高到下限 10000
+}
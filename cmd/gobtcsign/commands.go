@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign"
+)
+
+// prevOut represents one spent output in --prevouts file, the file is JSON array in the same order as tx inputs
+// Either address or pk_script is required
+//
+// prevOut 代表 --prevouts 文件中的一个被花费的输出，文件是和交易输入顺序相同的 JSON 数组
+// address 和 pk_script 二选一填写
+type prevOut struct {
+	Address  string `json:"address,omitempty"`   // Sender address // 发送者地址
+	PkScript string `json:"pk_script,omitempty"` // Sender pk-script hex // 发送者公钥脚本的十六进制
+	Amount   int64  `json:"amount"`              // Amount in satoshis // 聪的数量
+}
+
+// runBuild builds unsigned tx from tx params, outputs raw tx hex or base64 PSBT
+// runBuild 根据交易参数构建未签名交易，输出原始交易十六进制或 base64 的 PSBT
+func runBuild(env *cmdEnv, flagSet *flag.FlagSet, args []string) error {
+	asPsbt := flagSet.Bool("psbt", false, "output base64 PSBT carrying prevouts instead of raw tx hex")
+	if err := env.parse(flagSet, args); err != nil {
+		return err
+	}
+	param, err := env.readTxParams()
+	if err != nil {
+		return err
+	}
+	signParam, err := env.chain.CreateTxSignParams(param)
+	if err != nil {
+		return errors.WithMessage(err, "wrong create-tx-sign-params")
+	}
+	if *asPsbt {
+		packet, err := newPsbtPacket(signParam)
+		if err != nil {
+			return err
+		}
+		return env.writeOutput([]byte(packet))
+	}
+	txHex, err := gobtcsign.CvtMsgTxToHex(signParam.MsgTx)
+	if err != nil {
+		return errors.WithMessage(err, "wrong cvt-msg-tx-to-hex")
+	}
+	return env.writeOutput([]byte(txHex))
+}
+
+// runSign signs tx params or base64 PSBT and outputs signed raw tx hex
+// runSign 签名交易参数或 base64 的 PSBT，输出已签名的原始交易十六进制
+func runSign(env *cmdEnv, flagSet *flag.FlagSet, args []string) error {
+	var keyFlags = addKeyFlags(flagSet)
+	senderAddress := flagSet.String("address", "", "sender address, defaults to sender of the first input")
+	if err := env.parse(flagSet, args); err != nil {
+		return err
+	}
+	data, err := env.readInput()
+	if err != nil {
+		return err
+	}
+	privateKeyHex, err := keyFlags.loadPrivateKeyHex(env)
+	if err != nil {
+		return err
+	}
+	signParam, err := env.newSignParam(data)
+	if err != nil {
+		return err
+	}
+	if len(signParam.MsgTx.TxIn) == 0 {
+		return errors.New("wrong tx has no inputs to sign")
+	}
+	if *senderAddress == "" {
+		address, err := gobtcsign.GetPkScriptAddress(signParam.InputOuts[0].PkScript, env.chain.NetParams)
+		if err != nil {
+			return errors.WithMessage(err, "wrong sender of the first input, pass --address")
+		}
+		*senderAddress = address
+	}
+	if err := env.chain.Sign(*senderAddress, privateKeyHex, signParam); err != nil {
+		return errors.WithMessage(err, "wrong sign")
+	}
+	txHex, err := gobtcsign.CvtMsgTxToHex(signParam.MsgTx)
+	if err != nil {
+		return errors.WithMessage(err, "wrong cvt-msg-tx-to-hex")
+	}
+	return env.writeOutput([]byte(txHex))
+}
+
+// runVerify verifies signed tx hex with prevouts file and outputs JSON report, fails when any input is not valid
+// runVerify 使用前置输出文件验证已签名的交易十六进制并输出 JSON 报告，任何输入无效时返回失败
+func runVerify(env *cmdEnv, flagSet *flag.FlagSet, args []string) error {
+	prevOutsPath := flagSet.String("prevouts", "", "JSON file of prevouts in input order, items are {address|pk_script, amount}")
+	if err := env.parse(flagSet, args); err != nil {
+		return err
+	}
+	if *prevOutsPath == "" {
+		return errors.New("wrong args, --prevouts is required")
+	}
+	msgTx, err := env.readMsgTx()
+	if err != nil {
+		return err
+	}
+	inputOuts, err := env.readPrevOuts(*prevOutsPath)
+	if err != nil {
+		return err
+	}
+	prevOutFetcher, err := gobtcsign.NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+	if err != nil {
+		return errors.WithMessage(err, "wrong new-prev-out-fetcher")
+	}
+	report, err := gobtcsign.NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).VerifyReport(msgTx)
+	if err != nil {
+		return errors.WithMessage(err, "wrong verify")
+	}
+	data, err := report.ToJSON()
+	if err != nil {
+		return err
+	}
+	if err := env.writeOutput(data); err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+// runDecode explains tx hex as text or JSON, with amounts and fee when prevouts file is given
+// runDecode 以文本或 JSON 解释交易十六进制，给出前置输出文件时包含数量和费用
+func runDecode(env *cmdEnv, flagSet *flag.FlagSet, args []string) error {
+	prevOutsPath := flagSet.String("prevouts", "", "optional JSON file of prevouts in input order")
+	format := flagSet.String("format", "text", "output format: text or json")
+	if err := env.parse(flagSet, args); err != nil {
+		return err
+	}
+	msgTx, err := env.readMsgTx()
+	if err != nil {
+		return err
+	}
+	config := gobtcsign.NewTxExplainConfig(env.chain.NetParams, nil)
+	config.DustLimit = env.chain.DustLimit
+	config.RelayFeePerKb = env.chain.RelayFeePerKb
+	if *prevOutsPath != "" {
+		inputOuts, err := env.readPrevOuts(*prevOutsPath)
+		if err != nil {
+			return err
+		}
+		if len(inputOuts) != len(msgTx.TxIn) {
+			return errors.Errorf("wrong prevouts count=%d, expected=%d", len(inputOuts), len(msgTx.TxIn))
+		}
+		var utxoMap = make(map[wire.OutPoint]*gobtcsign.SenderAmountUtxo, len(inputOuts))
+		for idx, txIn := range msgTx.TxIn {
+			sender := &gobtcsign.AddressTuple{PkScript: inputOuts[idx].PkScript}
+			utxoMap[txIn.PreviousOutPoint] = gobtcsign.NewSenderAmountUtxo(sender, inputOuts[idx].Value)
+		}
+		config.UtxoFrom = gobtcsign.NewSenderAmountUtxoCache(utxoMap)
+	}
+	explain, err := gobtcsign.ExplainTx(context.Background(), msgTx, config)
+	if err != nil {
+		return errors.WithMessage(err, "wrong explain-tx")
+	}
+	switch *format {
+	case "text":
+		return env.writeOutput([]byte(strings.TrimRight(explain.ToText(), "\n")))
+	case "json":
+		data, err := explain.ToJSON()
+		if err != nil {
+			return err
+		}
+		return env.writeOutput(data)
+	default:
+		return errors.Errorf("wrong format=%s, expected text or json", *format)
+	}
+}
+
+// estimateResult represents output of estimate command
+// estimateResult 代表 estimate 命令的输出
+type estimateResult struct {
+	Chain        string `json:"chain"`           // Chain name // 链的名称
+	VSize        int    `json:"vsize"`           // Estimated signed vsize, slightly above the real one // 预估的签名后虚拟大小，略大于实际值
+	FeeRatePerKb int64  `json:"fee_rate_per_kb"` // Fee rate after clamping into chain bounds // 限制在链的范围内之后的费率
+	Fee          int64  `json:"fee"`             // Estimated fee in satoshis // 预估的聪的费用
+	InputAmount  int64  `json:"input_amount"`    // Sum of inputs // 输入的总额
+	OutputAmount int64  `json:"output_amount"`   // Sum of outputs // 输出的总额
+	ChangeAmount int64  `json:"change_amount"`   // Inputs minus outputs minus fee, negative means insufficient // 输入减去输出和费用，为负表示不足
+}
+
+// runEstimate estimates signed size and fee of tx params under chain fee rules
+// runEstimate 按照链的费用规则预估交易参数签名后的大小和费用
+func runEstimate(env *cmdEnv, flagSet *flag.FlagSet, args []string) error {
+	feeRatePerKb := flagSet.Int64("fee-rate", 0, "fee rate in satoshis per kvB (per kB on dogecoin), defaults to chain relay fee")
+	changeAddress := flagSet.String("change", "", "optional change address, adds one change output to the estimate")
+	if err := env.parse(flagSet, args); err != nil {
+		return err
+	}
+	param, err := env.readTxParams()
+	if err != nil {
+		return err
+	}
+	var change = gobtcsign.NewNoChange()
+	if *changeAddress != "" {
+		address, err := env.chain.DecodeAddress(*changeAddress)
+		if err != nil {
+			return errors.WithMessage(err, "wrong change address")
+		}
+		change = &gobtcsign.ChangeTo{AddressX: address}
+	}
+	var rate = btcutil.Amount(*feeRatePerKb)
+	if rate == 0 {
+		rate = env.chain.RelayFeePerKb
+	}
+	rate = env.chain.FeeRateBounds.Clamp(rate)
+	vSize, err := param.EstimateTxSize(env.chain.NetParams, change)
+	if err != nil {
+		return errors.WithMessage(err, "wrong estimate-tx-size")
+	}
+	fee, err := env.chain.EstimateTxFee(param, change, rate)
+	if err != nil {
+		return errors.WithMessage(err, "wrong estimate-tx-fee")
+	}
+	var res = &estimateResult{
+		Chain:        env.chain.Name,
+		VSize:        vSize,
+		FeeRatePerKb: int64(rate),
+		Fee:          int64(fee),
+	}
+	for _, input := range param.VinList {
+		res.InputAmount += input.Amount
+	}
+	for _, output := range param.OutList {
+		res.OutputAmount += output.Amount
+	}
+	res.ChangeAmount = res.InputAmount - res.OutputAmount - res.Fee
+	return env.writeJSON(res)
+}
+
+// newWalletResult represents output of newwallet command, private key fields stay empty when saved into keystore
+// newWalletResult 代表 newwallet 命令的输出，保存到密钥库时私钥字段为空
+type newWalletResult struct {
+	Chain         string `json:"chain"`                     // Chain name // 链的名称
+	Address       string `json:"address"`                   // Wallet address // 钱包地址
+	PrivateKeyHex string `json:"private_key_hex,omitempty"` // Private key hex // 私钥的十六进制
+	PrivateKeyWif string `json:"private_key_wif,omitempty"` // Private key WIF // 私钥的 WIF
+	Keystore      string `json:"keystore,omitempty"`        // Keystore path // 密钥库路径
+}
+
+// runNewWallet creates P2WPKH or P2PKH wallet, P2PKH on chains without SegWit
+// runNewWallet 创建 P2WPKH 或 P2PKH 钱包，在没有 SegWit 的链上使用 P2PKH
+func runNewWallet(env *cmdEnv, flagSet *flag.FlagSet, args []string) error {
+	addressType := flagSet.String("type", "", "address type: p2wpkh or p2pkh, defaults to p2wpkh, p2pkh on chains without segwit")
+	keystorePath := flagSet.String("keystore", "", "save private key into encrypted keystore file instead of printing it")
+	passwordFile := flagSet.String("password-file", "", "file with keystore password, defaults to env "+passwordEnvKey)
+	if err := env.parse(flagSet, args); err != nil {
+		return err
+	}
+	if *addressType == "" {
+		*addressType = "p2wpkh"
+		if !env.chain.SegwitSupported {
+			*addressType = "p2pkh"
+		}
+	}
+	var address, privateKeyHex string
+	var err error
+	switch *addressType {
+	case "p2wpkh":
+		if !env.chain.SegwitSupported {
+			return errors.Errorf("wrong type=%s on %s without segwit", *addressType, env.chain.Name)
+		}
+		address, privateKeyHex, err = gobtcsign.CreateWalletP2WPKH(env.chain.NetParams)
+	case "p2pkh":
+		address, privateKeyHex, err = gobtcsign.CreateWalletP2PKH(env.chain.NetParams)
+	default:
+		return errors.Errorf("wrong type=%s, expected p2wpkh or p2pkh", *addressType)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "wrong create wallet")
+	}
+	var res = &newWalletResult{Chain: env.chain.Name, Address: address}
+	if *keystorePath != "" {
+		password, err := loadPassword(env, *passwordFile)
+		if err != nil {
+			return err
+		}
+		if err := saveKeystore(*keystorePath, env.chain.Name, address, privateKeyHex, password); err != nil {
+			return err
+		}
+		res.Keystore = *keystorePath
+		return env.writeJSON(res)
+	}
+	privKeyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return errors.WithMessage(err, "wrong decode private key")
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+	wif, err := btcutil.NewWIF(privKey, env.chain.NetParams, true)
+	if err != nil {
+		return errors.WithMessage(err, "wrong new-wif")
+	}
+	res.PrivateKeyHex = privateKeyHex
+	res.PrivateKeyWif = wif.String()
+	return env.writeJSON(res)
+}
+
+// readTxParams reads tx params from input, see decodeTxParams
+// readTxParams 从输入读取交易参数，参见 decodeTxParams
+func (env *cmdEnv) readTxParams() (*gobtcsign.BitcoinTxParams, error) {
+	data, err := env.readInput()
+	if err != nil {
+		return nil, err
+	}
+	return decodeTxParams(data, env.chain)
+}
+
+// decodeTxParams decodes TxParamsDoc JSON or YAML, see gobtcsign.TxParamsSchemaVersion
+// JSON without version is decoded as plain BitcoinTxParams, unknown fields are rejected in every format
+//
+// decodeTxParams 解析 TxParamsDoc 的 JSON 或 YAML，参见 gobtcsign.TxParamsSchemaVersion
+// 没有 version 的 JSON 按普通的 BitcoinTxParams 解析，所有格式都拒绝未知字段
+func decodeTxParams(data []byte, chain *gobtcsign.Chain) (*gobtcsign.BitcoinTxParams, error) {
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		return gobtcsign.UnmarshalTxParamsYAML(data, chain.NetParams)
+	}
+	var probe struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err == nil && probe.Version != nil {
+		return gobtcsign.UnmarshalTxParamsJSON(data, chain.NetParams)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var param gobtcsign.BitcoinTxParams
+	if err := decoder.Decode(&param); err != nil {
+		return nil, errors.WithMessage(err, "wrong decode tx params json")
+	}
+	return &param, nil
+}
+
+// readMsgTx reads raw tx hex from input
+// readMsgTx 从输入读取原始交易十六进制
+func (env *cmdEnv) readMsgTx() (*wire.MsgTx, error) {
+	data, err := env.readInput()
+	if err != nil {
+		return nil, err
+	}
+	msgTx, err := gobtcsign.NewMsgTxFromHex(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong new-msg-tx-from-hex")
+	}
+	return msgTx, nil
+}
+
+// readPrevOuts reads prevouts file into inputOuts
+// readPrevOuts 把前置输出文件读取为 inputOuts
+func (env *cmdEnv) readPrevOuts(path string) ([]*wire.TxOut, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong read prevouts file")
+	}
+	var items []*prevOut
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, errors.WithMessage(err, "wrong decode prevouts json")
+	}
+	var inputOuts = make([]*wire.TxOut, 0, len(items))
+	for idx, item := range items {
+		pkScript, err := hex.DecodeString(item.PkScript)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong prevout pk_script. index=%d", idx)
+		}
+		sender := &gobtcsign.AddressTuple{Address: item.Address, PkScript: pkScript}
+		pkScript, err = sender.GetPkScript(env.chain.NetParams)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong prevout sender. index=%d", idx)
+		}
+		inputOuts = append(inputOuts, wire.NewTxOut(item.Amount, pkScript))
+	}
+	return inputOuts, nil
+}
+
+// newSignParam creates SignParam from base64 PSBT or tx params, see decodeTxParams
+// newSignParam 根据 base64 的 PSBT 或交易参数创建 SignParam，参见 decodeTxParams
+func (env *cmdEnv) newSignParam(data []byte) (*gobtcsign.SignParam, error) {
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, psbtBase64Prefix) {
+		return newSignParamFromPsbt(text, env.chain)
+	}
+	param, err := decodeTxParams(data, env.chain)
+	if err != nil {
+		return nil, err
+	}
+	signParam, err := env.chain.CreateTxSignParams(param)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong create-tx-sign-params")
+	}
+	return signParam, nil
+}
+
+// writeJSON writes indented JSON into output
+// writeJSON 把缩进的 JSON 写入输出
+func (env *cmdEnv) writeJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "wrong marshal json")
+	}
+	return env.writeOutput(data)
+}
+
+// psbtBase64Prefix is base64 of PSBT magic bytes "psbt\xff"
+// psbtBase64Prefix 是 PSBT 魔数 "psbt\xff" 的 base64
+const psbtBase64Prefix = "cHNidP"
+
+// newPsbtPacket creates base64 PSBT of unsigned tx, every input carries its prevout as witness utxo
+// Legacy inputs carry it as well, the full previous tx is not known offline
+//
+// newPsbtPacket 创建未签名交易的 base64 PSBT，每个输入都以 witness utxo 携带其前置输出
+// 传统输入同样如此，因为离线时不知道完整的前置交易
+func newPsbtPacket(signParam *gobtcsign.SignParam) (string, error) {
+	packet, err := psbt.NewFromUnsignedTx(signParam.MsgTx.Copy())
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong new-psbt")
+	}
+	for idx := range packet.Inputs {
+		packet.Inputs[idx].WitnessUtxo = signParam.InputOuts[idx]
+	}
+	res, err := packet.B64Encode()
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong encode psbt")
+	}
+	return res, nil
+}
+
+// newSignParamFromPsbt creates SignParam from base64 PSBT, prevouts come from witness or non-witness utxo
+// newSignParamFromPsbt 根据 base64 的 PSBT 创建 SignParam，前置输出来自 witness utxo 或 non-witness utxo
+func newSignParamFromPsbt(text string, chain *gobtcsign.Chain) (*gobtcsign.SignParam, error) {
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(text), true)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode psbt")
+	}
+	msgTx := packet.UnsignedTx.Copy()
+	var inputOuts = make([]*wire.TxOut, 0, len(packet.Inputs))
+	for idx, input := range packet.Inputs {
+		prevOutPoint := msgTx.TxIn[idx].PreviousOutPoint
+		// Non-witness utxo is trusted only when it is the very tx being spent
+		// 只有当非见证 utxo 正是被花费的交易时才信任它
+		var nonWitnessOut *wire.TxOut
+		if input.NonWitnessUtxo != nil {
+			if txHash := input.NonWitnessUtxo.TxHash(); txHash != prevOutPoint.Hash {
+				return nil, errors.Errorf("wrong psbt input %d non-witness utxo hash=%s, expected %s", idx, txHash.String(), prevOutPoint.Hash.String())
+			}
+			if int(prevOutPoint.Index) >= len(input.NonWitnessUtxo.TxOut) {
+				return nil, errors.Errorf("wrong psbt input %d non-witness utxo has no output %d", idx, prevOutPoint.Index)
+			}
+			nonWitnessOut = input.NonWitnessUtxo.TxOut[prevOutPoint.Index]
+		}
+		switch {
+		case input.WitnessUtxo != nil:
+			if nonWitnessOut != nil && (nonWitnessOut.Value != input.WitnessUtxo.Value || !bytes.Equal(nonWitnessOut.PkScript, input.WitnessUtxo.PkScript)) {
+				return nil, errors.Errorf("wrong psbt input %d witness utxo mismatches non-witness utxo", idx)
+			}
+			inputOuts = append(inputOuts, input.WitnessUtxo)
+		case nonWitnessOut != nil:
+			inputOuts = append(inputOuts, nonWitnessOut)
+		default:
+			return nil, errors.Errorf("wrong psbt input %d has no utxo", idx)
+		}
+	}
+	return &gobtcsign.SignParam{MsgTx: msgTx, InputOuts: inputOuts, NetParams: chain.NetParams}, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// passwordEnvKey is the environment variable holding keystore password when --password-file is not given
+// passwordEnvKey 是未指定 --password-file 时保存密钥库密码的环境变量
+const passwordEnvKey = "GOBTCSIGN_PASSWORD"
+
+// keystoreVersion is the version of keystore file format
+// keystoreVersion 是密钥库文件格式的版本
+const keystoreVersion = 1
+
+// scrypt cost parameters of new keystores, stored in file so that they can change later
+// 新密钥库的 scrypt 成本参数，保存在文件中以便以后调整
+const (
+	scryptN      = 1 << 17
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// Upper bounds of scrypt params read from keystore files, a crafted file must not exhaust memory or CPU
+// 从密钥库文件读取的 scrypt 参数的上限，构造的文件不能耗尽内存或 CPU
+const (
+	scryptMaxN  = 1 << 20
+	scryptMaxRP = 16
+)
+
+// keystoreFile represents encrypted private key file, private key hex is sealed with AES-256-GCM under scrypt key
+// keystoreFile 代表加密的私钥文件，私钥十六进制使用 scrypt 派生的密钥以 AES-256-GCM 加密
+type keystoreFile struct {
+	Version    int          `json:"version"`    // File format version // 文件格式版本
+	Chain      string       `json:"chain"`      // Chain name when created // 创建时的链名称
+	Address    string       `json:"address"`    // Wallet address, also authenticated as additional data // 钱包地址，同时作为附加数据参与认证
+	KDF        string       `json:"kdf"`        // Always scrypt // 固定为 scrypt
+	KDFParams  scryptParams `json:"kdfparams"`  // Scrypt parameters // scrypt 参数
+	Cipher     string       `json:"cipher"`     // Always aes-256-gcm // 固定为 aes-256-gcm
+	Nonce      string       `json:"nonce"`      // GCM nonce hex // GCM 随机数的十六进制
+	Ciphertext string       `json:"ciphertext"` // Sealed private key hex // 加密后的私钥十六进制
+}
+
+// scryptParams represents scrypt parameters in keystoreFile
+// scryptParams 代表 keystoreFile 中的 scrypt 参数
+type scryptParams struct {
+	N    int    `json:"n"`    // CPU/memory cost // CPU 和内存成本
+	R    int    `json:"r"`    // Block size // 块大小
+	P    int    `json:"p"`    // Parallelization // 并行度
+	Salt string `json:"salt"` // Salt hex // 盐的十六进制
+}
+
+// keyFlags represents flags choosing private key source, exactly one is required
+// keyFlags 代表选择私钥来源的参数，必须且只能指定一个
+type keyFlags struct {
+	keyFile      *string // File with private key hex or WIF // 包含私钥十六进制或 WIF 的文件
+	wif          *string // Private key WIF // 私钥的 WIF
+	keystore     *string // Encrypted keystore file // 加密的密钥库文件
+	passwordFile *string // File with keystore password // 包含密钥库密码的文件
+}
+
+// addKeyFlags adds private key flags into flag set
+// addKeyFlags 把私钥参数添加到参数集合
+func addKeyFlags(flagSet *flag.FlagSet) *keyFlags {
+	return &keyFlags{
+		keyFile:      flagSet.String("key-file", "", "file with private key hex or WIF"),
+		wif:          flagSet.String("wif", "", "private key WIF, visible in process list, prefer --key-file on shared machines"),
+		keystore:     flagSet.String("keystore", "", "encrypted keystore file created by newwallet"),
+		passwordFile: flagSet.String("password-file", "", "file with keystore password, defaults to env "+passwordEnvKey),
+	}
+}
+
+// loadPrivateKeyHex loads private key hex from the chosen source, WIF must belong to the chain network
+// loadPrivateKeyHex 从选择的来源加载私钥十六进制，WIF 必须属于链的网络
+func (k *keyFlags) loadPrivateKeyHex(env *cmdEnv) (string, error) {
+	var count int
+	for _, value := range []string{*k.keyFile, *k.wif, *k.keystore} {
+		if value != "" {
+			count++
+		}
+	}
+	if count != 1 {
+		return "", errors.New("wrong args, exactly one of --key-file, --wif and --keystore is required")
+	}
+	switch {
+	case *k.keyFile != "":
+		data, err := os.ReadFile(*k.keyFile)
+		if err != nil {
+			return "", errors.WithMessage(err, "wrong read key file")
+		}
+		return parsePrivateKey(strings.TrimSpace(string(data)), env)
+	case *k.wif != "":
+		return parsePrivateKey(*k.wif, env)
+	default:
+		password, err := loadPassword(env, *k.passwordFile)
+		if err != nil {
+			return "", err
+		}
+		return loadKeystore(*k.keystore, password)
+	}
+}
+
+// parsePrivateKey accepts 64 hex chars or WIF and returns private key hex
+// parsePrivateKey 接受 64 个十六进制字符或 WIF，返回私钥十六进制
+func parsePrivateKey(text string, env *cmdEnv) (string, error) {
+	if len(text) == 64 {
+		if _, err := hex.DecodeString(text); err == nil {
+			return strings.ToLower(text), nil
+		}
+	}
+	wif, err := btcutil.DecodeWIF(text)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong private key, expected hex or WIF")
+	}
+	if !wif.IsForNet(env.chain.NetParams) {
+		return "", errors.Errorf("wrong private key WIF is not for %s", env.chain.Name)
+	}
+	return hex.EncodeToString(wif.PrivKey.Serialize()), nil
+}
+
+// loadPassword reads password from file, or from environment when path is empty
+// loadPassword 从文件读取密码，路径为空时从环境变量读取
+func loadPassword(env *cmdEnv, path string) (string, error) {
+	if path == "" {
+		password := env.getenv(passwordEnvKey)
+		if password == "" {
+			return "", errors.Errorf("wrong args, keystore needs --password-file or env %s", passwordEnvKey)
+		}
+		return password, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong read password file")
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return "", errors.New("wrong password file is empty")
+	}
+	return password, nil
+}
+
+// saveKeystore encrypts private key hex with password and writes keystore file, existing file is not overwritten
+// saveKeystore 使用密码加密私钥十六进制并写入密钥库文件，不会覆盖已有文件
+func saveKeystore(path string, chainName string, address string, privateKeyHex string, password string) error {
+	var salt = make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.WithMessage(err, "wrong random salt")
+	}
+	var res = &keystoreFile{
+		Version:   keystoreVersion,
+		Chain:     chainName,
+		Address:   address,
+		KDF:       "scrypt",
+		KDFParams: scryptParams{N: scryptN, R: scryptR, P: scryptP, Salt: hex.EncodeToString(salt)},
+		Cipher:    "aes-256-gcm",
+	}
+	aead, err := newKeystoreAEAD(&res.KDFParams, password)
+	if err != nil {
+		return err
+	}
+	var nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.WithMessage(err, "wrong random nonce")
+	}
+	res.Nonce = hex.EncodeToString(nonce)
+	res.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, []byte(privateKeyHex), []byte(address)))
+
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "wrong marshal keystore")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return errors.WithMessage(err, "wrong create keystore file")
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return errors.WithMessage(err, "wrong write keystore file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.WithMessage(err, "wrong close keystore file")
+	}
+	return nil
+}
+
+// loadKeystore reads keystore file and decrypts private key hex with password
+// loadKeystore 读取密钥库文件并使用密码解密私钥十六进制
+func loadKeystore(path string, password string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong read keystore file")
+	}
+	var ks keystoreFile
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return "", errors.WithMessage(err, "wrong decode keystore json")
+	}
+	if ks.Version != keystoreVersion || ks.KDF != "scrypt" || ks.Cipher != "aes-256-gcm" {
+		return "", errors.Errorf("wrong keystore version=%d kdf=%s cipher=%s", ks.Version, ks.KDF, ks.Cipher)
+	}
+	if err := ks.KDFParams.check(); err != nil {
+		return "", err
+	}
+	aead, err := newKeystoreAEAD(&ks.KDFParams, password)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := hex.DecodeString(ks.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return "", errors.New("wrong keystore nonce")
+	}
+	ciphertext, err := hex.DecodeString(ks.Ciphertext)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong keystore ciphertext")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(ks.Address))
+	if err != nil {
+		return "", errors.New("wrong keystore password or file is corrupted")
+	}
+	return string(plaintext), nil
+}
+
+// check bounds scrypt params before deriving: N a power of two up to scryptMaxN, r·p up to scryptMaxRP
+// check 在派生之前限制 scrypt 参数的范围：N 是不超过 scryptMaxN 的 2 的幂，r·p 不超过 scryptMaxRP
+func (params *scryptParams) check() error {
+	if params.N < 2 || params.N > scryptMaxN || params.N&(params.N-1) != 0 {
+		return errors.Errorf("wrong keystore scrypt n=%d, expected power of two up to %d", params.N, scryptMaxN)
+	}
+	if params.R < 1 || params.P < 1 || params.R > scryptMaxRP || params.R*params.P > scryptMaxRP {
+		return errors.Errorf("wrong keystore scrypt r=%d p=%d, expected r*p up to %d", params.R, params.P, scryptMaxRP)
+	}
+	return nil
+}
+
+// newKeystoreAEAD derives AES-256-GCM cipher from password with scrypt params
+// newKeystoreAEAD 使用 scrypt 参数从密码派生 AES-256-GCM 加密器
+func newKeystoreAEAD(params *scryptParams, password string) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong keystore salt")
+	}
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, scryptKeyLen)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong scrypt key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong new aes cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong new gcm")
+	}
+	return aead, nil
+}
@@ -0,0 +1,171 @@
+// Package main provides gobtcsign command-line tool for offline build, sign, verify and decode
+// Every command reads from stdin and writes to stdout by default, so commands pipe into each other on air-gapped machines
+//
+// main 包提供 gobtcsign 命令行工具，用于离线构建、签名、验证和解析交易
+// 每个命令默认从 stdin 读取并写到 stdout，因此在离线机器上命令之间可以通过管道衔接
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign"
+)
+
+// command represents one subcommand of the tool
+// command 代表工具的一个子命令
+type command struct {
+	name  string                                                        // Subcommand name // 子命令名称
+	usage string                                                        // One line usage // 单行用法
+	run   func(env *cmdEnv, flagSet *flag.FlagSet, args []string) error // Adds flags, calls env.parse and runs // 添加参数，调用 env.parse 后执行
+}
+
+// commands lists subcommands in the order shown in usage
+// commands 按用法中显示的顺序列出子命令
+var commands = []*command{
+	{name: "build", usage: "build unsigned tx hex or PSBT from tx params JSON or YAML", run: runBuild},
+	{name: "sign", usage: "sign tx params or PSBT with key file, WIF or keystore", run: runSign},
+	{name: "verify", usage: "verify signed tx hex with its prevouts", run: runVerify},
+	{name: "decode", usage: "explain tx hex", run: runDecode},
+	{name: "estimate", usage: "estimate size and fee of tx params", run: runEstimate},
+	{name: "newwallet", usage: "create wallet, optionally saved into encrypted keystore", run: runNewWallet},
+}
+
+// chainFactories maps --chain values to chain constructors, short names point to mainnet
+// chainFactories 把 --chain 的取值映射到链的构造函数，短名称指向主网
+var chainFactories = map[string]func() *gobtcsign.Chain{
+	"bitcoin":           gobtcsign.NewBitcoinMainNetChain,
+	"bitcoin-mainnet":   gobtcsign.NewBitcoinMainNetChain,
+	"bitcoin-testnet3":  gobtcsign.NewBitcoinTestNetChain,
+	"bitcoin-signet":    gobtcsign.NewBitcoinSigNetChain,
+	"bitcoin-regtest":   gobtcsign.NewBitcoinRegTestChain,
+	"dogecoin":          gobtcsign.NewDogecoinMainNetChain,
+	"dogecoin-mainnet":  gobtcsign.NewDogecoinMainNetChain,
+	"dogecoin-testnet":  gobtcsign.NewDogecoinTestNetChain,
+	"litecoin":          gobtcsign.NewLitecoinMainNetChain,
+	"litecoin-mainnet":  gobtcsign.NewLitecoinMainNetChain,
+	"litecoin-testnet4": gobtcsign.NewLitecoinTestNetChain,
+}
+
+// cmdEnv carries standard streams and environment lookup, replaced in tests
+// cmdEnv 携带标准输入输出流和环境变量查询，测试时会被替换
+type cmdEnv struct {
+	stdin     io.Reader               // Default input // 默认输入
+	stdout    io.Writer               // Default output // 默认输出
+	stderr    io.Writer               // Usage and flag errors // 用法和参数错误
+	getenv    func(key string) string // Environment lookup // 环境变量查询
+	chainName *string                 // Value of --chain // --chain 的取值
+	chain     *gobtcsign.Chain        // Chain chosen with --chain, set by parse // 通过 --chain 选择的链，由 parse 设置
+	inPath    string                  // Input path, "-" means stdin // 输入路径，"-" 表示 stdin
+	outPath   string                  // Output path, "-" means stdout // 输出路径，"-" 表示 stdout
+}
+
+func main() {
+	env := &cmdEnv{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	if err := run(env, os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gobtcsign: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches args to subcommand
+// run 把参数分发给子命令
+func run(env *cmdEnv, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(env.stderr)
+		if len(args) == 0 {
+			return errors.New("wrong args, missing command")
+		}
+		return nil
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		flagSet := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		flagSet.SetOutput(env.stderr)
+		env.chainName = flagSet.String("chain", "bitcoin-mainnet", "chain name: "+strings.Join(chainNames(), ", "))
+		flagSet.StringVar(&env.inPath, "in", "-", "input file, - means stdin")
+		flagSet.StringVar(&env.outPath, "out", "-", "output file, - means stdout")
+		return cmd.run(env, flagSet, args[1:])
+	}
+	printUsage(env.stderr)
+	return errors.Errorf("wrong command=%s", args[0])
+}
+
+// parse parses subcommand args and resolves --chain
+// parse 解析子命令的参数并确定 --chain
+func (env *cmdEnv) parse(flagSet *flag.FlagSet, args []string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() > 0 {
+		return errors.Errorf("wrong args=%v, unexpected positional args", flagSet.Args())
+	}
+	newChain, ok := chainFactories[*env.chainName]
+	if !ok {
+		return errors.Errorf("wrong chain=%s, expected one of: %s", *env.chainName, strings.Join(chainNames(), ", "))
+	}
+	env.chain = newChain()
+	return nil
+}
+
+// chainNames returns sorted --chain values
+// chainNames 返回排好序的 --chain 取值
+func chainNames() []string {
+	var names = make([]string, 0, len(chainFactories))
+	for name := range chainFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printUsage prints commands and common flags
+// printUsage 打印命令和通用参数
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gobtcsign <command> [--chain name] [--in file] [--out file] [flags]")
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w, "run 'gobtcsign <command> -h' to see flags of the command")
+}
+
+// readInput reads whole input of --in
+// readInput 读取 --in 的全部输入
+func (env *cmdEnv) readInput() ([]byte, error) {
+	if env.inPath == "-" {
+		data, err := io.ReadAll(env.stdin)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong read stdin")
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(env.inPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong read input file")
+	}
+	return data, nil
+}
+
+// writeOutput writes data with trailing newline into --out
+// writeOutput 把数据加上换行后写入 --out
+func (env *cmdEnv) writeOutput(data []byte) error {
+	data = append(data, '\n')
+	if env.outPath == "-" {
+		if _, err := env.stdout.Write(data); err != nil {
+			return errors.WithMessage(err, "wrong write stdout")
+		}
+		return nil
+	}
+	if err := os.WriteFile(env.outPath, data, 0o600); err != nil {
+		return errors.WithMessage(err, "wrong write output file")
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign"
+)
+
+const (
+	testSenderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	testPrivateKeyHex = "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"
+)
+
+// testTxParamsJSON spends one P2WPKH utxo on bitcoin testnet3
+// testTxParamsJSON 在比特币测试网 testnet3 上花费一个 P2WPKH 的 utxo
+const testTxParamsJSON = `{
+  "VinList": [
+    {
+      "OutPoint": {"Hash": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", "Index": 0},
+      "Sender": {"Address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"},
+      "Amount": 4900,
+      "RBFInfo": {"AllowRBF": false, "Sequence": 4294967295}
+    }
+  ],
+  "OutList": [
+    {"Target": {"Address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"}, "Amount": 3000}
+  ],
+  "RBFInfo": {"AllowRBF": true, "Sequence": 4294967293}
+}`
+
+// runCmd runs the tool with stdin text and returns stdout text
+// runCmd 使用 stdin 文本运行工具并返回 stdout 文本
+func runCmd(t *testing.T, stdin string, env map[string]string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmdEnv := &cmdEnv{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string { return env[key] },
+	}
+	err := run(cmdEnv, args)
+	return strings.TrimSpace(stdout.String()), err
+}
+
+// writeTempFile writes content into file of temp dir and returns its path
+// writeTempFile 把内容写入临时目录中的文件并返回路径
+func writeTempFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestRun_BuildSignVerify builds, signs, verifies and decodes one testnet tx through the commands
+//
+// TestRun_BuildSignVerify 通过命令构建、签名、验证和解析一笔测试网交易
+func TestRun_BuildSignVerify(t *testing.T) {
+	unsignedHex, err := runCmd(t, testTxParamsJSON, nil, "build", "--chain", "bitcoin-testnet3")
+	require.NoError(t, err)
+	msgTx, err := gobtcsign.NewMsgTxFromHex(unsignedHex)
+	require.NoError(t, err)
+	require.Len(t, msgTx.TxIn, 1)
+	require.Empty(t, msgTx.TxIn[0].Witness)
+
+	keyFile := writeTempFile(t, "key.txt", testPrivateKeyHex+"\n")
+	signedHex, err := runCmd(t, testTxParamsJSON, nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", keyFile)
+	require.NoError(t, err)
+
+	prevOuts := writeTempFile(t, "prevouts.json", `[{"address": "`+testSenderAddress+`", "amount": 4900}]`)
+	output, err := runCmd(t, signedHex, nil, "verify", "--chain", "bitcoin-testnet3", "--prevouts", prevOuts)
+	require.NoError(t, err)
+	var report gobtcsign.VerifyReport
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	require.True(t, report.Valid)
+
+	// Wrong amount breaks the P2WPKH signature
+	// 错误的数量会使 P2WPKH 签名失效
+	prevOuts = writeTempFile(t, "prevouts.json", `[{"address": "`+testSenderAddress+`", "amount": 4901}]`)
+	_, err = runCmd(t, signedHex, nil, "verify", "--chain", "bitcoin-testnet3", "--prevouts", prevOuts)
+	require.ErrorIs(t, err, gobtcsign.ErrSignatureInvalid)
+
+	output, err = runCmd(t, signedHex, nil, "decode", "--chain", "bitcoin-testnet3", "--format", "json", "--prevouts", writeTempFile(t, "p.json", `[{"address": "`+testSenderAddress+`", "amount": 4900}]`))
+	require.NoError(t, err)
+	var explain gobtcsign.TxExplain
+	require.NoError(t, json.Unmarshal([]byte(output), &explain))
+	require.Equal(t, int64(1900), explain.Fee)
+	require.Equal(t, testSenderAddress, explain.Inputs[0].Address)
+}
+
+// TestRun_BuildPsbtSign signs PSBT built by the tool and rejects WIF of another network
+//
+// TestRun_BuildPsbtSign 签名工具构建的 PSBT，并拒绝其它网络的 WIF
+func TestRun_BuildPsbtSign(t *testing.T) {
+	packet, err := runCmd(t, testTxParamsJSON, nil, "build", "--chain", "bitcoin-testnet3", "--psbt")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(packet, "cHNidP"))
+
+	privKeyBytes, err := hex.DecodeString(testPrivateKeyHex)
+	require.NoError(t, err)
+	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+	testnetWif, err := btcutil.NewWIF(privKey, &chaincfg.TestNet3Params, true)
+	require.NoError(t, err)
+	mainnetWif, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, true)
+	require.NoError(t, err)
+
+	signedHex, err := runCmd(t, packet, nil, "sign", "--chain", "bitcoin-testnet3", "--wif", testnetWif.String())
+	require.NoError(t, err)
+	expectedHex, err := runCmd(t, testTxParamsJSON, nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", writeTempFile(t, "key.txt", testPrivateKeyHex))
+	require.NoError(t, err)
+	require.Equal(t, expectedHex, signedHex)
+
+	// WIF of another network is rejected
+	// 其它网络的 WIF 会被拒绝
+	_, err = runCmd(t, packet, nil, "sign", "--chain", "bitcoin-testnet3", "--wif", mainnetWif.String())
+	require.Error(t, err)
+}
+
+// TestRun_SignPsbtNonWitnessUtxo signs PSBT with non-witness utxo and rejects utxo not matching the spent tx
+//
+// TestRun_SignPsbtNonWitnessUtxo 签名带非见证 utxo 的 PSBT，并拒绝和被花费交易不匹配的 utxo
+func TestRun_SignPsbtNonWitnessUtxo(t *testing.T) {
+	senderPkScript, err := gobtcsign.GetAddressPkScript(testSenderAddress, &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	targetPkScript, err := gobtcsign.GetAddressPkScript("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxIn(wire.NewTxIn(gobtcsign.MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(4900, senderPkScript))
+	prevTxHash := prevTx.TxHash()
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevTxHash, 0), nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(3000, targetPkScript))
+	newPacket := func(nonWitnessUtxo *wire.MsgTx, witnessUtxo *wire.TxOut) string {
+		packet, err := psbt.NewFromUnsignedTx(msgTx)
+		require.NoError(t, err)
+		packet.Inputs[0].NonWitnessUtxo = nonWitnessUtxo
+		packet.Inputs[0].WitnessUtxo = witnessUtxo
+		text, err := packet.B64Encode()
+		require.NoError(t, err)
+		return text
+	}
+	keyFile := writeTempFile(t, "key.txt", testPrivateKeyHex)
+
+	_, err = runCmd(t, newPacket(prevTx, nil), nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", keyFile)
+	require.NoError(t, err)
+	_, err = runCmd(t, newPacket(prevTx, wire.NewTxOut(4900, senderPkScript)), nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", keyFile)
+	require.NoError(t, err)
+
+	// Witness utxo disagreeing with non-witness utxo is rejected
+	// 见证 utxo 和非见证 utxo 不一致时会被拒绝
+	_, err = runCmd(t, newPacket(prevTx, wire.NewTxOut(4901, senderPkScript)), nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", keyFile)
+	require.ErrorContains(t, err, "mismatches")
+
+	// Non-witness utxo of another tx is rejected
+	// 其它交易的非见证 utxo 会被拒绝
+	otherTx := prevTx.Copy()
+	otherTx.TxOut[0].Value = 49000
+	_, err = runCmd(t, newPacket(otherTx, nil), nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", keyFile)
+	require.ErrorContains(t, err, "non-witness utxo hash")
+}
+
+// TestRun_SignDOGE signs Dogecoin testnet tx and estimates its fee per started kB
+//
+// TestRun_SignDOGE 签名狗狗币测试网交易，并按每个开始的 kB 预估其手续费
+func TestRun_SignDOGE(t *testing.T) {
+	const paramsJSON = `{
+  "VinList": [
+    {"OutPoint": {"Hash": "57a3514865d3f4c5cbd49270204aaf4928c4c10651430dcd0cb79b80cda5ef0b", "Index": 0}, "Sender": {"Address": "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC"}, "Amount": 6799372, "RBFInfo": {"AllowRBF": false, "Sequence": 4294967295}},
+    {"OutPoint": {"Hash": "af3ec989221c5940bc6fe811b8746f043df7ffd77afd5dd6250d4e82928b8cb4", "Index": 0}, "Sender": {"Address": "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC"}, "Amount": 14632612, "RBFInfo": {"AllowRBF": false, "Sequence": 4294967295}}
+  ],
+  "OutList": [
+    {"Target": {"Address": "ng4P16anXNUrQw6VKHmoMW8NHsTkFBdNrn"}, "Amount": 1234567},
+    {"Target": {"Address": "ndEqDSpcZquZspz5uro1M21ENmrz9Gbp9K"}, "Amount": 2345678},
+    {"Target": {"Address": "nnCfgxxyuJvYDanY3Y2nQMxW9wWWfGjkvR"}, "Amount": 3456789},
+    {"Target": {"Address": "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC"}, "Amount": 14049272}
+  ],
+  "RBFInfo": {"AllowRBF": true, "Sequence": 4294967293}
+}`
+	keyFile := writeTempFile(t, "key.txt", "5f397bc72377b75db7b008a9c3fcd71651bfb138d6fc2458bb0279b9cfc8442a")
+	signedHex, err := runCmd(t, paramsJSON, nil, "sign", "--chain", "dogecoin-testnet", "--key-file", keyFile)
+	require.NoError(t, err)
+	msgTx, err := gobtcsign.NewMsgTxFromHex(signedHex)
+	require.NoError(t, err)
+	require.Equal(t, "173d5e1b33fc9adf64cd4b1f3b2ac73acaf0e10c967cd6fa1aa191d817d7ff77", gobtcsign.GetTxHash(msgTx))
+
+	output, err := runCmd(t, paramsJSON, nil, "estimate", "--chain", "dogecoin-testnet", "--fee-rate", "1000000")
+	require.NoError(t, err)
+	var res estimateResult
+	require.NoError(t, json.Unmarshal([]byte(output), &res))
+	require.Equal(t, "dogecoin-testnet", res.Chain)
+	require.Equal(t, int64(1000000), res.FeeRatePerKb)
+	require.Equal(t, int64(1000000), res.Fee) // Below 1 kB pays one started kB // 不到 1 kB 按一个开始的 kB 收费
+	require.Equal(t, int64(345678-1000000), res.ChangeAmount)
+
+	// Bitcoin testnet address does not belong to Dogecoin
+	// 比特币测试网地址不属于狗狗币
+	_, err = runCmd(t, testTxParamsJSON, nil, "build", "--chain", "dogecoin-testnet")
+	require.Error(t, err)
+}
+
+// TestRun_NewWalletKeystoreSign creates wallet with encrypted keystore and signs with the keystore
+//
+// TestRun_NewWalletKeystoreSign 创建带加密密钥库的钱包，并使用该密钥库签名
+func TestRun_NewWalletKeystoreSign(t *testing.T) {
+	output, err := runCmd(t, "", nil, "newwallet", "--chain", "dogecoin")
+	require.NoError(t, err)
+	var wallet newWalletResult
+	require.NoError(t, json.Unmarshal([]byte(output), &wallet))
+	require.True(t, strings.HasPrefix(wallet.Address, "D"))
+	require.NotEmpty(t, wallet.PrivateKeyWif)
+
+	keystorePath := filepath.Join(t.TempDir(), "wallet.json")
+	env := map[string]string{passwordEnvKey: "correct horse battery staple"}
+	output, err = runCmd(t, "", env, "newwallet", "--chain", "bitcoin-testnet3", "--keystore", keystorePath)
+	require.NoError(t, err)
+	wallet = newWalletResult{}
+	require.NoError(t, json.Unmarshal([]byte(output), &wallet))
+	require.True(t, strings.HasPrefix(wallet.Address, "tb1q"))
+	require.Empty(t, wallet.PrivateKeyHex)
+
+	// Keystore file is never overwritten
+	// 密钥库文件永远不会被覆盖
+	_, err = runCmd(t, "", env, "newwallet", "--chain", "bitcoin-testnet3", "--keystore", keystorePath)
+	require.Error(t, err)
+
+	paramsJSON := strings.ReplaceAll(testTxParamsJSON, testSenderAddress, wallet.Address)
+	signedHex, err := runCmd(t, paramsJSON, env, "sign", "--chain", "bitcoin-testnet3", "--keystore", keystorePath)
+	require.NoError(t, err)
+	prevOuts := writeTempFile(t, "prevouts.json", `[{"address": "`+wallet.Address+`", "amount": 4900}]`)
+	_, err = runCmd(t, signedHex, nil, "verify", "--chain", "bitcoin-testnet3", "--prevouts", prevOuts)
+	require.NoError(t, err)
+
+	_, err = runCmd(t, paramsJSON, map[string]string{passwordEnvKey: "wrong"}, "sign", "--chain", "bitcoin-testnet3", "--keystore", keystorePath)
+	require.Error(t, err)
+}
+
+// TestRun_WrongArgs rejects wrong commands, chains, inputs and missing keys
+//
+// TestRun_WrongArgs 拒绝错误的命令、链、输入和缺失的私钥
+func TestRun_WrongArgs(t *testing.T) {
+	_, err := runCmd(t, "", nil)
+	require.Error(t, err)
+	_, err = runCmd(t, "", nil, "unknown")
+	require.Error(t, err)
+	_, err = runCmd(t, testTxParamsJSON, nil, "build", "--chain", "bitcoin-cash")
+	require.Error(t, err)
+	_, err = runCmd(t, `{"VinList": [], "Unknown": 1}`, nil, "build", "--chain", "bitcoin-testnet3")
+	require.Error(t, err)
+	_, err = runCmd(t, testTxParamsJSON, nil, "sign", "--chain", "bitcoin-testnet3")
+	require.Error(t, err)
+	_, err = runCmd(t, `{"VinList": [], "OutList": []}`, nil, "sign", "--chain", "bitcoin-testnet3", "--key-file", writeTempFile(t, "key.txt", testPrivateKeyHex))
+	require.ErrorContains(t, err, "no inputs")
+}
+
+// TestRun_BuildTxParamsDoc builds the same tx from schema v1 in YAML and JSON as from plain params
+//
+// TestRun_BuildTxParamsDoc 使用 YAML 和 JSON 的 v1 格式构建出和普通参数相同的交易
+func TestRun_BuildTxParamsDoc(t *testing.T) {
+	const paramsYAML = `
+version: 1
+inputs:
+  - outpoint: fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0
+    address: tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap
+    amount: "0.000049"
+outputs:
+  - address: tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx
+    amount: 3000
+rbf:
+  allow_rbf: true
+  sequence: 4294967293
+`
+	expectedHex, err := runCmd(t, testTxParamsJSON, nil, "build", "--chain", "bitcoin-testnet3")
+	require.NoError(t, err)
+	unsignedHex, err := runCmd(t, paramsYAML, nil, "build", "--chain", "bitcoin-testnet3")
+	require.NoError(t, err)
+	require.Equal(t, expectedHex, unsignedHex)
+
+	param, err := gobtcsign.UnmarshalTxParamsYAML([]byte(paramsYAML), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	paramsJSON, err := gobtcsign.MarshalTxParamsJSON(param)
+	require.NoError(t, err)
+	unsignedHex, err = runCmd(t, string(paramsJSON), nil, "build", "--chain", "bitcoin-testnet3")
+	require.NoError(t, err)
+	require.Equal(t, expectedHex, unsignedHex)
+
+	_, err = runCmd(t, strings.Replace(paramsYAML, `"0.000049"`, `0.000049`, 1), nil, "build", "--chain", "bitcoin-testnet3")
+	require.ErrorIs(t, err, gobtcsign.ErrInvalidTxParamsDoc)
+}
+
+// TestLoadKeystore_WrongScryptParams rejects keystore files with scrypt params out of bounds before deriving
+//
+// TestLoadKeystore_WrongScryptParams 在派生之前拒绝 scrypt 参数超出范围的密钥库文件
+func TestLoadKeystore_WrongScryptParams(t *testing.T) {
+	for _, params := range []scryptParams{
+		{N: 1 << 21, R: 8, P: 1},
+		{N: 3 << 10, R: 8, P: 1},
+		{N: 0, R: 8, P: 1},
+		{N: 1 << 10, R: 0, P: 1},
+		{N: 1 << 10, R: 8, P: 4},
+		{N: 1 << 10, R: 1 << 30, P: 1 << 30},
+	} {
+		data, err := json.Marshal(&keystoreFile{Version: keystoreVersion, KDF: "scrypt", KDFParams: params, Cipher: "aes-256-gcm"})
+		require.NoError(t, err)
+		_, err = loadKeystore(writeTempFile(t, "wallet.json", string(data)), "password")
+		require.ErrorContains(t, err, "wrong keystore scrypt")
+	}
+	require.NoError(t, (&scryptParams{N: scryptN, R: scryptR, P: scryptP}).check())
+}
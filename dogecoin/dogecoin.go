@@ -1,24 +1,46 @@
 // Package dogecoin provides Dogecoin network configuration parameters
-// Registers Dogecoin MainNet, TestNet, and RegressionNet chain configs
+// Registration into global chaincfg is opt-in through Register
 //
 // dogecoin 包提供狗狗币网络配置参数
-// 注册狗狗币主网、测试网和回归测试网链配置
+// 注册到全局 chaincfg 需要主动调用 Register
 package dogecoin
 
 import (
+	"sync"
+
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
 )
 
-func init() {
-	if err := chaincfg.Register(&MainNetParams); err != nil {
-		panic(err)
-	}
-	if err := chaincfg.Register(&TestNetParams); err != nil {
-		panic(err)
-	}
-	if err := chaincfg.Register(&RegressionNetParams); err != nil {
-		panic(err)
-	}
+// MessageMagic is the prefix used by Dogecoin Core signmessage/verifymessage
+// Pass it to gobtcsign.SignMessageWithMagic and gobtcsign.VerifyMessageWithMagic
+//
+// MessageMagic 是 Dogecoin Core signmessage/verifymessage 使用的前缀
+// 把它传给 gobtcsign.SignMessageWithMagic 和 gobtcsign.VerifyMessageWithMagic 即可
+const MessageMagic = "Dogecoin Signed Message:\n"
+
+var registerOnce struct {
+	once sync.Once
+	err  error
+}
+
+// Register registers Dogecoin MainNet, TestNet and RegressionNet into the global chaincfg registry, it is opt-in
+// gobtcsign decodes Dogecoin addresses without it, call it only when other code needs btcutil.DecodeAddress or hdkeychain
+// Repeated calls return the result of the first call
+//
+// Register 把狗狗币主网、测试网和回归测试网注册到全局 chaincfg 注册表，需要主动调用
+// gobtcsign 不依赖它就能解析狗狗币地址，只有其它代码需要 btcutil.DecodeAddress 或 hdkeychain 时才调用
+// 重复调用返回第一次调用的结果
+func Register() error {
+	registerOnce.once.Do(func() {
+		for _, netParams := range []*chaincfg.Params{&MainNetParams, &TestNetParams, &RegressionNetParams} {
+			if err := chaincfg.Register(netParams); err != nil {
+				registerOnce.err = errors.WithMessagef(err, "wrong register network=%s", netParams.Name)
+				return
+			}
+		}
+	})
+	return registerOnce.err
 }
 
 // MainNetParams represents chain configuration for Dogecoin mainnet
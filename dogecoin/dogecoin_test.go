@@ -3,6 +3,7 @@ package dogecoin
 import (
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -95,3 +96,15 @@ func TestParams_AddressEncodingDiffers(t *testing.T) {
 	require.NotEqual(t, MainNetParams.Bech32HRPSegwit, TestNetParams.Bech32HRPSegwit)
 	require.NotEqual(t, MainNetParams.Bech32HRPSegwit, RegressionNetParams.Bech32HRPSegwit)
 }
+
+// TestRegister validates opt-in registration lets btcutil.DecodeAddress decode doget1 addresses
+//
+// TestRegister 验证主动注册后 btcutil.DecodeAddress 能够解析 doget1 地址
+func TestRegister(t *testing.T) {
+	require.NoError(t, Register())
+	require.NoError(t, Register()) // repeated call is fine // 重复调用没有问题
+
+	address, err := btcutil.DecodeAddress("doget1qknwmnkmgqcdqlmys5j72auslstyvlg0twvv68k", &TestNetParams)
+	require.NoError(t, err)
+	require.True(t, address.IsForNet(&TestNetParams))
+}
@@ -47,9 +47,16 @@ type DustFee = dusts.DustFee
 // 参考：https://github.com/dogecoin/dogecoin/blob/b4a5d2bef20f5cca54d9c14ca118dec259e47bb4/doc/fee-recommendation.md
 // 狗狗币定义软灰尘和硬灰尘限制 - 硬灰尘会被拒绝，软灰尘会收取额外费用
 func NewDogeDustFee() DustFee {
+	return NewDogeDustFeeV2(DefaultRelayPolicy())
+}
+
+// NewDogeDustFeeV2 creates DustFee with soft dust rules of relay policy
+//
+// NewDogeDustFeeV2 使用转发策略的软灰尘规则创建 DustFee
+func NewDogeDustFeeV2(policy *RelayPolicy) DustFee {
 	res := dusts.NewDustFee()
-	res.SoftDustSize = SoftDustLimit
-	res.ExtraDustFee = ExtraDustsFee
+	res.SoftDustSize = policy.SoftDustLimit
+	res.ExtraDustFee = policy.ExtraDustFee
 	return res
 }
 
@@ -65,9 +72,17 @@ type DustLimit = dusts.DustLimit
 // 使用独立于费率的简单常量比较
 // 低于 MinDustOutput 的输出被视为灰尘并拒绝
 func NewDogeDustLimit() *DustLimit {
+	return NewDogeDustLimitV2(DefaultRelayPolicy())
+}
+
+// NewDogeDustLimitV2 creates DustLimit with hard dust limit of relay policy
+//
+// NewDogeDustLimitV2 使用转发策略的硬灰尘限制创建 DustLimit
+func NewDogeDustLimitV2(policy *RelayPolicy) *DustLimit {
+	hardDustLimit := policy.HardDustLimit
 	return dusts.NewDustLimit(func(output *wire.TxOut, relayFeePerKb btcutil.Amount) bool {
 		// Dogecoin dust rules are simple - direct constant comparison, no fee rate dependency
 		// 狗狗币的灰尘规定比较简单 - 直接和常量比较，不依赖于费率
-		return output.Value < MinDustOutput
+		return btcutil.Amount(output.Value) < hardDustLimit
 	})
 }
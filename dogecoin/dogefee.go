@@ -0,0 +1,82 @@
+package dogecoin
+
+import (
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/yyle88/gobtcsign/internal/feerates"
+)
+
+const (
+	// MinRelayFeePerKb represents min relay fee rate (0.001 DOGE/kB) of Dogecoin Core 1.14.5+
+	// Reference: https://github.com/dogecoin/dogecoin/blob/master/doc/fee-recommendation.md
+	//
+	// MinRelayFeePerKb 代表狗狗币 Core 1.14.5+ 的最低转发费率（0.001 DOGE/kB）
+	// 参考：https://github.com/dogecoin/dogecoin/blob/master/doc/fee-recommendation.md
+	MinRelayFeePerKb = 100000
+
+	// MaxFeeRatePerKb represents sane ceiling of fee rate (1 DOGE/kB), far above the recommended 0.01 DOGE/kB
+	//
+	// MaxFeeRatePerKb 代表合理的费率上限（1 DOGE/kB），远高于推荐的 0.01 DOGE/kB
+	MaxFeeRatePerKb = 100000000
+)
+
+// FeeRateBounds type alias from internal feerates package
+// FeeRateBounds 来自 internal feerates 包的类型别名
+type FeeRateBounds = feerates.FeeRateBounds
+
+// NewDogeFeeRateBounds creates FeeRateBounds with Dogecoin min relay fee as floor
+//
+// NewDogeFeeRateBounds 创建以狗狗币最低转发费率为下限的 FeeRateBounds
+func NewDogeFeeRateBounds() FeeRateBounds {
+	return NewDogeFeeRateBoundsV2(DefaultRelayPolicy())
+}
+
+// NewDogeFeeRateBoundsV2 creates FeeRateBounds with min relay fee of relay policy as floor
+//
+// NewDogeFeeRateBoundsV2 创建以转发策略的最低转发费率为下限的 FeeRateBounds
+func NewDogeFeeRateBoundsV2(policy *RelayPolicy) FeeRateBounds {
+	return feerates.NewFeeRateBounds(policy.MinRelayFeePerKb, max(MaxFeeRatePerKb, policy.MinRelayFeePerKb))
+}
+
+// FeeCalculator charges Dogecoin fee per started 1000 bytes plus soft dust surcharge
+// Dogecoin has no SegWit, so the estimated size is the serialized size
+//
+// FeeCalculator 按照每个开始的 1000 字节收取狗狗币费用，并加上软灰尘附加费
+// 狗狗币没有 SegWit，因此预估大小就是序列化大小
+type FeeCalculator struct {
+	FeeRatePerKb     btcutil.Amount // Fee per started kB, raised to MinRelayFeePerKb when lower // 每个开始的 kB 的费用，低于 MinRelayFeePerKb 时会被提高
+	MinRelayFeePerKb btcutil.Amount // Min relay fee rate of the node // 节点的最低转发费率
+	DustFee          DustFee        // Soft dust surcharge // 软灰尘附加费
+}
+
+// NewDogeFeeCalculator creates FeeCalculator with fee rate and Dogecoin soft dust rules
+//
+// NewDogeFeeCalculator 使用费率和狗狗币软灰尘规则创建 FeeCalculator
+func NewDogeFeeCalculator(feeRatePerKb btcutil.Amount) *FeeCalculator {
+	return NewDogeFeeCalculatorV2(DefaultRelayPolicy(), feeRatePerKb)
+}
+
+// NewDogeFeeCalculatorV2 creates FeeCalculator with fee rate and rules of relay policy
+//
+// NewDogeFeeCalculatorV2 使用费率和转发策略的规则创建 FeeCalculator
+func NewDogeFeeCalculatorV2(policy *RelayPolicy, feeRatePerKb btcutil.Amount) *FeeCalculator {
+	return &FeeCalculator{
+		FeeRatePerKb:     feeRatePerKb,
+		MinRelayFeePerKb: policy.MinRelayFeePerKb,
+		DustFee:          NewDogeDustFeeV2(policy),
+	}
+}
+
+// CalcFee returns fee of tx with size and outputs, it matches gobtcsign.TxFeeCalculator
+//
+// CalcFee 返回指定大小和输出的交易的费用，它符合 gobtcsign.TxFeeCalculator
+func (c *FeeCalculator) CalcFee(txSize int, outputs []*wire.TxOut) btcutil.Amount {
+	return FeeForSize(max(c.FeeRatePerKb, c.MinRelayFeePerKb), txSize) + c.DustFee.SumExtraDustFee(outputs)
+}
+
+// FeeForSize returns fee rate multiplied by count of started kB, such as 1001 bytes count as 2 kB
+//
+// FeeForSize 返回费率乘以开始的 kB 数量，比如 1001 字节算作 2 kB
+func FeeForSize(feeRatePerKb btcutil.Amount, txSize int) btcutil.Amount {
+	return feeRatePerKb * btcutil.Amount((txSize+999)/1000)
+}
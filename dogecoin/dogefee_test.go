@@ -0,0 +1,38 @@
+package dogecoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeeForSize validates fee is charged per started kB
+//
+// TestFeeForSize 验证费用按开始的 kB 收取
+func TestFeeForSize(t *testing.T) {
+	require.Equal(t, btcutil.Amount(0), FeeForSize(MinRelayFeePerKb, 0))
+	require.Equal(t, btcutil.Amount(MinRelayFeePerKb), FeeForSize(MinRelayFeePerKb, 1))
+	require.Equal(t, btcutil.Amount(MinRelayFeePerKb), FeeForSize(MinRelayFeePerKb, 1000))
+	require.Equal(t, btcutil.Amount(2*MinRelayFeePerKb), FeeForSize(MinRelayFeePerKb, 1001))
+}
+
+// TestFeeCalculator_CalcFee validates rounding, min relay floor and soft dust surcharge
+//
+// TestFeeCalculator_CalcFee 验证取整、最低转发费率下限和软灰尘附加费
+func TestFeeCalculator_CalcFee(t *testing.T) {
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(2000000, nil), // not soft dust
+		wire.NewTxOut(500000, nil),  // soft dust
+	}
+
+	calculator := NewDogeFeeCalculator(1000000)
+	require.Equal(t, btcutil.Amount(1000000+ExtraDustsFee), calculator.CalcFee(226, outputs))
+	require.Equal(t, btcutil.Amount(2000000+ExtraDustsFee), calculator.CalcFee(1226, outputs))
+
+	// Fee rate below min relay fee is raised
+	// 低于最低转发费率的费率会被提高
+	calculator = NewDogeFeeCalculator(1000)
+	require.Equal(t, btcutil.Amount(MinRelayFeePerKb), calculator.CalcFee(226, outputs[:1]))
+}
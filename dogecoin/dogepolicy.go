@@ -0,0 +1,131 @@
+package dogecoin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/pkg/errors"
+)
+
+// RelayPolicy bundles relay rules of a Dogecoin node: min relay fee, hard dust, soft dust and per-dust surcharge
+// Dogecoin Core changed the defaults across 1.14.x releases and some pools run their own values,
+// so pick the profile matching the node the tx is broadcast to
+//
+// RelayPolicy 打包狗狗币节点的转发规则：最低转发费率、硬灰尘、软灰尘和每个灰尘的附加费
+// 狗狗币 Core 在 1.14.x 的版本中修改过默认值，有的矿池也使用自己的值，
+// 因此要选择和广播交易的节点匹配的配置
+type RelayPolicy struct {
+	Name             string         // Profile name such as 1.14.5 or custom // 配置名称，比如 1.14.5 或 custom
+	MinRelayFeePerKb btcutil.Amount // Min relay fee rate per started kB // 每个开始的 kB 的最低转发费率
+	HardDustLimit    btcutil.Amount // Outputs below are rejected // 低于它的输出会被拒绝
+	SoftDustLimit    btcutil.Amount // Outputs below are charged ExtraDustFee // 低于它的输出会收取 ExtraDustFee
+	ExtraDustFee     btcutil.Amount // Surcharge per soft dust output // 每个软灰尘输出的附加费
+}
+
+var (
+	// PolicyV1_14_0 represents defaults of Dogecoin Core 1.14.0 to 1.14.3: 1 DOGE/kB relay fee and 1 DOGE soft dust
+	// No hard dust limit, dust outputs are relayed once the surcharge is paid
+	//
+	// PolicyV1_14_0 代表狗狗币 Core 1.14.0 到 1.14.3 的默认值：1 DOGE/kB 的转发费率和 1 DOGE 的软灰尘
+	// 没有硬灰尘限制，灰尘输出只要支付了附加费就会被转发
+	PolicyV1_14_0 = RelayPolicy{
+		Name:             "1.14.0",
+		MinRelayFeePerKb: 100000000,
+		HardDustLimit:    0,
+		SoftDustLimit:    100000000,
+		ExtraDustFee:     100000000,
+	}
+
+	// PolicyV1_14_4 represents defaults of Dogecoin Core 1.14.4: fees lowered and soft dust at 0.01 DOGE
+	// Still no hard dust limit, -harddustlimit only arrives in 1.14.5
+	//
+	// PolicyV1_14_4 代表狗狗币 Core 1.14.4 的默认值：费用降低，软灰尘是 0.01 DOGE
+	// 仍然没有硬灰尘限制，-harddustlimit 到 1.14.5 才加入
+	PolicyV1_14_4 = RelayPolicy{
+		Name:             "1.14.4",
+		MinRelayFeePerKb: 100000,
+		HardDustLimit:    0,
+		SoftDustLimit:    1000000,
+		ExtraDustFee:     1000000,
+	}
+
+	// PolicyV1_14_5 represents defaults of Dogecoin Core 1.14.5 and later, which adds -harddustlimit of 0.001 DOGE
+	// Reference: https://github.com/dogecoin/dogecoin/blob/master/doc/fee-recommendation.md
+	//
+	// PolicyV1_14_5 代表狗狗币 Core 1.14.5 及之后版本的默认值，增加了 0.001 DOGE 的 -harddustlimit
+	// 参考：https://github.com/dogecoin/dogecoin/blob/master/doc/fee-recommendation.md
+	PolicyV1_14_5 = RelayPolicy{
+		Name:             "1.14.5",
+		MinRelayFeePerKb: MinRelayFeePerKb,
+		HardDustLimit:    MinDustOutput,
+		SoftDustLimit:    SoftDustLimit,
+		ExtraDustFee:     ExtraDustsFee,
+	}
+)
+
+// DefaultRelayPolicy returns copy of the policy of current Dogecoin Core, used by NewDogeDustFee and NewDogeDustLimit
+//
+// DefaultRelayPolicy 返回当前狗狗币 Core 策略的副本，NewDogeDustFee 和 NewDogeDustLimit 使用它
+func DefaultRelayPolicy() *RelayPolicy {
+	res := PolicyV1_14_5
+	return &res
+}
+
+// GetRelayPolicy returns copy of the policy profile of Dogecoin Core version such as 1.14.6 or v1.14.2
+//
+// GetRelayPolicy 返回狗狗币 Core 版本的策略配置副本，版本比如 1.14.6 或 v1.14.2
+func GetRelayPolicy(version string) (*RelayPolicy, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "1" || parts[1] != "14" {
+		return nil, errors.Errorf("wrong dogecoin core version=%s, expected 1.14.x", version)
+	}
+	var patch int
+	if len(parts) == 3 {
+		num, err := strconv.Atoi(parts[2])
+		if err != nil || num < 0 {
+			return nil, errors.Errorf("wrong dogecoin core version=%s patch", version)
+		}
+		patch = num
+	}
+	var res RelayPolicy
+	switch {
+	case patch < 4:
+		res = PolicyV1_14_0
+	case patch == 4:
+		res = PolicyV1_14_4
+	default:
+		res = PolicyV1_14_5
+	}
+	return &res, nil
+}
+
+// NewCustomRelayPolicy creates policy for nodes running their own values, such as pools with -minrelaytxfee and -dustlimit
+//
+// NewCustomRelayPolicy 为使用自己配置的节点创建策略，比如设置了 -minrelaytxfee 和 -dustlimit 的矿池
+func NewCustomRelayPolicy(minRelayFeePerKb, hardDustLimit, softDustLimit, extraDustFee btcutil.Amount) (*RelayPolicy, error) {
+	res := &RelayPolicy{
+		Name:             "custom",
+		MinRelayFeePerKb: minRelayFeePerKb,
+		HardDustLimit:    hardDustLimit,
+		SoftDustLimit:    softDustLimit,
+		ExtraDustFee:     extraDustFee,
+	}
+	if err := res.Check(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Check validates values are not negative and soft dust limit is not below hard dust limit
+//
+// Check 校验数值不是负数，并且软灰尘限制不低于硬灰尘限制
+func (p *RelayPolicy) Check() error {
+	if p.MinRelayFeePerKb < 0 || p.HardDustLimit < 0 || p.SoftDustLimit < 0 || p.ExtraDustFee < 0 {
+		return errors.Errorf("wrong relay policy=%s has negative value", p.Name)
+	}
+	if p.SoftDustLimit < p.HardDustLimit {
+		return errors.Errorf("wrong relay policy=%s soft-dust-limit=%d < hard-dust-limit=%d", p.Name, int64(p.SoftDustLimit), int64(p.HardDustLimit))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package dogecoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultRelayPolicy validates default profile matches the package constants
+//
+// TestDefaultRelayPolicy 验证默认配置和包中的常量一致
+func TestDefaultRelayPolicy(t *testing.T) {
+	policy := DefaultRelayPolicy()
+	require.Equal(t, "1.14.5", policy.Name)
+	require.Equal(t, btcutil.Amount(MinRelayFeePerKb), policy.MinRelayFeePerKb)
+	require.Equal(t, btcutil.Amount(MinDustOutput), policy.HardDustLimit)
+	require.Equal(t, btcutil.Amount(SoftDustLimit), policy.SoftDustLimit)
+	require.Equal(t, btcutil.Amount(ExtraDustsFee), policy.ExtraDustFee)
+	require.NoError(t, policy.Check())
+
+	// Changing the copy does not change the profile
+	// 修改副本不会修改配置
+	policy.HardDustLimit = 0
+	require.Equal(t, btcutil.Amount(MinDustOutput), PolicyV1_14_5.HardDustLimit)
+}
+
+// TestGetRelayPolicy validates version to profile mapping
+//
+// TestGetRelayPolicy 验证版本到配置的映射
+func TestGetRelayPolicy(t *testing.T) {
+	testCases := map[string]string{
+		"1.14.0":  "1.14.0",
+		"1.14.3":  "1.14.0",
+		"v1.14.4": "1.14.4",
+		"1.14.5":  "1.14.5",
+		"1.14.9":  "1.14.5",
+		"1.14":    "1.14.0",
+	}
+	for version, expected := range testCases {
+		policy, err := GetRelayPolicy(version)
+		require.NoError(t, err, version)
+		require.Equal(t, expected, policy.Name, version)
+	}
+
+	for _, version := range []string{"1.13.0", "2.0", "1.14.x", "latest"} {
+		_, err := GetRelayPolicy(version)
+		require.Error(t, err, version)
+	}
+}
+
+// TestNewCustomRelayPolicy validates custom profile drives dust rules and fee calculation
+//
+// TestNewCustomRelayPolicy 验证自定义配置决定灰尘规则和费用计算
+func TestNewCustomRelayPolicy(t *testing.T) {
+	_, err := NewCustomRelayPolicy(100000, 2000000, 1000000, 1000000)
+	require.Error(t, err)
+
+	policy, err := NewCustomRelayPolicy(500000, 200000, 5000000, 2000000)
+	require.NoError(t, err)
+	require.Equal(t, "custom", policy.Name)
+
+	dustLimit := NewDogeDustLimitV2(policy)
+	require.True(t, dustLimit.IsDustOutput(wire.NewTxOut(199999, nil), 0))
+	require.False(t, dustLimit.IsDustOutput(wire.NewTxOut(200000, nil), 0))
+
+	dustFee := NewDogeDustFeeV2(policy)
+	require.Equal(t, btcutil.Amount(5000000), dustFee.SoftDustSize)
+	require.Equal(t, btcutil.Amount(2000000), dustFee.ExtraDustFee)
+
+	outputs := []*wire.TxOut{wire.NewTxOut(3000000, nil)} // soft dust under this policy // 在这个策略下是软灰尘
+	calculator := NewDogeFeeCalculatorV2(policy, 100000)
+	require.Equal(t, btcutil.Amount(500000+2000000), calculator.CalcFee(226, outputs))
+
+	bounds := NewDogeFeeRateBoundsV2(policy)
+	require.Equal(t, btcutil.Amount(500000), bounds.Clamp(100000))
+}
+
+// TestPolicyV1_14_0 validates old profile charges 1 DOGE per soft dust output
+//
+// TestPolicyV1_14_0 验证旧的配置对每个软灰尘输出收取 1 DOGE
+func TestPolicyV1_14_0(t *testing.T) {
+	policy, err := GetRelayPolicy("1.14.2")
+	require.NoError(t, err)
+
+	outputs := []*wire.TxOut{wire.NewTxOut(50000000, nil)}
+	calculator := NewDogeFeeCalculatorV2(policy, 1000000)
+	require.Equal(t, btcutil.Amount(100000000+100000000), calculator.CalcFee(226, outputs))
+
+	// Tiny outputs are charged but never rejected before 1.14.5
+	require.False(t, NewDogeDustLimitV2(policy).IsDustOutput(wire.NewTxOut(1, nil), policy.MinRelayFeePerKb))
+}
@@ -0,0 +1,349 @@
+package gobtcsign
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// ElectrumClient implements GetUtxoFromInterface using Electrum JSON-RPC protocol over TCP or TLS
+// Works with ElectrumX/Fulcrum/electrs servers, including those of Dogecoin and Litecoin
+// Reference: https://electrumx.readthedocs.io/en/latest/protocol-methods.html
+//
+// ElectrumClient 使用基于 TCP 或 TLS 的 Electrum JSON-RPC 协议实现 GetUtxoFromInterface
+// 适用于 ElectrumX/Fulcrum/electrs 服务器，包括狗狗币和莱特币的服务器
+// 参考：https://electrumx.readthedocs.io/en/latest/protocol-methods.html
+type ElectrumClient struct {
+	address   string           // Server host:port // 服务器地址 host:port
+	tlsConfig *tls.Config      // Uses TLS when not nil // 不为 nil 时使用 TLS
+	netParams *chaincfg.Params // Network to decode addresses // 解析地址所用的网络参数
+	timeout   time.Duration    // Dial timeout // 连接超时时间
+	mutex     sync.Mutex       // One request group on the connection at a time // 同一时间连接上只有一组请求
+	conn      net.Conn         // Connection, dialed on demand // 连接，按需建立
+	reader    *bufio.Reader    // Reads newline delimited responses // 读取以换行分隔的响应
+	nextID    int64            // Next request id // 下一个请求编号
+}
+
+// NewElectrumClient creates ElectrumClient with server address, TLS config and network params
+// Uses plain TCP when tlsConfig is nil, the connection is dialed on first request
+//
+// NewElectrumClient 使用服务器地址、TLS 配置和网络参数创建 ElectrumClient
+// 当 tlsConfig 为 nil 时使用普通 TCP，在首次请求时建立连接
+func NewElectrumClient(address string, tlsConfig *tls.Config, netParams *chaincfg.Params) *ElectrumClient {
+	return &ElectrumClient{
+		address:   address,
+		tlsConfig: tlsConfig,
+		netParams: netParams,
+		timeout:   30 * time.Second,
+	}
+}
+
+// ElectrumScriptHash converts pkScript to Electrum script hash (reversed sha256 in hex)
+//
+// ElectrumScriptHash 把 pkScript 转换为 Electrum 的脚本哈希（反转的 sha256 的十六进制）
+func ElectrumScriptHash(pkScript []byte) string {
+	hash := sha256.Sum256(pkScript)
+	return chainhash.Hash(hash).String()
+}
+
+type electrumRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type electrumResponse struct {
+	ID     *int64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+type electrumUnspent struct {
+	TxHash string `json:"tx_hash"`
+	TxPos  uint32 `json:"tx_pos"`
+	Height int64  `json:"height"`
+	Value  int64  `json:"value"`
+}
+
+// GetUtxoFrom retrieves UTXO sender and amount from Electrum server
+//
+// GetUtxoFrom 从 Electrum 服务器检索 UTXO 发送者和数量
+func (ec *ElectrumClient) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	results, err := ec.GetUtxoFromList(context.Background(), []wire.OutPoint{utxo})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// GetUtxoFromList retrieves many UTXOs from Electrum server
+// Each previous transaction is fetched once, all requests are pipelined on one connection
+//
+// GetUtxoFromList 从 Electrum 服务器检索多个 UTXO
+// 每个前置交易只请求一次，所有请求在同一个连接上流水线发送
+func (ec *ElectrumClient) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	var txHashes = make([]chainhash.Hash, 0, len(utxos))
+	var txResults = make(map[chainhash.Hash]*wire.MsgTx, len(utxos))
+	for _, utxo := range utxos {
+		if _, ok := txResults[utxo.Hash]; !ok {
+			txResults[utxo.Hash] = nil
+			txHashes = append(txHashes, utxo.Hash)
+		}
+	}
+
+	var paramsList = make([][]interface{}, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		paramsList = append(paramsList, []interface{}{txHash.String(), false})
+	}
+	results, err := ec.callMany(ctx, "blockchain.transaction.get", paramsList)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong get-txs")
+	}
+	for idx, result := range results {
+		var txHex string
+		if err := json.Unmarshal(result, &txHex); err != nil {
+			return nil, errors.WithMessagef(err, "wrong get-tx result. hash=%s", txHashes[idx].String())
+		}
+		msgTx, err := NewMsgTxFromHex(txHex)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong get-tx result. hash=%s", txHashes[idx].String())
+		}
+		if msgTx.TxHash() != txHashes[idx] {
+			return nil, errors.Errorf("wrong get-tx result. hash=%s mismatch %s", txHashes[idx].String(), msgTx.TxHash().String())
+		}
+		txResults[txHashes[idx]] = msgTx
+	}
+
+	var utxoFroms = make([]*SenderAmountUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		previousUtxoTx := txResults[utxo.Hash]
+		if int64(utxo.Index) >= int64(len(previousUtxoTx.TxOut)) {
+			return nil, errors.Errorf("wrong utxo[%s:%d] index-out-of-range vout-count=%d", utxo.Hash.String(), utxo.Index, len(previousUtxoTx.TxOut))
+		}
+		previousOutput := previousUtxoTx.TxOut[utxo.Index]
+		utxoFroms = append(utxoFroms, NewSenderAmountUtxo(
+			&AddressTuple{Address: ec.extractAddress(previousOutput.PkScript), PkScript: previousOutput.PkScript},
+			previousOutput.Value,
+		))
+	}
+	return utxoFroms, nil
+}
+
+// extractAddress returns address of standard single-key script, otherwise empty string
+// The pkScript is kept anyway, so non-standard outputs can still be signed
+//
+// extractAddress 返回标准单密钥脚本的地址，否则返回空字符串
+// 无论如何都会保留 pkScript，因此非标准输出仍然可以签名
+func (ec *ElectrumClient) extractAddress(pkScript []byte) string {
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, ec.netParams)
+	if err != nil || len(addresses) != 1 {
+		return ""
+	}
+	return addresses[0].EncodeAddress()
+}
+
+// ListAddressUtxos lists spendable UTXOs of address, including unconfirmed ones
+//
+// ListAddressUtxos 列出地址的可花费 UTXO，包括未确认的
+func (ec *ElectrumClient) ListAddressUtxos(ctx context.Context, address string) ([]*AddressUtxo, error) {
+	pkScript, err := GetAddressPkScript(address, ec.netParams)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong address")
+	}
+	var items []*electrumUnspent
+	if err := ec.call(ctx, "blockchain.scripthash.listunspent", []interface{}{ElectrumScriptHash(pkScript)}, &items); err != nil {
+		return nil, errors.WithMessage(err, "wrong list-unspent")
+	}
+	var results = make([]*AddressUtxo, 0, len(items))
+	for _, item := range items {
+		txHash, err := chainhash.NewHashFromStr(item.TxHash)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong utxo txid=%s", item.TxHash)
+		}
+		// Height is 0 or -1 (with unconfirmed parents) when the tx is in mempool
+		// 交易在内存池时高度为 0 或 -1（存在未确认的父交易）
+		var blockHeight int64
+		if item.Height > 0 {
+			blockHeight = item.Height
+		}
+		results = append(results, &AddressUtxo{
+			OutPoint:    *wire.NewOutPoint(txHash, item.TxPos),
+			Sender:      AddressTuple{Address: address, PkScript: pkScript},
+			Amount:      item.Value,
+			Confirmed:   item.Height > 0,
+			BlockHeight: blockHeight,
+		})
+	}
+	return results, nil
+}
+
+// Broadcast sends signed transaction to Electrum server and returns txid
+//
+// Broadcast 把已签名的交易发送到 Electrum 服务器并返回交易哈希
+func (ec *ElectrumClient) Broadcast(ctx context.Context, msgTx *wire.MsgTx) (string, error) {
+	txHex, err := CvtMsgTxToHex(msgTx)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong cvt-msg-tx-to-hex")
+	}
+	var txid string
+	if err := ec.call(ctx, "blockchain.transaction.broadcast", []interface{}{txHex}, &txid); err != nil {
+		return "", errors.WithMessage(err, "wrong broadcast")
+	}
+	if txid != GetTxHash(msgTx) {
+		return "", errors.Errorf("wrong broadcast txid mismatch: got %s, expected %s", txid, GetTxHash(msgTx))
+	}
+	return txid, nil
+}
+
+// Close closes the connection, next request dials again
+//
+// Close 关闭连接，下次请求时会重新连接
+func (ec *ElectrumClient) Close() error {
+	ec.mutex.Lock()
+	defer ec.mutex.Unlock()
+	return ec.closeConn()
+}
+
+func (ec *ElectrumClient) call(ctx context.Context, method string, params []interface{}, res interface{}) error {
+	results, err := ec.callMany(ctx, method, [][]interface{}{params})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(results[0], res); err != nil {
+		return errors.WithMessagef(err, "wrong unmarshal-result. method=%s", method)
+	}
+	return nil
+}
+
+// callMany writes one request per params and then reads responses matched by id
+// Connection is dropped on any I/O error, so a broken stream is never reused
+//
+// callMany 为每组参数写入一个请求，然后按编号匹配读取响应
+// 出现任何 I/O 错误时都会断开连接，避免复用已经错乱的数据流
+func (ec *ElectrumClient) callMany(ctx context.Context, method string, paramsList [][]interface{}) ([]json.RawMessage, error) {
+	if len(paramsList) == 0 {
+		return nil, nil
+	}
+	ec.mutex.Lock()
+	defer ec.mutex.Unlock()
+
+	if err := ec.connect(ctx); err != nil {
+		return nil, err
+	}
+	return ec.roundTripWithContext(ctx, method, paramsList)
+}
+
+// roundTripWithContext interrupts blocking I/O by expiring the deadline when ctx is done
+// The connection is closed when that happens, since its deadline is no longer usable
+//
+// roundTripWithContext 在 ctx 结束时通过让截止时间过期来中断阻塞的 I/O
+// 发生这种情况时会关闭连接，因为该连接的截止时间已经不可用
+func (ec *ElectrumClient) roundTripWithContext(ctx context.Context, method string, paramsList [][]interface{}) ([]json.RawMessage, error) {
+	conn := ec.conn
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	results, err := ec.roundTrip(method, paramsList)
+	if !stop() {
+		_ = ec.closeConn()
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		_ = ec.closeConn()
+		return nil, err
+	}
+	return results, nil
+}
+
+func (ec *ElectrumClient) roundTrip(method string, paramsList [][]interface{}) ([]json.RawMessage, error) {
+	var indexes = make(map[int64]int, len(paramsList))
+	var payload []byte
+	for idx, params := range paramsList {
+		ec.nextID++
+		data, err := json.Marshal(&electrumRequest{JSONRPC: "2.0", ID: ec.nextID, Method: method, Params: params})
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong marshal-request")
+		}
+		payload = append(append(payload, data...), '\n')
+		indexes[ec.nextID] = idx
+	}
+	if _, err := ec.conn.Write(payload); err != nil {
+		return nil, errors.WithMessage(err, "wrong write-request")
+	}
+
+	var results = make([]json.RawMessage, len(paramsList))
+	for remain := len(paramsList); remain > 0; {
+		line, err := ec.reader.ReadBytes('\n')
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong read-response")
+		}
+		var response electrumResponse
+		if err := json.Unmarshal(line, &response); err != nil {
+			return nil, errors.WithMessage(err, "wrong unmarshal-response")
+		}
+		// Subscription notifications carry no id
+		// 订阅通知没有编号
+		if response.ID == nil {
+			continue
+		}
+		idx, ok := indexes[*response.ID]
+		if !ok {
+			continue
+		}
+		if len(response.Error) > 0 && string(response.Error) != "null" {
+			return nil, errors.Errorf("wrong %s response. index=%d error=%s", method, idx, string(response.Error))
+		}
+		results[idx] = response.Result
+		delete(indexes, *response.ID)
+		remain--
+	}
+	return results, nil
+}
+
+func (ec *ElectrumClient) connect(ctx context.Context) error {
+	if ec.conn != nil {
+		return nil
+	}
+	dialer := &net.Dialer{Timeout: ec.timeout}
+	var conn net.Conn
+	var err error
+	if ec.tlsConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: ec.tlsConfig}).DialContext(ctx, "tcp", ec.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", ec.address)
+	}
+	if err != nil {
+		return errors.WithMessage(err, "wrong dial")
+	}
+	ec.conn = conn
+	ec.reader = bufio.NewReader(conn)
+
+	// Protocol version must be negotiated before other requests
+	// 在其它请求之前必须先协商协议版本
+	if _, err := ec.roundTripWithContext(ctx, "server.version", [][]interface{}{{"gobtcsign", "1.4"}}); err != nil {
+		return errors.WithMessage(err, "wrong server-version")
+	}
+	return nil
+}
+
+func (ec *ElectrumClient) closeConn() error {
+	if ec.conn == nil {
+		return nil
+	}
+	err := ec.conn.Close()
+	ec.conn = nil
+	ec.reader = nil
+	return err
+}
@@ -0,0 +1,297 @@
+package gobtcsign
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign/dogecoin"
+)
+
+// fakeElectrum serves Electrum JSON-RPC lines from in-memory txs and unspent lists
+type fakeElectrum struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	txs      map[string]string             // txid -> raw tx hex
+	unspent  map[string][]*electrumUnspent // script hash -> unspent list
+	delay    time.Duration                 // delay before each response except server.version
+	conns    int                           // connections accepted
+}
+
+func newFakeElectrum(t *testing.T, tlsConfig *tls.Config) *fakeElectrum {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	fake := &fakeElectrum{
+		listener: listener,
+		txs:      map[string]string{},
+		unspent:  map[string][]*electrumUnspent{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			fake.mutex.Lock()
+			fake.conns++
+			fake.mutex.Unlock()
+			go fake.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return fake
+}
+
+func (f *fakeElectrum) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 1<<20)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var request struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return
+		}
+		if request.Method == "server.version" {
+			// A notification arrives before the response, the client must skip it
+			_ = encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "method": "blockchain.headers.subscribe", "params": []interface{}{map[string]interface{}{"height": 1}}})
+			_ = encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": []string{"ElectrumX 1.16.0", "1.4"}})
+			continue
+		}
+		f.mutex.Lock()
+		delay := f.delay
+		f.mutex.Unlock()
+		time.Sleep(delay)
+		result, rpcErr := f.handle(request.Method, request.Params)
+		if rpcErr != nil {
+			_ = encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "error": map[string]interface{}{"code": 2, "message": rpcErr.Error()}})
+			continue
+		}
+		_ = encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result})
+	}
+}
+
+func (f *fakeElectrum) handle(method string, params []json.RawMessage) (interface{}, error) {
+	var first string
+	_ = json.Unmarshal(params[0], &first)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	switch method {
+	case "blockchain.transaction.get":
+		txHex, ok := f.txs[first]
+		if !ok {
+			return nil, errors.New("No such mempool or blockchain transaction")
+		}
+		return txHex, nil
+	case "blockchain.scripthash.listunspent":
+		return append([]*electrumUnspent{}, f.unspent[first]...), nil
+	case "blockchain.transaction.broadcast":
+		msgTx, err := NewMsgTxFromHex(first)
+		if err != nil {
+			return nil, err
+		}
+		f.txs[GetTxHash(msgTx)] = first
+		return GetTxHash(msgTx), nil
+	}
+	return nil, errors.Errorf("unknown method %s", method)
+}
+
+func (f *fakeElectrum) setDelay(delay time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.delay = delay
+}
+
+func (f *fakeElectrum) connCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.conns
+}
+
+// addTx stores previous tx and registers its outputs as unspent
+func (f *fakeElectrum) addTx(t *testing.T, msgTx *wire.MsgTx, height int64) {
+	txHex, err := CvtMsgTxToHex(msgTx)
+	require.NoError(t, err)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.txs[GetTxHash(msgTx)] = txHex
+	for idx, txOut := range msgTx.TxOut {
+		scriptHash := ElectrumScriptHash(txOut.PkScript)
+		f.unspent[scriptHash] = append(f.unspent[scriptHash], &electrumUnspent{
+			TxHash: GetTxHash(msgTx),
+			TxPos:  uint32(idx),
+			Height: height,
+			Value:  txOut.Value,
+		})
+	}
+}
+
+func newSelfSignedTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{certDER}, PrivateKey: privateKey}}}
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+	return serverConfig, clientConfig
+}
+
+func caseElectrumPreviousTx(t *testing.T, netParams *chaincfg.Params, address string, amounts ...int64) *wire.MsgTx {
+	pkScript, err := GetAddressPkScript(address, netParams)
+	require.NoError(t, err)
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(MustNewOutPoint("5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", 0), nil, nil))
+	for _, amount := range amounts {
+		msgTx.AddTxOut(wire.NewTxOut(amount, pkScript))
+	}
+	msgTx.AddTxOut(wire.NewTxOut(1000, MustGetPkScript(MustNewAddress("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", &chaincfg.TestNet3Params))))
+	return msgTx
+}
+
+func TestElectrumClient_SignAndBroadcast(t *testing.T) {
+	var _ GetUtxoFromInterface = &ElectrumClient{}
+	var _ GetUtxoFromInterfaceV2 = &ElectrumClient{}
+	var _ ListAddressUtxosInterface = &ElectrumClient{}
+
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	const privateKeyHex = "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"
+	netParams := chaincfg.TestNet3Params
+
+	serverConfig, clientConfig := newSelfSignedTLSConfigs(t)
+	fake := newFakeElectrum(t, serverConfig)
+	fake.addTx(t, caseElectrumPreviousTx(t, &netParams, senderAddress, 4900, 4320), 3503553)
+
+	client := NewElectrumClient(fake.listener.Addr().String(), clientConfig, &netParams)
+	defer func() { _ = client.Close() }()
+
+	utxos, err := client.ListAddressUtxos(context.Background(), senderAddress)
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+	require.True(t, utxos[0].Confirmed)
+	require.Equal(t, int64(3503553), utxos[0].BlockHeight)
+
+	param := &BitcoinTxParams{
+		OutList: []OutType{
+			{Target: *NewAddressTuple("tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q"), Amount: 8000},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+	for _, utxo := range utxos {
+		param.VinList = append(param.VinList, utxo.GetVinType(*NewRBFActive()))
+	}
+	require.Equal(t, int64(1220), int64(param.GetFee()))
+
+	signParam, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	require.NoError(t, Sign(senderAddress, privateKeyHex, signParam))
+
+	// Inputs read back from the server have the same senders and amounts
+	customParam, err := NewCustomParamFromMsgTxV2(context.Background(), signParam.MsgTx, client)
+	require.NoError(t, err)
+	require.Equal(t, senderAddress, customParam.VinList[0].Sender.Address)
+	require.Equal(t, int64(1220), int64(customParam.GetFee()))
+	require.NoError(t, customParam.VerifyMsgTxSign(signParam.MsgTx, &netParams))
+
+	txid, err := client.Broadcast(context.Background(), signParam.MsgTx)
+	require.NoError(t, err)
+	require.Equal(t, GetTxHash(signParam.MsgTx), txid)
+
+	// The broadcast tx is now available from the server
+	utxoFrom, err := client.GetUtxoFrom(*MustNewOutPoint(txid, 0))
+	require.NoError(t, err)
+	require.Equal(t, "tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q", utxoFrom.sender.Address)
+	require.Equal(t, int64(8000), utxoFrom.amount)
+
+	_, err = client.GetUtxoFrom(*MustNewOutPoint(txid, 1))
+	require.Error(t, err)
+	t.Log(err)
+
+	_, err = client.GetUtxoFrom(*MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0))
+	require.Error(t, err)
+	t.Log(err)
+
+	// All requests are sent over one connection
+	require.Equal(t, 1, fake.connCount())
+}
+
+func TestElectrumClient_ListAddressUtxos_DOGE(t *testing.T) {
+	const senderAddress = "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC"
+	netParams := dogecoin.TestNetParams
+
+	fake := newFakeElectrum(t, nil)
+	fake.addTx(t, caseElectrumPreviousTx(t, &netParams, senderAddress, 100000000), 0)
+
+	client := NewElectrumClient(fake.listener.Addr().String(), nil, &netParams)
+	defer func() { _ = client.Close() }()
+
+	utxos, err := client.ListAddressUtxos(context.Background(), senderAddress)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	require.False(t, utxos[0].Confirmed)
+	require.Equal(t, int64(100000000), utxos[0].Amount)
+
+	utxoFrom, err := client.GetUtxoFrom(utxos[0].OutPoint)
+	require.NoError(t, err)
+	require.Equal(t, senderAddress, utxoFrom.sender.Address)
+	require.NoError(t, utxoFrom.sender.VerifyMatch(&netParams))
+}
+
+func TestElectrumClient_ContextCanceled(t *testing.T) {
+	netParams := chaincfg.TestNet3Params
+
+	fake := newFakeElectrum(t, nil)
+	fake.setDelay(300 * time.Millisecond)
+
+	client := NewElectrumClient(fake.listener.Addr().String(), nil, &netParams)
+	defer func() { _ = client.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.ListAddressUtxos(ctx, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The interrupted connection is dropped and the next request dials again
+	fake.setDelay(0)
+	utxos, err := client.ListAddressUtxos(context.Background(), "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap")
+	require.NoError(t, err)
+	require.Empty(t, utxos)
+	require.Equal(t, 2, fake.connCount())
+}
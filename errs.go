@@ -0,0 +1,155 @@
+package gobtcsign
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/pkg/errors"
+)
+
+// Sentinel errors of building, signing and verifying, check them with errors.Is
+// The typed errors below match them and carry details, get details with errors.As
+//
+// 拼装、签名和验签的哨兵错误，使用 errors.Is 检查
+// 下面的类型化错误能匹配它们并携带详情，使用 errors.As 获取详情
+var (
+	ErrUnsupportedAddressType = errors.New("unsupported address type")   // Address type has no signing logic // 地址类型没有对应的签名逻辑
+	ErrScriptMismatch         = errors.New("address pk-script mismatch") // Address and pk-script disagree // 地址和公钥脚本不一致
+	ErrNoPkScriptNoAddress    = errors.New("no pk-script no address")    // Neither address nor pk-script is set // 地址和公钥脚本都没有设置
+	ErrInputMismatch          = errors.New("input mismatch")             // Tx inputs disagree with params // 交易输入和参数不一致
+	ErrOutputMismatch         = errors.New("output mismatch")            // Tx outputs disagree with params // 交易输出和参数不一致
+	ErrInsufficientFunds      = errors.New("insufficient funds")         // Inputs cannot cover outputs and fee // 输入不足以支付输出和费用
+	ErrDustOutput             = errors.New("dust output")                // Output below dust limit // 输出低于灰尘限制
+	ErrSignatureInvalid       = errors.New("signature invalid")          // Script engine rejects input // 脚本引擎拒绝了输入
+)
+
+// UnsupportedAddressTypeError represents address whose type has no signing logic
+//
+// UnsupportedAddressTypeError 代表其类型没有对应签名逻辑的地址
+type UnsupportedAddressTypeError struct {
+	Address     string // Address string // 地址字符串
+	AddressType string // Go type of decoded address // 解析后地址的 Go 类型
+}
+
+func (e *UnsupportedAddressTypeError) Error() string {
+	return fmt.Sprintf("wrong address=%s address_type=%s not-support-this-address-type", e.Address, e.AddressType)
+}
+
+func (e *UnsupportedAddressTypeError) Is(target error) bool {
+	return target == ErrUnsupportedAddressType
+}
+
+// ScriptMismatchError represents address and pk-script that disagree
+//
+// ScriptMismatchError 代表不一致的地址和公钥脚本
+type ScriptMismatchError struct {
+	Address  string // Address, empty when comparing against tx // 地址，和交易比较时为空
+	Got      []byte // Given pk-script // 给出的公钥脚本
+	Expected []byte // Pk-script derived from address or params // 根据地址或参数得到的公钥脚本
+}
+
+func (e *ScriptMismatchError) Error() string {
+	return fmt.Sprintf("address-pk-script-mismatch: address=%s got %x, expected %x", e.Address, e.Got, e.Expected)
+}
+
+func (e *ScriptMismatchError) Is(target error) bool {
+	return target == ErrScriptMismatch
+}
+
+// InputMismatchError represents tx input disagreeing with params
+// Index is -1 when the input count is what differs
+//
+// InputMismatchError 代表和参数不一致的交易输入
+// 当不一致的是输入数量时 Index 为 -1
+type InputMismatchError struct {
+	Index    int    // Input index, -1 means count // 输入的序号，-1 表示数量
+	Field    string // Mismatched field: count, outpoint-hash, outpoint-index, tx-in-sequence // 不一致的字段
+	Got      string // Value in tx // 交易里的值
+	Expected string // Value in params // 参数里的值
+}
+
+func (e *InputMismatchError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("input %s mismatch: got %s, expected %s", e.Field, e.Got, e.Expected)
+	}
+	return fmt.Sprintf("input %d %s mismatch: got %s, expected %s", e.Index, e.Field, e.Got, e.Expected)
+}
+
+func (e *InputMismatchError) Is(target error) bool {
+	return target == ErrInputMismatch
+}
+
+// OutputMismatchError represents tx output disagreeing with params
+// Index is -1 when the output count is what differs, a script mismatch also matches ErrScriptMismatch
+//
+// OutputMismatchError 代表和参数不一致的交易输出
+// 当不一致的是输出数量时 Index 为 -1，脚本不一致时也能匹配 ErrScriptMismatch
+type OutputMismatchError struct {
+	Index    int    // Output index, -1 means count // 输出的序号，-1 表示数量
+	Field    string // Mismatched field: count, script, amount // 不一致的字段
+	Got      string // Value in tx // 交易里的值
+	Expected string // Value in params // 参数里的值
+}
+
+func (e *OutputMismatchError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("output %s mismatch: got %s, expected %s", e.Field, e.Got, e.Expected)
+	}
+	return fmt.Sprintf("output %d %s mismatch: got %s, expected %s", e.Index, e.Field, e.Got, e.Expected)
+}
+
+func (e *OutputMismatchError) Is(target error) bool {
+	return target == ErrOutputMismatch || (target == ErrScriptMismatch && e.Field == "script")
+}
+
+// InsufficientFundsError represents inputs that cannot cover outputs and fee
+//
+// InsufficientFundsError 代表不足以支付输出和费用的输入
+type InsufficientFundsError struct {
+	Available btcutil.Amount // Sum of inputs // 输入的总额
+	Required  btcutil.Amount // Sum of outputs plus fee // 输出的总额加上费用
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("insufficient funds: available %d, required %d", int64(e.Available), int64(e.Required))
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// DustOutputError represents output below dust limit
+//
+// DustOutputError 代表低于灰尘限制的输出
+type DustOutputError struct {
+	Index  int   // Output index // 输出的序号
+	Amount int64 // Output amount // 输出的数量
+}
+
+func (e *DustOutputError) Error() string {
+	return fmt.Sprintf("output %d amount=%d is dust", e.Index, e.Amount)
+}
+
+func (e *DustOutputError) Is(target error) bool {
+	return target == ErrDustOutput
+}
+
+// SignatureInvalidError represents input rejected by script engine, Err is the engine error
+//
+// SignatureInvalidError 代表被脚本引擎拒绝的输入，Err 是引擎的错误
+type SignatureInvalidError struct {
+	Index int   // Input index // 输入的序号
+	Err   error // Script engine error // 脚本引擎的错误
+}
+
+func (e *SignatureInvalidError) Error() string {
+	return fmt.Sprintf("wrong check-sign-vm-execute. index=%d: %v", e.Index, e.Err)
+}
+
+func (e *SignatureInvalidError) Is(target error) bool {
+	return target == ErrSignatureInvalid
+}
+
+func (e *SignatureInvalidError) Unwrap() error {
+	return e.Err
+}
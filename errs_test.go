@@ -0,0 +1,137 @@
+package gobtcsign
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func caseErrsTxParams() *BitcoinTxParams {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	return &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   4900,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"),
+				Amount: 3000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+}
+
+func TestSign_UnsupportedAddressType(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+	address, err := btcutil.NewAddressTaproot(make([]byte, 32), netParams)
+	require.NoError(t, err)
+
+	param := caseErrsTxParams()
+	param.VinList[0].Sender = *NewAddressTuple(address.EncodeAddress())
+	signParam, err := param.CreateTxSignParams(netParams)
+	require.NoError(t, err)
+
+	err = Sign(address.EncodeAddress(), "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092", signParam)
+	require.ErrorIs(t, err, ErrUnsupportedAddressType)
+	var typeErr *UnsupportedAddressTypeError
+	require.True(t, errors.As(err, &typeErr))
+	require.Equal(t, "*btcutil.AddressTaproot", typeErr.AddressType)
+}
+
+func TestAddressTuple_ScriptMismatch(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+	tuple := &AddressTuple{
+		Address:  "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap",
+		PkScript: MustGetPkScript(MustNewAddress("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", netParams)),
+	}
+	_, err := tuple.GetPkScript(netParams)
+	require.ErrorIs(t, err, ErrScriptMismatch)
+	require.ErrorIs(t, tuple.VerifyMatch(netParams), ErrScriptMismatch)
+
+	_, err = (&AddressTuple{}).GetPkScript(netParams)
+	require.ErrorIs(t, err, ErrNoPkScriptNoAddress)
+}
+
+func TestCheckMsgTxParam_Mismatch(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+	param := caseErrsTxParams()
+	signParam, err := param.CreateTxSignParams(netParams)
+	require.NoError(t, err)
+
+	other := caseErrsTxParams()
+	other.VinList = append(other.VinList, other.VinList[0])
+	err = other.CheckMsgTxParam(signParam.MsgTx, netParams)
+	require.ErrorIs(t, err, ErrInputMismatch)
+	var inputErr *InputMismatchError
+	require.True(t, errors.As(err, &inputErr))
+	require.Equal(t, -1, inputErr.Index)
+	require.Equal(t, "count", inputErr.Field)
+
+	other = caseErrsTxParams()
+	other.VinList[0].OutPoint.Index = 1
+	err = other.CheckMsgTxParam(signParam.MsgTx, netParams)
+	require.True(t, errors.As(err, &inputErr))
+	require.Equal(t, 0, inputErr.Index)
+	require.Equal(t, "outpoint-index", inputErr.Field)
+
+	other = caseErrsTxParams()
+	other.OutList[0].Target = *NewAddressTuple("tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q")
+	err = other.CheckMsgTxParam(signParam.MsgTx, netParams)
+	require.ErrorIs(t, err, ErrOutputMismatch)
+	require.ErrorIs(t, err, ErrScriptMismatch)
+}
+
+func TestCheckFunds_InsufficientFunds(t *testing.T) {
+	param := caseErrsTxParams()
+	param.OutList[0].Amount = 5000
+	err := param.CheckFunds(0)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	var fundsErr *InsufficientFundsError
+	require.True(t, errors.As(err, &fundsErr))
+	require.Equal(t, btcutil.Amount(4900), fundsErr.Available)
+	require.Equal(t, btcutil.Amount(5000), fundsErr.Required)
+
+	require.ErrorIs(t, caseErrsTxParams().CheckFunds(2000), ErrInsufficientFunds)
+	require.NoError(t, caseErrsTxParams().CheckFunds(1900))
+}
+
+func TestCheckDustOutputs(t *testing.T) {
+	param := caseErrsTxParams()
+	require.NoError(t, param.CheckDustOutputs(&chaincfg.TestNet3Params, NewDustLimit(), 1000))
+
+	param.OutList[0].Amount = 100
+	err := param.CheckDustOutputs(&chaincfg.TestNet3Params, NewDustLimit(), 1000)
+	require.ErrorIs(t, err, ErrDustOutput)
+	var dustErr *DustOutputError
+	require.True(t, errors.As(err, &dustErr))
+	require.Equal(t, 0, dustErr.Index)
+}
+
+func TestVerifySign_SignatureInvalid(t *testing.T) {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	netParams := &chaincfg.TestNet3Params
+	param := caseErrsTxParams()
+	signParam, err := param.CreateTxSignParams(netParams)
+	require.NoError(t, err)
+	require.NoError(t, Sign(senderAddress, "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092", signParam))
+
+	// P2WPKH signature commits to the amount, a wrong amount makes it invalid
+	err = VerifySignV2(signParam.MsgTx, []*VerifyTxInputParam{NewVerifyTxInputParam(senderAddress, 4901)}, netParams)
+	require.ErrorIs(t, err, ErrSignatureInvalid)
+	var signErr *SignatureInvalidError
+	require.True(t, errors.As(err, &signErr))
+	require.Equal(t, 0, signErr.Index)
+	require.Error(t, signErr.Err)
+
+	err = VerifySignV2(signParam.MsgTx, []*VerifyTxInputParam{}, netParams)
+	require.ErrorIs(t, err, ErrInputMismatch)
+}
@@ -0,0 +1,357 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// EsploraClient implements GetUtxoFromInterface using Esplora/Electrs HTTP API
+// Also lists address UTXOs, queries tx status and fee estimates, and broadcasts transactions
+// Reference: https://github.com/Blockstream/esplora/blob/master/API.md
+//
+// EsploraClient 使用 Esplora/Electrs HTTP 接口实现 GetUtxoFromInterface
+// 同时支持列出地址的 UTXO、查询交易状态和费率预估，以及广播交易
+// 参考：https://github.com/Blockstream/esplora/blob/master/API.md
+type EsploraClient struct {
+	baseURL     string       // API root such as https://blockstream.info/testnet/api // 接口根地址
+	httpClient  *http.Client // HTTP client // HTTP 客户端
+	concurrency int          // Max requests in flight // 同时进行的最大请求数
+}
+
+// esploraDefaultTimeout bounds each request when NewEsploraClient gets no HTTP client
+// esploraDefaultTimeout 在 NewEsploraClient 没有传入 HTTP 客户端时限制每个请求的时长
+const esploraDefaultTimeout = 30 * time.Second
+
+// esploraMaxResponseSize bounds response body, large enough for address UTXO lists and raw txs
+// esploraMaxResponseSize 限制响应体的大小，足够容纳地址的 UTXO 列表和原始交易
+const esploraMaxResponseSize = 8 << 20
+
+// NewEsploraClient creates EsploraClient with API root and HTTP client
+// Uses client with 30 seconds timeout when httpClient is nil
+//
+// NewEsploraClient 使用接口根地址和 HTTP 客户端创建 EsploraClient
+// 当 httpClient 为 nil 时使用超时为 30 秒的客户端
+func NewEsploraClient(baseURL string, httpClient *http.Client) *EsploraClient {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: esploraDefaultTimeout}
+	}
+	return &EsploraClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		httpClient:  httpClient,
+		concurrency: 8,
+	}
+}
+
+// EsploraTxStatus represents confirmation status of transaction
+//
+// EsploraTxStatus 代表交易的确认状态
+type EsploraTxStatus struct {
+	Confirmed   bool   `json:"confirmed"`              // Whether tx is in a block // 是否已经上链
+	BlockHeight int64  `json:"block_height,omitempty"` // Block height // 区块高度
+	BlockHash   string `json:"block_hash,omitempty"`   // Block hash // 区块哈希
+	BlockTime   int64  `json:"block_time,omitempty"`   // Block timestamp // 区块时间戳
+}
+
+type esploraTxOut struct {
+	ScriptPubKey        string `json:"scriptpubkey"`
+	ScriptPubKeyType    string `json:"scriptpubkey_type"`
+	ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+	Value               int64  `json:"value"`
+}
+
+type esploraTx struct {
+	Txid   string          `json:"txid"`
+	Vout   []*esploraTxOut `json:"vout"`
+	Status EsploraTxStatus `json:"status"`
+}
+
+type esploraUtxo struct {
+	Txid   string          `json:"txid"`
+	Vout   uint32          `json:"vout"`
+	Value  int64           `json:"value"`
+	Status EsploraTxStatus `json:"status"`
+}
+
+// GetUtxoFrom retrieves UTXO sender and amount from Esplora
+//
+// GetUtxoFrom 从 Esplora 检索 UTXO 发送者和数量
+func (ec *EsploraClient) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	results, err := ec.GetUtxoFromList(context.Background(), []wire.OutPoint{utxo})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// GetUtxoFromList retrieves many UTXOs from Esplora, each previous transaction is fetched once
+//
+// GetUtxoFromList 从 Esplora 检索多个 UTXO，每个前置交易只请求一次
+func (ec *EsploraClient) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	var txHashes = make([]chainhash.Hash, 0, len(utxos))
+	var txResults = make(map[chainhash.Hash]*esploraTx, len(utxos))
+	for _, utxo := range utxos {
+		if _, ok := txResults[utxo.Hash]; !ok {
+			txResults[utxo.Hash] = nil
+			txHashes = append(txHashes, utxo.Hash)
+		}
+	}
+
+	var mutex sync.Mutex
+	if err := runWithLimit(ctx, len(txHashes), ec.concurrency, func(ctx context.Context, idx int) error {
+		var res esploraTx
+		if err := ec.getJSON(ctx, "/tx/"+txHashes[idx].String(), &res); err != nil {
+			return errors.WithMessagef(err, "wrong get-tx. hash=%s", txHashes[idx].String())
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		txResults[txHashes[idx]] = &res
+		return nil
+	}); err != nil {
+		return nil, errors.WithMessage(err, "wrong get-txs")
+	}
+
+	var results = make([]*SenderAmountUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		previousUtxoTx := txResults[utxo.Hash]
+		if int64(utxo.Index) >= int64(len(previousUtxoTx.Vout)) {
+			return nil, errors.Errorf("wrong utxo[%s:%d] index-out-of-range vout-count=%d", utxo.Hash.String(), utxo.Index, len(previousUtxoTx.Vout))
+		}
+		previousOutput := previousUtxoTx.Vout[utxo.Index]
+		pkScript, err := hex.DecodeString(previousOutput.ScriptPubKey)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong utxo[%s:%d] pk-script", utxo.Hash.String(), utxo.Index)
+		}
+		results = append(results, NewSenderAmountUtxo(
+			&AddressTuple{Address: previousOutput.ScriptPubKeyAddress, PkScript: pkScript},
+			previousOutput.Value,
+		))
+	}
+	return results, nil
+}
+
+// ListAddressUtxos lists spendable UTXOs of address, including unconfirmed ones
+//
+// ListAddressUtxos 列出地址的可花费 UTXO，包括未确认的
+func (ec *EsploraClient) ListAddressUtxos(ctx context.Context, address string) ([]*AddressUtxo, error) {
+	var items []*esploraUtxo
+	if err := ec.getJSON(ctx, "/address/"+address+"/utxo", &items); err != nil {
+		return nil, errors.WithMessage(err, "wrong get-address-utxo")
+	}
+	var results = make([]*AddressUtxo, 0, len(items))
+	for _, item := range items {
+		txHash, err := chainhash.NewHashFromStr(item.Txid)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong utxo txid=%s", item.Txid)
+		}
+		results = append(results, &AddressUtxo{
+			OutPoint:    *wire.NewOutPoint(txHash, item.Vout),
+			Sender:      *NewAddressTuple(address),
+			Amount:      item.Value,
+			Confirmed:   item.Status.Confirmed,
+			BlockHeight: item.Status.BlockHeight,
+		})
+	}
+	return results, nil
+}
+
+// GetTxStatus returns confirmation status of transaction
+//
+// GetTxStatus 返回交易的确认状态
+func (ec *EsploraClient) GetTxStatus(ctx context.Context, txHash string) (*EsploraTxStatus, error) {
+	var res EsploraTxStatus
+	if err := ec.getJSON(ctx, "/tx/"+txHash+"/status", &res); err != nil {
+		return nil, errors.WithMessage(err, "wrong get-tx-status")
+	}
+	return &res, nil
+}
+
+// GetFeeEstimates returns fee rates (sat/vB) keyed by confirmation target in blocks
+//
+// GetFeeEstimates 返回以确认区块数为键的费率（sat/vB）
+func (ec *EsploraClient) GetFeeEstimates(ctx context.Context) (map[int]float64, error) {
+	var items map[string]float64
+	if err := ec.getJSON(ctx, "/fee-estimates", &items); err != nil {
+		return nil, errors.WithMessage(err, "wrong get-fee-estimates")
+	}
+	var res = make(map[int]float64, len(items))
+	for key, value := range items {
+		target, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong fee-estimates target=%s", key)
+		}
+		res[target] = value
+	}
+	return res, nil
+}
+
+// EstimateFeeRatePerKb returns fee rate per kvB for confirmation target
+// Uses the closest target not larger than the given one, so the rate is never too low
+//
+// EstimateFeeRatePerKb 返回指定确认区块数对应的每 kvB 费率
+// 使用不大于给定值的最接近的确认区块数，以保证费率不会偏低
+func (ec *EsploraClient) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	estimates, err := ec.GetFeeEstimates(ctx)
+	if err != nil {
+		return 0, errors.WithMessage(err, "wrong get-fee-estimates")
+	}
+	if len(estimates) == 0 {
+		return 0, errors.New("wrong fee-estimates empty")
+	}
+	var targets = make([]int, 0, len(estimates))
+	for target := range estimates {
+		targets = append(targets, target)
+	}
+	sort.Ints(targets)
+
+	var choose = targets[0]
+	for _, target := range targets {
+		if target <= confTarget {
+			choose = target
+		}
+	}
+	// sat/vB -> sat/kvB, rounded up to avoid paying less than the estimate
+	// sat/vB -> sat/kvB，向上取整以避免费率低于预估值
+	satPerKvb := estimates[choose] * 1000
+	res := btcutil.Amount(int64(satPerKvb))
+	if float64(res) < satPerKvb {
+		res++
+	}
+	return res, nil
+}
+
+// Broadcast sends signed transaction to Esplora and returns txid
+//
+// Broadcast 把已签名的交易发送到 Esplora 并返回交易哈希
+func (ec *EsploraClient) Broadcast(ctx context.Context, msgTx *wire.MsgTx) (string, error) {
+	txHex, err := CvtMsgTxToHex(msgTx)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong cvt-msg-tx-to-hex")
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, ec.baseURL+"/tx", strings.NewReader(txHex))
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong new-request")
+	}
+	request.Header.Set("Content-Type", "text/plain")
+	data, err := ec.do(request)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong post-tx")
+	}
+	txid := strings.TrimSpace(string(data))
+	if txid != GetTxHash(msgTx) {
+		return "", errors.Errorf("wrong broadcast txid mismatch: got %s, expected %s", txid, GetTxHash(msgTx))
+	}
+	return txid, nil
+}
+
+type esploraOutspend struct {
+	Spent bool   `json:"spent"`
+	Txid  string `json:"txid"`
+}
+
+// GetTipHeight returns current chain tip height
+//
+// GetTipHeight 返回当前链顶高度
+func (ec *EsploraClient) GetTipHeight(ctx context.Context) (int64, error) {
+	var res int64
+	if err := ec.getJSON(ctx, "/blocks/tip/height", &res); err != nil {
+		return 0, errors.WithMessage(err, "wrong get-tip-height")
+	}
+	return res, nil
+}
+
+// GetTxWatchStatus implements TxWatchBackend
+// When tx is unknown, its inputs are checked to find the tx spending them instead
+//
+// GetTxWatchStatus 实现 TxWatchBackend
+// 当交易未知时，检查其输入以找到花费这些输入的其它交易
+func (ec *EsploraClient) GetTxWatchStatus(ctx context.Context, msgTx *wire.MsgTx) (*TxWatchStatus, error) {
+	txid := GetTxHash(msgTx)
+	status, err := ec.GetTxStatus(ctx, txid)
+	if err == nil {
+		if !status.Confirmed {
+			return &TxWatchStatus{InMempool: true}, nil
+		}
+		tipHeight, err := ec.GetTipHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &TxWatchStatus{BlockHeight: status.BlockHeight, TipHeight: tipHeight}, nil
+	}
+	if !isEsploraNotFound(err) {
+		return nil, err
+	}
+	for _, txIn := range msgTx.TxIn {
+		var outspend esploraOutspend
+		path := fmt.Sprintf("/tx/%s/outspend/%d", txIn.PreviousOutPoint.Hash.String(), txIn.PreviousOutPoint.Index)
+		if err := ec.getJSON(ctx, path, &outspend); err != nil {
+			return nil, errors.WithMessage(err, "wrong get-outspend")
+		}
+		if outspend.Spent && outspend.Txid != txid {
+			return &TxWatchStatus{ConflictTxid: outspend.Txid}, nil
+		}
+	}
+	return &TxWatchStatus{}, nil
+}
+
+func (ec *EsploraClient) getJSON(ctx context.Context, path string, res interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, ec.baseURL+path, nil)
+	if err != nil {
+		return errors.WithMessage(err, "wrong new-request")
+	}
+	data, err := ec.do(request)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return errors.WithMessage(err, "wrong unmarshal-response")
+	}
+	return nil
+}
+
+func (ec *EsploraClient) do(request *http.Request) ([]byte, error) {
+	response, err := ec.httpClient.Do(request)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong http-request")
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, esploraMaxResponseSize+1))
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong read-response")
+	}
+	if len(data) > esploraMaxResponseSize {
+		return nil, errors.Errorf("wrong response larger than %d bytes", esploraMaxResponseSize)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, &esploraStatusError{statusCode: response.StatusCode, message: strings.TrimSpace(string(data))}
+	}
+	return data, nil
+}
+
+type esploraStatusError struct {
+	statusCode int
+	message    string
+}
+
+func (e *esploraStatusError) Error() string {
+	return fmt.Sprintf("wrong http-status=%d message=%s", e.statusCode, e.message)
+}
+
+func isEsploraNotFound(err error) bool {
+	var statusErr *esploraStatusError
+	return errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound
+}
@@ -0,0 +1,199 @@
+package gobtcsign
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// Recorded responses of https://blockstream.info/testnet/api, trimmed to the fields in use
+var esploraRecordedResponses = map[string]string{
+	"/tx/e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919": `{
+		"txid": "e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919",
+		"version": 1,
+		"locktime": 0,
+		"vout": [
+			{"scriptpubkey": "0014b3c4715e00a5ff9707ec7be2586e62d286ae4a18", "scriptpubkey_type": "v0_p2wpkh", "scriptpubkey_address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "value": 3000},
+			{"scriptpubkey": "0014fcb55e6920e2c6f37327a5bf4a24cf42ebbaf07c", "scriptpubkey_type": "v0_p2wpkh", "scriptpubkey_address": "tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q", "value": 2000},
+			{"scriptpubkey": "001462152b40d8b2cbac358541d850c079ea10d1407f", "scriptpubkey_type": "v0_p2wpkh", "scriptpubkey_address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "value": 13089}
+		],
+		"status": {"confirmed": true, "block_height": 3503553, "block_hash": "0000000000000010e8f16fa0c4e2bbf1a2c59c8a0fe1b52e15fd9e59cdc7b1f6", "block_time": 1733036000}
+	}`,
+	"/tx/e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919/status": `{"confirmed": true, "block_height": 3503553, "block_hash": "0000000000000010e8f16fa0c4e2bbf1a2c59c8a0fe1b52e15fd9e59cdc7b1f6", "block_time": 1733036000}`,
+	"/address/tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap/utxo": `[
+		{"txid": "e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", "vout": 2, "status": {"confirmed": true, "block_height": 3503553, "block_hash": "0000000000000010e8f16fa0c4e2bbf1a2c59c8a0fe1b52e15fd9e59cdc7b1f6", "block_time": 1733036000}, "value": 13089},
+		{"txid": "5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", "vout": 0, "status": {"confirmed": false}, "value": 4560}
+	]`,
+	"/tx/e587e4f65a7fa5dbba6bede6b000e8ece097671bb348db3de0e507c8b36469ad/status":     `{"confirmed": true, "block_height": 3503560, "block_hash": "000000000000000ba4a5cbbdc1fda2a9e04ef8d8ec4dd8f6e1c3db53e72ea0e7", "block_time": 1733037000}`,
+	"/tx/e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919/outspend/2": `{"spent": true, "txid": "e587e4f65a7fa5dbba6bede6b000e8ece097671bb348db3de0e507c8b36469ad", "vin": 0, "status": {"confirmed": true, "block_height": 3503560}}`,
+	"/blocks/tip/height": `3503565`,
+	"/fee-estimates":     `{"1": 21.417, "2": 21.417, "3": 18.2, "6": 12.001, "144": 1.5, "1008": 1.0}`,
+}
+
+func newEsploraTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/tx" {
+			body, _ := io.ReadAll(r.Body)
+			msgTx, err := NewMsgTxFromHex(string(body))
+			if err != nil {
+				http.Error(w, "sendrawtransaction RPC error: TX decode failed", http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(GetTxHash(msgTx)))
+			return
+		}
+		response, ok := esploraRecordedResponses[r.URL.Path]
+		if !ok {
+			http.Error(w, "Transaction not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+}
+
+func TestEsploraClient_GetUtxoFrom(t *testing.T) {
+	var _ GetUtxoFromInterface = &EsploraClient{}
+	var _ GetUtxoFromInterfaceV2 = &EsploraClient{}
+	var _ ListAddressUtxosInterface = &EsploraClient{}
+
+	server := newEsploraTestServer()
+	defer server.Close()
+
+	client := NewEsploraClient(server.URL+"/", nil)
+
+	utxoFrom, err := client.GetUtxoFrom(*MustNewOutPoint("e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", 2))
+	require.NoError(t, err)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", utxoFrom.sender.Address)
+	require.NoError(t, utxoFrom.sender.VerifyMatch(&chaincfg.TestNet3Params))
+	require.Equal(t, int64(13089), utxoFrom.amount)
+
+	_, err = client.GetUtxoFrom(*MustNewOutPoint("e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", 3))
+	require.Error(t, err)
+	t.Log(err)
+
+	_, err = client.GetUtxoFrom(*MustNewOutPoint("5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", 0))
+	require.Error(t, err)
+	t.Log(err)
+}
+
+func TestEsploraClient_NewCustomParamFromMsgTx(t *testing.T) {
+	server := newEsploraTestServer()
+	defer server.Close()
+
+	const txHex = "010000000001011939727ec645869768167683487829f437cc37c664938345426d0df14e5df0e10200000000fdffffff02d204000000000000160014b3c4715e00a5ff9707ec7be2586e62d286ae4a18e80200000000000016001462152b40d8b2cbac358541d850c079ea10d1407f02483045022100e8269080acc14fd24ee13cbbdaa5ea34192f090c917b4ca3da44eda25badd58e02206813da9023bebd556a95e04e6a55c9a5fdf5dfb19746c896d7fd7f26aaa58878012102407ea64d7a9e992028a94481af95ea7d8f54870bd73e5878a014da594335ba3200000000"
+	msgTx, err := NewMsgTxFromHex(txHex)
+	require.NoError(t, err)
+
+	client := NewEsploraClient(server.URL, nil)
+
+	param, err := NewCustomParamFromMsgTxV2(context.Background(), msgTx, client)
+	require.NoError(t, err)
+	require.Equal(t, int64(11111), int64(param.GetFee()))
+	require.NoError(t, param.VerifyMsgTxSign(msgTx, &chaincfg.TestNet3Params))
+
+	txid, err := client.Broadcast(context.Background(), msgTx)
+	require.NoError(t, err)
+	require.Equal(t, GetTxHash(msgTx), txid)
+}
+
+func TestEsploraClient_ListAddressUtxos(t *testing.T) {
+	server := newEsploraTestServer()
+	defer server.Close()
+
+	client := NewEsploraClient(server.URL, nil)
+
+	utxos, err := client.ListAddressUtxos(context.Background(), "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap")
+	require.NoError(t, err)
+	require.Len(t, utxos, 2)
+	require.Equal(t, "e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", utxos[0].OutPoint.Hash.String())
+	require.Equal(t, uint32(2), utxos[0].OutPoint.Index)
+	require.Equal(t, int64(13089), utxos[0].Amount)
+	require.True(t, utxos[0].Confirmed)
+	require.Equal(t, int64(3503553), utxos[0].BlockHeight)
+	require.False(t, utxos[1].Confirmed)
+
+	vin := utxos[0].GetVinType(*NewRBFActive())
+	require.Equal(t, utxos[0].OutPoint, vin.OutPoint)
+	require.Equal(t, int64(13089), vin.Amount)
+}
+
+func TestEsploraClient_GetTxStatus(t *testing.T) {
+	server := newEsploraTestServer()
+	defer server.Close()
+
+	client := NewEsploraClient(server.URL, nil)
+
+	status, err := client.GetTxStatus(context.Background(), "e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919")
+	require.NoError(t, err)
+	require.True(t, status.Confirmed)
+	require.Equal(t, int64(3503553), status.BlockHeight)
+
+	_, err = client.GetTxStatus(context.Background(), "5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d")
+	require.Error(t, err)
+}
+
+func TestEsploraClient_EstimateFeeRatePerKb(t *testing.T) {
+	server := newEsploraTestServer()
+	defer server.Close()
+
+	client := NewEsploraClient(server.URL, nil)
+
+	estimates, err := client.GetFeeEstimates(context.Background())
+	require.NoError(t, err)
+	require.Len(t, estimates, 6)
+
+	feeRate, err := client.EstimateFeeRatePerKb(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(21417), feeRate)
+
+	// No estimate for 4 blocks, so the 3 blocks one is used
+	feeRate, err = client.EstimateFeeRatePerKb(context.Background(), 4)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(18200), feeRate)
+
+	feeRate, err = client.EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(12001), feeRate)
+}
+
+func TestEsploraClient_GetTxWatchStatus(t *testing.T) {
+	var _ TxWatchBackend = &EsploraClient{}
+
+	server := newEsploraTestServer()
+	defer server.Close()
+
+	client := NewEsploraClient(server.URL, nil)
+
+	msgTx := caseBroadcastMsgTx(t)
+	status, err := client.GetTxWatchStatus(context.Background(), msgTx)
+	require.NoError(t, err)
+	require.Equal(t, int64(3503560), status.BlockHeight)
+	require.Equal(t, int64(3503565), status.TipHeight)
+
+	// Unknown tx spending the same input is conflicted by the confirmed one
+	replacedTx := msgTx.Copy()
+	replacedTx.TxOut[0].Value--
+	status, err = client.GetTxWatchStatus(context.Background(), replacedTx)
+	require.NoError(t, err)
+	require.Equal(t, GetTxHash(msgTx), status.ConflictTxid)
+	require.False(t, status.InMempool)
+}
+
+func TestEsploraClient_ResponseTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("1"), esploraMaxResponseSize+1))
+	}))
+	defer server.Close()
+
+	client := NewEsploraClient(server.URL, nil)
+	require.Equal(t, esploraDefaultTimeout, client.httpClient.Timeout)
+	_, err := client.GetTipHeight(context.Background())
+	require.ErrorContains(t, err, "larger than")
+}
@@ -0,0 +1,298 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/internal/feerates"
+)
+
+// FeeEstimator defines interface to get fee rate per kB for confirmation target in blocks
+// The result is used as feeRatePerKb of EstimateTxFee
+// Implemented by BitcoindFeeEstimator, RecommendedFeesEstimator, StaticFeeEstimator, EsploraClient and combinators
+//
+// FeeEstimator 定义获取指定确认区块数对应的每 kB 费率的接口
+// 结果用作 EstimateTxFee 的 feeRatePerKb 参数
+// 由 BitcoindFeeEstimator、RecommendedFeesEstimator、StaticFeeEstimator、EsploraClient 和组合器实现
+type FeeEstimator interface {
+	EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error)
+}
+
+// FeeRateBounds type alias from internal feerates package
+// FeeRateBounds 来自 internal feerates 包的类型别名
+type FeeRateBounds = feerates.FeeRateBounds
+
+// NewFeeRateBounds creates FeeRateBounds for Bitcoin
+// Floor is the default min relay fee 1 sat/vB, ceiling is 500 sat/vB
+//
+// NewFeeRateBounds 创建比特币的 FeeRateBounds
+// 下限是默认的最低转发费率 1 sat/vB，上限是 500 sat/vB
+func NewFeeRateBounds() FeeRateBounds {
+	return feerates.NewFeeRateBounds(1000, 500000)
+}
+
+// BitcoindFeeEstimator implements FeeEstimator using estimatesmartfee RPC
+// Also works with Dogecoin Core, where the mode param is not supported and must stay nil
+//
+// BitcoindFeeEstimator 使用 estimatesmartfee RPC 实现 FeeEstimator
+// 同样适用于狗狗币 Core，但其不支持 mode 参数，因此必须保持为 nil
+type BitcoindFeeEstimator struct {
+	client *rpcclient.Client             // Bitcoin RPC client // 比特币 RPC 客户端
+	mode   *btcjson.EstimateSmartFeeMode // Estimate mode, nil means node default // 预估模式，为 nil 时使用节点默认值
+}
+
+// NewBitcoindFeeEstimator creates BitcoindFeeEstimator with RPC client and optional mode
+//
+// NewBitcoindFeeEstimator 使用 RPC 客户端和可选的预估模式创建 BitcoindFeeEstimator
+func NewBitcoindFeeEstimator(client *rpcclient.Client, mode *btcjson.EstimateSmartFeeMode) *BitcoindFeeEstimator {
+	return &BitcoindFeeEstimator{client: client, mode: mode}
+}
+
+// EstimateFeeRatePerKb returns node estimate, fails when node has not enough data
+//
+// EstimateFeeRatePerKb 返回节点的预估值，当节点数据不足时失败
+func (b *BitcoindFeeEstimator) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	res, err := receiveWithContext(ctx, b.client.EstimateSmartFeeAsync(int64(confTarget), b.mode).Receive)
+	if err != nil {
+		return 0, errors.WithMessage(err, "wrong estimate-smart-fee")
+	}
+	if res.FeeRate == nil || *res.FeeRate <= 0 {
+		return 0, errors.Errorf("wrong estimate-smart-fee no fee rate. errors=%s", strings.Join(res.Errors, "; "))
+	}
+	feeRatePerKb, err := btcutil.NewAmount(*res.FeeRate)
+	if err != nil {
+		return 0, errors.WithMessage(err, "wrong fee-rate")
+	}
+	return feeRatePerKb, nil
+}
+
+// RecommendedFees represents response of mempool.space style /api/v1/fees/recommended, in sat/vB
+//
+// RecommendedFees 代表 mempool.space 风格的 /api/v1/fees/recommended 接口的响应，单位是 sat/vB
+type RecommendedFees struct {
+	FastestFee  float64 `json:"fastestFee"`  // Next block // 下一个区块
+	HalfHourFee float64 `json:"halfHourFee"` // About 3 blocks // 约 3 个区块
+	HourFee     float64 `json:"hourFee"`     // About 6 blocks // 约 6 个区块
+	EconomyFee  float64 `json:"economyFee"`  // No hurry // 不着急
+	MinimumFee  float64 `json:"minimumFee"`  // Mempool min fee // 内存池的最低费率
+}
+
+// RecommendedFeesEstimator implements FeeEstimator using mempool.space style recommended fees endpoint
+//
+// RecommendedFeesEstimator 使用 mempool.space 风格的推荐费率接口实现 FeeEstimator
+type RecommendedFeesEstimator struct {
+	endpoint   string       // Full URL such as https://mempool.space/api/v1/fees/recommended // 完整的接口地址
+	httpClient *http.Client // HTTP client // HTTP 客户端
+}
+
+// NewRecommendedFeesEstimator creates RecommendedFeesEstimator with endpoint URL and HTTP client
+// Uses http.DefaultClient when httpClient is nil
+//
+// NewRecommendedFeesEstimator 使用接口地址和 HTTP 客户端创建 RecommendedFeesEstimator
+// 当 httpClient 为 nil 时使用 http.DefaultClient
+func NewRecommendedFeesEstimator(endpoint string, httpClient *http.Client) *RecommendedFeesEstimator {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &RecommendedFeesEstimator{endpoint: endpoint, httpClient: httpClient}
+}
+
+// GetRecommendedFees returns recommended fees from endpoint
+//
+// GetRecommendedFees 从接口获取推荐费率
+func (r *RecommendedFeesEstimator) GetRecommendedFees(ctx context.Context) (*RecommendedFees, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.endpoint, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong new-request")
+	}
+	response, err := r.httpClient.Do(request)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong http-request")
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("wrong http-status=%d", response.StatusCode)
+	}
+	var res RecommendedFees
+	if err := json.NewDecoder(response.Body).Decode(&res); err != nil {
+		return nil, errors.WithMessage(err, "wrong unmarshal-response")
+	}
+	return &res, nil
+}
+
+// EstimateFeeRatePerKb maps confirmation target to fastest (1), half hour (<=3), hour (<=6) or economy fee
+//
+// EstimateFeeRatePerKb 把确认区块数映射为 fastest（1）、half hour（<=3）、hour（<=6）或 economy 费率
+func (r *RecommendedFeesEstimator) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	fees, err := r.GetRecommendedFees(ctx)
+	if err != nil {
+		return 0, errors.WithMessage(err, "wrong get-recommended-fees")
+	}
+	var satPerVb float64
+	switch {
+	case confTarget <= 1:
+		satPerVb = fees.FastestFee
+	case confTarget <= 3:
+		satPerVb = fees.HalfHourFee
+	case confTarget <= 6:
+		satPerVb = fees.HourFee
+	default:
+		satPerVb = fees.EconomyFee
+	}
+	if satPerVb <= 0 {
+		return 0, errors.Errorf("wrong recommended fee %v for conf-target=%d", satPerVb, confTarget)
+	}
+	// sat/vB -> sat/kvB, rounded up to avoid paying less than the estimate
+	// sat/vB -> sat/kvB，向上取整以避免费率低于预估值
+	satPerKvb := satPerVb * 1000
+	res := btcutil.Amount(int64(satPerKvb))
+	if float64(res) < satPerKvb {
+		res++
+	}
+	return res, nil
+}
+
+// StaticFeeEstimator implements FeeEstimator with fixed fee rate, usually the last resort of a fallback
+//
+// StaticFeeEstimator 使用固定费率实现 FeeEstimator，通常作为回退链的最后一环
+type StaticFeeEstimator struct {
+	feeRatePerKb btcutil.Amount // Fixed fee rate per kB // 固定的每 kB 费率
+}
+
+// NewStaticFeeEstimator creates StaticFeeEstimator with fixed fee rate per kB
+//
+// NewStaticFeeEstimator 使用固定的每 kB 费率创建 StaticFeeEstimator
+func NewStaticFeeEstimator(feeRatePerKb btcutil.Amount) *StaticFeeEstimator {
+	return &StaticFeeEstimator{feeRatePerKb: feeRatePerKb}
+}
+
+// EstimateFeeRatePerKb returns the fixed fee rate whatever the target
+//
+// EstimateFeeRatePerKb 无论确认区块数是多少都返回固定费率
+func (s *StaticFeeEstimator) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	return s.feeRatePerKb, nil
+}
+
+// FallbackFeeEstimator implements FeeEstimator by asking sources in order until one succeeds
+//
+// FallbackFeeEstimator 依次询问各个数据源直到有一个成功，以此实现 FeeEstimator
+type FallbackFeeEstimator struct {
+	sources []FeeEstimator // Sources in priority order // 按优先级排序的数据源
+}
+
+// NewFallbackFeeEstimator creates FallbackFeeEstimator with sources in priority order
+//
+// NewFallbackFeeEstimator 使用按优先级排序的数据源创建 FallbackFeeEstimator
+func NewFallbackFeeEstimator(sources ...FeeEstimator) *FallbackFeeEstimator {
+	return &FallbackFeeEstimator{sources: sources}
+}
+
+// EstimateFeeRatePerKb returns the first successful estimate, or the last error
+//
+// EstimateFeeRatePerKb 返回第一个成功的预估值，否则返回最后一个错误
+func (f *FallbackFeeEstimator) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	var lastErr = errors.New("wrong fee-estimator sources empty")
+	for idx, source := range f.sources {
+		res, err := source.EstimateFeeRatePerKb(ctx, confTarget)
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		lastErr = errors.WithMessagef(err, "wrong fee-estimator source. index=%d", idx)
+	}
+	return 0, lastErr
+}
+
+// FeeCombineMode represents how CombinedFeeEstimator merges estimates
+//
+// FeeCombineMode 代表 CombinedFeeEstimator 合并预估值的方式
+type FeeCombineMode string
+
+const (
+	FeeCombineMedian FeeCombineMode = "median" // Median, robust against one broken source // 中位数，能抵御单个数据源出错
+	FeeCombineMax    FeeCombineMode = "max"    // Max, favors fast confirmation // 最大值，倾向于快速确认
+)
+
+// CombinedFeeEstimator implements FeeEstimator by merging estimates of all sources
+// Failed sources are skipped, the merged rate is clamped into the chain bounds
+//
+// CombinedFeeEstimator 合并所有数据源的预估值来实现 FeeEstimator
+// 失败的数据源会被跳过，合并后的费率会被限制在链的上下限之内
+type CombinedFeeEstimator struct {
+	mode    FeeCombineMode // Merge mode // 合并方式
+	bounds  FeeRateBounds  // Chain floor and ceiling // 链的下限和上限
+	sources []FeeEstimator // Sources // 数据源
+}
+
+// NewMedianFeeEstimator creates CombinedFeeEstimator taking median of sources
+// Use NewFeeRateBounds for Bitcoin and dogecoin.NewDogeFeeRateBounds for Dogecoin
+//
+// NewMedianFeeEstimator 创建取各数据源中位数的 CombinedFeeEstimator
+// 比特币使用 NewFeeRateBounds，狗狗币使用 dogecoin.NewDogeFeeRateBounds
+func NewMedianFeeEstimator(bounds FeeRateBounds, sources ...FeeEstimator) *CombinedFeeEstimator {
+	return &CombinedFeeEstimator{mode: FeeCombineMedian, bounds: bounds, sources: sources}
+}
+
+// NewMaxFeeEstimator creates CombinedFeeEstimator taking max of sources
+//
+// NewMaxFeeEstimator 创建取各数据源最大值的 CombinedFeeEstimator
+func NewMaxFeeEstimator(bounds FeeRateBounds, sources ...FeeEstimator) *CombinedFeeEstimator {
+	return &CombinedFeeEstimator{mode: FeeCombineMax, bounds: bounds, sources: sources}
+}
+
+// EstimateFeeRatePerKb asks all sources in parallel and merges successful estimates
+// Fails only when every source fails
+//
+// EstimateFeeRatePerKb 并行询问所有数据源并合并成功的预估值
+// 只有当所有数据源都失败时才返回错误
+func (c *CombinedFeeEstimator) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	var rates = make([]btcutil.Amount, len(c.sources))
+	var errs = make([]error, len(c.sources))
+	// Each call keeps its own error, so that one failed source does not stop the others
+	// 每个调用各自保存错误，这样单个数据源失败不会中止其它数据源
+	if err := runWithLimit(ctx, len(c.sources), len(c.sources), func(ctx context.Context, idx int) error {
+		rates[idx], errs[idx] = c.sources[idx].EstimateFeeRatePerKb(ctx, confTarget)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	var results = make([]btcutil.Amount, 0, len(c.sources))
+	var lastErr = errors.New("wrong fee-estimator sources empty")
+	for idx, err := range errs {
+		if err != nil {
+			lastErr = errors.WithMessagef(err, "wrong fee-estimator source. index=%d", idx)
+			continue
+		}
+		results = append(results, rates[idx])
+	}
+	if len(results) == 0 {
+		return 0, lastErr
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i] < results[j] })
+
+	var res btcutil.Amount
+	switch c.mode {
+	case FeeCombineMax:
+		res = results[len(results)-1]
+	default:
+		mid := len(results) / 2
+		if len(results)%2 == 1 {
+			res = results[mid]
+		} else {
+			// Average of the two middle values, rounded up
+			// 两个中间值的平均值，向上取整
+			res = (results[mid-1] + results[mid] + 1) / 2
+		}
+	}
+	return c.bounds.Clamp(res), nil
+}
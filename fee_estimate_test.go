@@ -0,0 +1,127 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign/dogecoin"
+)
+
+// newFakeEstimateSmartFee serves estimatesmartfee with fixed BTC/kvB rate, nil rate means not enough data
+func newFakeEstimateSmartFee(feeRate *float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request fakeRpcRequest
+		_ = json.Unmarshal(body, &request)
+		res := &btcjson.EstimateSmartFeeResult{FeeRate: feeRate, Blocks: 2}
+		if feeRate == nil {
+			res.Errors = []string{"Insufficient data or no feerate found"}
+		}
+		_ = json.NewEncoder(w).Encode(&fakeRpcResponse{ID: request.ID, Result: res})
+	}))
+}
+
+func TestBitcoindFeeEstimator_EstimateFeeRatePerKb(t *testing.T) {
+	feeRate := 0.00012345
+	server := newFakeEstimateSmartFee(&feeRate)
+	defer server.Close()
+
+	client, err := rpcclient.New(newFakeBitcoindConfig(server), nil)
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	res, err := NewBitcoindFeeEstimator(client, nil).EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(12345), res)
+}
+
+func TestBitcoindFeeEstimator_NoData(t *testing.T) {
+	server := newFakeEstimateSmartFee(nil)
+	defer server.Close()
+
+	client, err := rpcclient.New(newFakeBitcoindConfig(server), nil)
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	mode := btcjson.EstimateModeEconomical
+	_, err = NewBitcoindFeeEstimator(client, &mode).EstimateFeeRatePerKb(context.Background(), 6)
+	require.ErrorContains(t, err, "Insufficient data")
+}
+
+func TestRecommendedFeesEstimator_EstimateFeeRatePerKb(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"fastestFee":12,"halfHourFee":8,"hourFee":5,"economyFee":2.5,"minimumFee":1}`))
+	}))
+	defer server.Close()
+
+	estimator := NewRecommendedFeesEstimator(server.URL+"/api/v1/fees/recommended", nil)
+	for confTarget, expected := range map[int]btcutil.Amount{1: 12000, 3: 8000, 6: 5000, 144: 2500} {
+		res, err := estimator.EstimateFeeRatePerKb(context.Background(), confTarget)
+		require.NoError(t, err)
+		require.Equal(t, expected, res)
+	}
+}
+
+// failingFeeEstimator always fails, stands in for an unreachable source
+type failingFeeEstimator struct{}
+
+func (failingFeeEstimator) EstimateFeeRatePerKb(ctx context.Context, confTarget int) (btcutil.Amount, error) {
+	return 0, errors.New("wrong source unreachable")
+}
+
+func TestFallbackFeeEstimator_EstimateFeeRatePerKb(t *testing.T) {
+	estimator := NewFallbackFeeEstimator(failingFeeEstimator{}, NewStaticFeeEstimator(3000), NewStaticFeeEstimator(5000))
+	res, err := estimator.EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(3000), res)
+
+	_, err = NewFallbackFeeEstimator(failingFeeEstimator{}).EstimateFeeRatePerKb(context.Background(), 6)
+	require.ErrorContains(t, err, "unreachable")
+}
+
+func TestCombinedFeeEstimator_EstimateFeeRatePerKb(t *testing.T) {
+	sources := []FeeEstimator{
+		NewStaticFeeEstimator(2000),
+		failingFeeEstimator{},
+		NewStaticFeeEstimator(9000),
+		NewStaticFeeEstimator(3001),
+		NewStaticFeeEstimator(4000),
+	}
+
+	res, err := NewMedianFeeEstimator(NewFeeRateBounds(), sources...).EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(3501), res) // (3001 + 4000) / 2 rounded up
+
+	res, err = NewMaxFeeEstimator(NewFeeRateBounds(), sources...).EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(9000), res)
+
+	// Bitcoin floor and ceiling
+	res, err = NewMedianFeeEstimator(NewFeeRateBounds(), NewStaticFeeEstimator(10)).EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(1000), res)
+
+	res, err = NewMaxFeeEstimator(NewFeeRateBounds(), NewStaticFeeEstimator(9000000)).EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(500000), res)
+
+	_, err = NewMedianFeeEstimator(NewFeeRateBounds(), failingFeeEstimator{}).EstimateFeeRatePerKb(context.Background(), 6)
+	require.Error(t, err)
+}
+
+func TestCombinedFeeEstimator_Dogecoin(t *testing.T) {
+	// Bitcoin style estimates are far below Dogecoin min relay fee
+	estimator := NewMedianFeeEstimator(dogecoin.NewDogeFeeRateBounds(), NewStaticFeeEstimator(1000), NewStaticFeeEstimator(2000))
+	res, err := estimator.EstimateFeeRatePerKb(context.Background(), 6)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(dogecoin.MinRelayFeePerKb), res)
+}
@@ -0,0 +1,57 @@
+package addresses
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+)
+
+// DecodeAddress decodes address on network without reading the global chaincfg registry
+// btcutil.DecodeAddress only accepts segwit HRPs registered with chaincfg.Register, so segwit addresses are decoded here
+// with the HRP of netParams, while base58 addresses are matched against netParams directly by btcutil
+//
+// DecodeAddress 在不读取全局 chaincfg 注册表的情况下解析网络上的地址
+// btcutil.DecodeAddress 只接受通过 chaincfg.Register 注册的 segwit 前缀，因此这里使用 netParams 的前缀解析 segwit 地址，
+// 而 base58 地址由 btcutil 直接和 netParams 匹配
+func DecodeAddress(address string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	if hrp := netParams.Bech32HRPSegwit; hrp != "" && strings.HasPrefix(strings.ToLower(address), hrp+"1") {
+		return decodeSegwitAddress(address, netParams)
+	}
+	// Other addresses keep the behavior of btcutil, which does not check segwit HRP against netParams
+	// 其它地址保持 btcutil 的行为，btcutil 不会检查 segwit 前缀是否和 netParams 一致
+	return btcutil.DecodeAddress(address, netParams)
+}
+
+// decodeSegwitAddress decodes bech32 (witness v0) or bech32m (witness v1) address with HRP of netParams
+// decodeSegwitAddress 使用 netParams 的前缀解析 bech32（见证 v0）或 bech32m（见证 v1）地址
+func decodeSegwitAddress(address string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	hrp, data, version, err := bech32.DecodeGeneric(address)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong bech32 decode")
+	}
+	if hrp != netParams.Bech32HRPSegwit {
+		return nil, errors.Errorf("address=%s hrp=%s is not for network=%s", address, hrp, netParams.Name)
+	}
+	if len(data) < 1 {
+		return nil, errors.Errorf("address=%s has no witness version", address)
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong bech32 convert-bits")
+	}
+	switch witnessVersion := data[0]; {
+	case witnessVersion == 0 && version == bech32.Version0:
+		switch len(program) {
+		case 20:
+			return btcutil.NewAddressWitnessPubKeyHash(program, netParams)
+		case 32:
+			return btcutil.NewAddressWitnessScriptHash(program, netParams)
+		}
+	case witnessVersion == 1 && version == bech32.VersionM && len(program) == 32:
+		return btcutil.NewAddressTaproot(program, netParams)
+	}
+	return nil, errors.Errorf("address=%s has unsupported witness version=%d program length=%d", address, data[0], len(program))
+}
@@ -0,0 +1,67 @@
+package addresses
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// customNetParams has HRP never registered with chaincfg, so btcutil.DecodeAddress cannot decode its segwit addresses
+// customNetParams 的前缀从未注册到 chaincfg，因此 btcutil.DecodeAddress 无法解析它的 segwit 地址
+var customNetParams = chaincfg.Params{
+	Name:             "custom",
+	Net:              0x12345678,
+	PubKeyHashAddrID: 111,
+	ScriptHashAddrID: 196,
+	Bech32HRPSegwit:  "tltc",
+}
+
+// TestDecodeAddress validates segwit and base58 addresses decode without chaincfg registration
+//
+// TestDecodeAddress 验证 segwit 和 base58 地址不需要 chaincfg 注册即可解析
+func TestDecodeAddress(t *testing.T) {
+	for _, address := range []string{
+		"tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladg",
+		"mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb",
+	} {
+		res, err := DecodeAddress(address, &customNetParams)
+		require.NoError(t, err)
+		require.Equal(t, address, res.EncodeAddress())
+		require.True(t, res.IsForNet(&customNetParams))
+	}
+}
+
+// TestDecodeAddress_Bitcoin validates P2WPKH, P2WSH and P2TR addresses on Bitcoin testnet
+//
+// TestDecodeAddress_Bitcoin 验证比特币测试网上的 P2WPKH、P2WSH 和 P2TR 地址
+func TestDecodeAddress_Bitcoin(t *testing.T) {
+	for _, address := range []string{
+		"tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap",
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+		"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c",
+	} {
+		res, err := DecodeAddress(address, &chaincfg.TestNet3Params)
+		require.NoError(t, err, address)
+		require.Equal(t, address, res.EncodeAddress())
+	}
+}
+
+// TestDecodeAddress_Wrong validates addresses of other networks and bad checksums are rejected
+//
+// TestDecodeAddress_Wrong 验证其它网络的地址和错误的校验和会被拒绝
+func TestDecodeAddress_Wrong(t *testing.T) {
+	for _, address := range []string{
+		"tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladh", // bad checksum
+		"LUAZqrgYEJ6hR8NaKyPJZwKdgyvWpxec9K",           // other version byte
+	} {
+		_, err := DecodeAddress(address, &customNetParams)
+		require.Error(t, err, address)
+	}
+
+	// Registered HRP of other network keeps btcutil behavior, the address decodes but is not for the network
+	// 其它网络已注册的前缀保持 btcutil 的行为，地址能解析但不属于该网络
+	res, err := DecodeAddress("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", &customNetParams)
+	require.NoError(t, err)
+	require.False(t, res.IsForNet(&customNetParams))
+}
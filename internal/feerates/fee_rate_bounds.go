@@ -0,0 +1,38 @@
+package feerates
+
+import (
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// FeeRateBounds represents floor and ceiling of fee rate per kB on a chain
+// Floor is usually the node min relay fee, ceiling guards against broken estimates
+//
+// FeeRateBounds 代表某条链上每 kB 费率的下限和上限
+// 下限通常是节点的最低转发费率，上限用于防止错误的预估值
+type FeeRateBounds struct {
+	Floor   btcutil.Amount // Min fee rate per kB // 每 kB 的最低费率
+	Ceiling btcutil.Amount // Max fee rate per kB, 0 means no ceiling // 每 kB 的最高费率，为 0 表示没有上限
+}
+
+// NewFeeRateBounds creates FeeRateBounds with floor and ceiling
+//
+// NewFeeRateBounds 使用下限和上限创建 FeeRateBounds
+func NewFeeRateBounds(floor btcutil.Amount, ceiling btcutil.Amount) FeeRateBounds {
+	return FeeRateBounds{
+		Floor:   floor,
+		Ceiling: ceiling,
+	}
+}
+
+// Clamp limits fee rate into [Floor, Ceiling]
+//
+// Clamp 把费率限制在 [Floor, Ceiling] 范围内
+func (B *FeeRateBounds) Clamp(feeRatePerKb btcutil.Amount) btcutil.Amount {
+	if B.Ceiling > 0 && feeRatePerKb > B.Ceiling {
+		feeRatePerKb = B.Ceiling
+	}
+	if feeRatePerKb < B.Floor {
+		feeRatePerKb = B.Floor
+	}
+	return feeRatePerKb
+}
@@ -0,0 +1,28 @@
+package feerates
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeeRateBounds_Clamp validates fee rates are limited into floor and ceiling
+//
+// TestFeeRateBounds_Clamp 验证费率被限制在下限和上限之间
+func TestFeeRateBounds_Clamp(t *testing.T) {
+	bounds := NewFeeRateBounds(1000, 500000)
+	require.Equal(t, btcutil.Amount(1000), bounds.Clamp(0))
+	require.Equal(t, btcutil.Amount(1000), bounds.Clamp(999))
+	require.Equal(t, btcutil.Amount(12345), bounds.Clamp(12345))
+	require.Equal(t, btcutil.Amount(500000), bounds.Clamp(500001))
+}
+
+// TestFeeRateBounds_Clamp_NoCeiling validates zero ceiling means no ceiling
+//
+// TestFeeRateBounds_Clamp_NoCeiling 验证上限为零时表示没有上限
+func TestFeeRateBounds_Clamp_NoCeiling(t *testing.T) {
+	bounds := NewFeeRateBounds(100000, 0)
+	require.Equal(t, btcutil.Amount(100000), bounds.Clamp(1))
+	require.Equal(t, btcutil.Amount(1e9), bounds.Clamp(1e9))
+}
@@ -0,0 +1,129 @@
+// Package litecoin provides Litecoin network configuration parameters
+// Registration into global chaincfg is opt-in through Register
+//
+// litecoin 包提供莱特币网络配置参数
+// 注册到全局 chaincfg 需要主动调用 Register
+package litecoin
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/pkg/errors"
+)
+
+// MessageMagic is the prefix used by Litecoin Core signmessage/verifymessage
+// Pass it to gobtcsign.SignMessageWithMagic and gobtcsign.VerifyMessageWithMagic
+//
+// MessageMagic 是 Litecoin Core signmessage/verifymessage 使用的前缀
+// 把它传给 gobtcsign.SignMessageWithMagic 和 gobtcsign.VerifyMessageWithMagic 即可
+const MessageMagic = "Litecoin Signed Message:\n"
+
+var registerOnce struct {
+	once sync.Once
+	err  error
+}
+
+// Register registers Litecoin MainNet, TestNet and RegressionNet into the global chaincfg registry, it is opt-in
+// gobtcsign decodes Litecoin addresses without it, call it only when other code needs btcutil.DecodeAddress or hdkeychain
+// Repeated calls return the result of the first call
+//
+// Register 把莱特币主网、测试网和回归测试网注册到全局 chaincfg 注册表，需要主动调用
+// gobtcsign 不依赖它就能解析莱特币地址，只有其它代码需要 btcutil.DecodeAddress 或 hdkeychain 时才调用
+// 重复调用返回第一次调用的结果
+func Register() error {
+	registerOnce.once.Do(func() {
+		for _, netParams := range []*chaincfg.Params{&MainNetParams, &TestNetParams, &RegressionNetParams} {
+			if err := chaincfg.Register(netParams); err != nil {
+				registerOnce.err = errors.WithMessagef(err, "wrong register network=%s", netParams.Name)
+				return
+			}
+		}
+	})
+	return registerOnce.err
+}
+
+// MainNetParams represents chain configuration for Litecoin mainnet
+// Script hash addresses start with M, legacy 3 addresses are handled by ConvertLegacyScriptHashAddress
+//
+// MainNetParams 代表莱特币主网的链配置
+// 脚本哈希地址以 M 开头，旧的 3 开头的地址由 ConvertLegacyScriptHashAddress 处理
+var MainNetParams = chaincfg.Params{
+	Name: "mainnet",
+	Net:  0xdbb6c0fb,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 48,  // starts with L
+	ScriptHashAddrID: 50,  // starts with M
+	PrivateKeyID:     176, // starts with 6 (uncompressed) or T (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics, Litecoin Core uses the Bitcoin ones
+	HDPrivateKeyID: [4]byte{0x04, 0x88, 0xad, 0xe4}, // starts with xprv
+	HDPublicKeyID:  [4]byte{0x04, 0x88, 0xb2, 0x1e}, // starts with xpub
+
+	// Human-readable part for Bech32 encoded segwit addresses, as defined in
+	// BIP 173.
+	Bech32HRPSegwit: "ltc",
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 2,
+}
+
+// TestNetParams represents chain configuration for Litecoin testnet4
+// Script hash addresses start with Q, legacy 2 addresses are handled by ConvertLegacyScriptHashAddress
+//
+// TestNetParams 代表莱特币测试网 testnet4 的链配置
+// 脚本哈希地址以 Q 开头，旧的 2 开头的地址由 ConvertLegacyScriptHashAddress 处理
+var TestNetParams = chaincfg.Params{
+	Name: "testnet4",
+	Net:  0xf1c8d2fd,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 111, // starts with m or n
+	ScriptHashAddrID: 58,  // starts with Q
+	PrivateKeyID:     239, // starts with 9 (uncompressed) or c (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// Human-readable part for Bech32 encoded segwit addresses, as defined in
+	// BIP 173.
+	Bech32HRPSegwit: "tltc",
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
+
+// RegressionNetParams represents chain configuration for Litecoin regression testing network
+// Uses custom network magic to avoid collision with Bitcoin RegTest
+//
+// RegressionNetParams 代表莱特币回归测试网络的链配置
+// 使用自定义网络标识以避免与比特币回归测试网冲突
+var RegressionNetParams = chaincfg.Params{
+	Name: "regtest",
+
+	// Litecoin has 0xdab5bffa as RegTest (same as Bitcoin's RegTest).
+	// Setting it to an arbitrary value (leet_hex(litecoin)), so that we can
+	// register the regtest network.
+	Net: 0x1173c014,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 111,
+	ScriptHashAddrID: 58,
+	PrivateKeyID:     239,
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// Human-readable part for Bech32 encoded segwit addresses, as defined in
+	// BIP 173.
+	Bech32HRPSegwit: "rltc",
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
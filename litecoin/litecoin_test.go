@@ -0,0 +1,86 @@
+package litecoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMainNetParams validates Litecoin MainNet network configuration parameters
+// Verifies network IDs, address encoding values, and bech32 HRP
+//
+// TestMainNetParams 验证莱特币主网网络配置参数
+// 验证网络 ID、地址编码值和 bech32 前缀
+func TestMainNetParams(t *testing.T) {
+	require.Equal(t, "mainnet", MainNetParams.Name)
+	require.EqualValues(t, 0xdbb6c0fb, MainNetParams.Net)
+	require.Equal(t, uint8(48), MainNetParams.PubKeyHashAddrID)
+	require.Equal(t, uint8(50), MainNetParams.ScriptHashAddrID)
+	require.Equal(t, uint8(176), MainNetParams.PrivateKeyID)
+	require.Equal(t, "ltc", MainNetParams.Bech32HRPSegwit)
+
+	require.Equal(t, [4]byte{0x04, 0x88, 0xad, 0xe4}, MainNetParams.HDPrivateKeyID)
+	require.Equal(t, [4]byte{0x04, 0x88, 0xb2, 0x1e}, MainNetParams.HDPublicKeyID)
+}
+
+// TestTestNetParams validates Litecoin TestNet network configuration parameters
+//
+// TestTestNetParams 验证莱特币测试网网络配置参数
+func TestTestNetParams(t *testing.T) {
+	require.Equal(t, "testnet4", TestNetParams.Name)
+	require.EqualValues(t, 0xf1c8d2fd, TestNetParams.Net)
+	require.Equal(t, uint8(111), TestNetParams.PubKeyHashAddrID)
+	require.Equal(t, uint8(58), TestNetParams.ScriptHashAddrID)
+	require.Equal(t, uint8(239), TestNetParams.PrivateKeyID)
+	require.Equal(t, "tltc", TestNetParams.Bech32HRPSegwit)
+}
+
+// TestRegressionNetParams validates Litecoin RegressionNet configuration parameters
+//
+// TestRegressionNetParams 验证莱特币回归测试网配置参数
+func TestRegressionNetParams(t *testing.T) {
+	require.Equal(t, "regtest", RegressionNetParams.Name)
+	require.EqualValues(t, 0x1173c014, RegressionNetParams.Net)
+	require.Equal(t, uint8(111), RegressionNetParams.PubKeyHashAddrID)
+	require.Equal(t, uint8(58), RegressionNetParams.ScriptHashAddrID)
+	require.Equal(t, "rltc", RegressionNetParams.Bech32HRPSegwit)
+}
+
+// TestDecodeAddress_Networks validates addresses of same key decode on their own network without chaincfg registration
+//
+// TestDecodeAddress_Networks 验证同一个密钥的地址不需要 chaincfg 注册即可在各自的网络上解析
+func TestDecodeAddress_Networks(t *testing.T) {
+	testCases := []struct {
+		address   string
+		netParams *chaincfg.Params
+	}{
+		{"LUAZqrgYEJ6hR8NaKyPJZwKdgyvWpxec9K", &MainNetParams},
+		{"MGqmp5H7ef2T3zekNp3wYBsCwzSQGX7peL", &MainNetParams},
+		{"ltc1qvg2jksxckt96cdv9g8v9psreaggdzsrl4qu57z", &MainNetParams},
+		{"mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb", &TestNetParams},
+		{"tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladg", &TestNetParams},
+		{"rltc1qvg2jksxckt96cdv9g8v9psreaggdzsrl8778ak", &RegressionNetParams},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			address, err := DecodeAddress(tc.address, tc.netParams)
+			require.NoError(t, err)
+			require.True(t, address.IsForNet(tc.netParams))
+			require.Equal(t, tc.address, address.EncodeAddress())
+		})
+	}
+}
+
+// TestRegister validates opt-in registration lets btcutil.DecodeAddress decode Litecoin segwit addresses
+//
+// TestRegister 验证主动注册后 btcutil.DecodeAddress 能够解析莱特币 segwit 地址
+func TestRegister(t *testing.T) {
+	require.NoError(t, Register())
+	require.NoError(t, Register()) // repeated call is fine // 重复调用没有问题
+
+	address, err := btcutil.DecodeAddress("tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladg", &TestNetParams)
+	require.NoError(t, err)
+	require.True(t, address.IsForNet(&TestNetParams))
+}
@@ -0,0 +1,95 @@
+package litecoin
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/internal/addresses"
+)
+
+// ErrMwebAddress means destination is a MWEB (MimbleWimble Extension Block) address
+// MWEB outputs live in the extension block and cannot be built with plain wire.MsgTx
+//
+// ErrMwebAddress 表示目标是 MWEB（MimbleWimble 扩展区块）地址
+// MWEB 输出位于扩展区块中，不能使用普通的 wire.MsgTx 构建
+var ErrMwebAddress = errors.New("mweb address not supported")
+
+// IsMwebAddress tells whether address is a MWEB address (ltcmweb1 on mainnet, tmweb1 on testnet)
+//
+// IsMwebAddress 判断地址是否为 MWEB 地址（主网是 ltcmweb1，测试网是 tmweb1）
+func IsMwebAddress(address string) bool {
+	lower := strings.ToLower(address)
+	return strings.HasPrefix(lower, "ltcmweb1") || strings.HasPrefix(lower, "tmweb1")
+}
+
+// DecodeAddress decodes Litecoin address, rejecting MWEB addresses with ErrMwebAddress
+// Legacy script hash addresses (3 on mainnet, 2 on testnet) are accepted and converted to the current prefix
+//
+// DecodeAddress 解析莱特币地址，MWEB 地址会以 ErrMwebAddress 拒绝
+// 旧的脚本哈希地址（主网 3 开头，测试网 2 开头）会被接受并转换为当前的前缀
+func DecodeAddress(address string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	if IsMwebAddress(address) {
+		return nil, errors.WithMessagef(ErrMwebAddress, "wrong address=%s is MWEB, send to a ltc1 or L/M address instead", address)
+	}
+	if converted, err := ConvertLegacyScriptHashAddress(address, netParams); err == nil {
+		address = converted
+	}
+	res, err := addresses.DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode-address")
+	}
+	if !res.IsForNet(netParams) {
+		return nil, errors.Errorf("wrong address=%s is not for network=%s", address, netParams.Name)
+	}
+	return res, nil
+}
+
+// GetAddressPkScript returns pkScript of Litecoin address, rejecting MWEB addresses with ErrMwebAddress
+// Use it to fill AddressTuple.PkScript of Litecoin senders and targets
+//
+// GetAddressPkScript 返回莱特币地址的公钥脚本，MWEB 地址会以 ErrMwebAddress 拒绝
+// 用它填写莱特币发送者和接收者的 AddressTuple.PkScript
+func GetAddressPkScript(address string, netParams *chaincfg.Params) ([]byte, error) {
+	res, err := DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(res)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong get-pk-script")
+	}
+	return pkScript, nil
+}
+
+// ConvertLegacyScriptHashAddress converts legacy script hash address (3 on mainnet, 2 on testnet) to the current prefix
+// Litecoin switched P2SH prefix to M/Q, but old wallets still show 3/2 addresses of the same script
+//
+// ConvertLegacyScriptHashAddress 把旧的脚本哈希地址（主网 3 开头，测试网 2 开头）转换为当前的前缀
+// 莱特币把 P2SH 的前缀换成了 M/Q，但旧的钱包仍然显示同一脚本的 3/2 开头的地址
+func ConvertLegacyScriptHashAddress(address string, netParams *chaincfg.Params) (string, error) {
+	hash, version, err := base58.CheckDecode(address)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong base58-check-decode")
+	}
+	if version != legacyScriptHashAddrID(netParams) || len(hash) != 20 {
+		return "", errors.Errorf("wrong address=%s is not legacy script hash address", address)
+	}
+	res, err := btcutil.NewAddressScriptHashFromHash(hash, netParams)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong new-address-script-hash")
+	}
+	return res.EncodeAddress(), nil
+}
+
+// legacyScriptHashAddrID returns old P2SH version byte, 5 on mainnet and 196 on testnet and regtest
+// legacyScriptHashAddrID 返回旧的 P2SH 版本字节，主网是 5，测试网和回归测试网是 196
+func legacyScriptHashAddrID(netParams *chaincfg.Params) byte {
+	if netParams.Net == MainNetParams.Net {
+		return 5
+	}
+	return 196
+}
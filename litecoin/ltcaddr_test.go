@@ -0,0 +1,68 @@
+package litecoin
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsMwebAddress validates MWEB address detection on mainnet and testnet prefixes
+//
+// TestIsMwebAddress 验证主网和测试网前缀的 MWEB 地址识别
+func TestIsMwebAddress(t *testing.T) {
+	require.True(t, IsMwebAddress("ltcmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3"))
+	require.True(t, IsMwebAddress("TMWEB1QQ0YQ03EWM830UGMK"))
+	require.False(t, IsMwebAddress("ltc1qvg2jksxckt96cdv9g8v9psreaggdzsrl4qu57z"))
+	require.False(t, IsMwebAddress("LUAZqrgYEJ6hR8NaKyPJZwKdgyvWpxec9K"))
+}
+
+// TestDecodeAddress_Mweb validates MWEB destinations are rejected with ErrMwebAddress
+//
+// TestDecodeAddress_Mweb 验证 MWEB 目标地址会以 ErrMwebAddress 拒绝
+func TestDecodeAddress_Mweb(t *testing.T) {
+	_, err := DecodeAddress("ltcmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3", &MainNetParams)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrMwebAddress))
+	t.Log(err)
+}
+
+// TestDecodeAddress_WrongNet validates address of another network is rejected
+//
+// TestDecodeAddress_WrongNet 验证其它网络的地址会被拒绝
+func TestDecodeAddress_WrongNet(t *testing.T) {
+	_, err := DecodeAddress("tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladg", &MainNetParams)
+	require.Error(t, err)
+}
+
+// TestConvertLegacyScriptHashAddress validates legacy 3/2 addresses convert to M/Q addresses of same script
+//
+// TestConvertLegacyScriptHashAddress 验证旧的 3/2 开头的地址转换为同一脚本的 M/Q 开头的地址
+func TestConvertLegacyScriptHashAddress(t *testing.T) {
+	address, err := ConvertLegacyScriptHashAddress("3AddWBs9hYB2FVNrGw4biYcodHqxCKXrE2", &MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "MGqmp5H7ef2T3zekNp3wYBsCwzSQGX7peL", address)
+
+	res, err := DecodeAddress("3AddWBs9hYB2FVNrGw4biYcodHqxCKXrE2", &MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "MGqmp5H7ef2T3zekNp3wYBsCwzSQGX7peL", res.EncodeAddress())
+
+	address, err = ConvertLegacyScriptHashAddress("2N2BqZvoBJzgNTH1Px4gULVc4qe47ziTamb", &TestNetParams)
+	require.NoError(t, err)
+	require.Equal(t, byte('Q'), address[0])
+
+	_, err = ConvertLegacyScriptHashAddress("LUAZqrgYEJ6hR8NaKyPJZwKdgyvWpxec9K", &MainNetParams)
+	require.Error(t, err)
+}
+
+// TestGetAddressPkScript validates pkScript of Litecoin address and MWEB rejection
+//
+// TestGetAddressPkScript 验证莱特币地址的公钥脚本以及对 MWEB 地址的拒绝
+func TestGetAddressPkScript(t *testing.T) {
+	pkScript, err := GetAddressPkScript("ltc1qvg2jksxckt96cdv9g8v9psreaggdzsrl4qu57z", &MainNetParams)
+	require.NoError(t, err)
+	require.Len(t, pkScript, 22)
+
+	_, err = GetAddressPkScript("ltcmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3", &MainNetParams)
+	require.True(t, errors.Is(err, ErrMwebAddress))
+}
@@ -0,0 +1,38 @@
+package litecoin
+
+import (
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/yyle88/gobtcsign/internal/dusts"
+)
+
+// DustFee type alias from internal dusts package
+// DustFee 来自 internal dusts 包的类型别名
+type DustFee = dusts.DustFee
+
+// NewLtcDustFee creates empty DustFee configuration for Litecoin
+// Litecoin has no soft dust fee, returns zero config to maintain logic consistency with Dogecoin
+//
+// NewLtcDustFee 创建莱特币的空 DustFee 配置
+// 莱特币没有软灰尘费用，返回零配置以保持与狗狗币逻辑一致
+func NewLtcDustFee() DustFee {
+	return dusts.NewDustFee()
+}
+
+// DustLimit type alias from internal dusts package
+// DustLimit 来自 internal dusts 包的类型别名
+type DustLimit = dusts.DustLimit
+
+// NewLtcDustLimit creates DustLimit with Litecoin dust rules
+// Litecoin Core uses the Bitcoin formula with dust relay fee 30000 sat/kvB, which equals txrules.IsDustOutput at 10000 sat/kvB
+// Relay fees below MinRelayFeePerKb are raised to it, so a Bitcoin default relay fee cannot loosen the rule
+//
+// NewLtcDustLimit 创建使用莱特币灰尘规则的 DustLimit
+// 莱特币 Core 使用比特币的公式，灰尘转发费率是 30000 sat/kvB，等价于 txrules.IsDustOutput 使用 10000 sat/kvB
+// 低于 MinRelayFeePerKb 的转发费率会被提高到该值，这样比特币的默认转发费率不会放宽规则
+func NewLtcDustLimit() *DustLimit {
+	return dusts.NewDustLimit(func(output *wire.TxOut, relayFeePerKb btcutil.Amount) bool {
+		return txrules.IsDustOutput(output, max(relayFeePerKb, MinRelayFeePerKb))
+	})
+}
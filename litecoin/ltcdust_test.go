@@ -0,0 +1,55 @@
+package litecoin
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewLtcDustFee validates Litecoin has no soft dust fee
+//
+// TestNewLtcDustFee 验证莱特币没有软灰尘费用
+func TestNewLtcDustFee(t *testing.T) {
+	dustFee := NewLtcDustFee()
+	require.Equal(t, btcutil.Amount(0), dustFee.SoftDustSize)
+	require.Equal(t, btcutil.Amount(0), dustFee.ExtraDustFee)
+}
+
+// TestLtcDustLimit_IsDustOutput validates dust thresholds of P2PKH (5460) and P2WPKH (2940) outputs
+// Relay fee below MinRelayFeePerKb is raised so thresholds keep the same
+//
+// TestLtcDustLimit_IsDustOutput 验证 P2PKH（5460）和 P2WPKH（2940）输出的灰尘阈值
+// 低于 MinRelayFeePerKb 的转发费率会被提高，因此阈值保持不变
+func TestLtcDustLimit_IsDustOutput(t *testing.T) {
+	p2pkh, err := txscript.PayToAddrScript(mustDecode(t, "mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb"))
+	require.NoError(t, err)
+	p2wpkh, err := txscript.PayToAddrScript(mustDecode(t, "tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladg"))
+	require.NoError(t, err)
+
+	dustLimit := NewLtcDustLimit()
+	for _, relayFeePerKb := range []btcutil.Amount{1000, MinRelayFeePerKb} {
+		require.True(t, dustLimit.IsDustOutput(wire.NewTxOut(5459, p2pkh), relayFeePerKb))
+		require.False(t, dustLimit.IsDustOutput(wire.NewTxOut(5460, p2pkh), relayFeePerKb))
+		require.True(t, dustLimit.IsDustOutput(wire.NewTxOut(2939, p2wpkh), relayFeePerKb))
+		require.False(t, dustLimit.IsDustOutput(wire.NewTxOut(2940, p2wpkh), relayFeePerKb))
+	}
+}
+
+// TestNewLtcFeeRateBounds validates fee rate clamping with Litecoin bounds
+//
+// TestNewLtcFeeRateBounds 验证使用莱特币边界限制费率
+func TestNewLtcFeeRateBounds(t *testing.T) {
+	bounds := NewLtcFeeRateBounds()
+	require.Equal(t, btcutil.Amount(MinRelayFeePerKb), bounds.Clamp(1000))
+	require.Equal(t, btcutil.Amount(20000), bounds.Clamp(20000))
+	require.Equal(t, btcutil.Amount(MaxFeeRatePerKb), bounds.Clamp(MaxFeeRatePerKb*2))
+}
+
+func mustDecode(t *testing.T, address string) btcutil.Address {
+	res, err := DecodeAddress(address, &TestNetParams)
+	require.NoError(t, err)
+	return res
+}
@@ -0,0 +1,30 @@
+package litecoin
+
+import (
+	"github.com/yyle88/gobtcsign/internal/feerates"
+)
+
+const (
+	// MinRelayFeePerKb represents default min relay fee rate (0.0001 LTC/kB) of Litecoin Core
+	// Reference: https://github.com/litecoin-project/litecoin/blob/master/src/policy/policy.h
+	//
+	// MinRelayFeePerKb 代表莱特币 Core 默认的最低转发费率（0.0001 LTC/kB）
+	// 参考：https://github.com/litecoin-project/litecoin/blob/master/src/policy/policy.h
+	MinRelayFeePerKb = 10000
+
+	// MaxFeeRatePerKb represents sane ceiling of fee rate (0.01 LTC/kB)
+	//
+	// MaxFeeRatePerKb 代表合理的费率上限（0.01 LTC/kB）
+	MaxFeeRatePerKb = 1000000
+)
+
+// FeeRateBounds type alias from internal feerates package
+// FeeRateBounds 来自 internal feerates 包的类型别名
+type FeeRateBounds = feerates.FeeRateBounds
+
+// NewLtcFeeRateBounds creates FeeRateBounds with Litecoin min relay fee as floor
+//
+// NewLtcFeeRateBounds 创建以莱特币最低转发费率为下限的 FeeRateBounds
+func NewLtcFeeRateBounds() FeeRateBounds {
+	return feerates.NewFeeRateBounds(MinRelayFeePerKb, MaxFeeRatePerKb)
+}
@@ -0,0 +1,146 @@
+package litecoin_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign"
+	"github.com/yyle88/gobtcsign/litecoin"
+)
+
+const (
+	testPrivateKeyHex = "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"
+	testP2PKHAddress  = "mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb"
+	testP2WPKHAddress = "tltc1qvg2jksxckt96cdv9g8v9psreaggdzsrlzjladg"
+	testTargetAddress = "tltc1qknwmnkmgqcdqlmys5j72auslstyvlg0t6ny7v2"
+)
+
+// TestSignLTC_P2PKH signs fixture P2PKH tx on Litecoin testnet and pins the signed result
+//
+// TestSignLTC_P2PKH 在莱特币测试网签名 P2PKH 固定交易并固定签名结果
+func TestSignLTC_P2PKH(t *testing.T) {
+	netParams := litecoin.TestNetParams
+
+	param := gobtcsign.BitcoinTxParams{
+		VinList: []gobtcsign.VinType{
+			{
+				OutPoint: *gobtcsign.MustNewOutPoint("57a3514865d3f4c5cbd49270204aaf4928c4c10651430dcd0cb79b80cda5ef0b", 1),
+				Sender:   *gobtcsign.NewAddressTuple(testP2PKHAddress),
+				Amount:   1000000,
+				RBFInfo:  *gobtcsign.NewRBFNotUse(),
+			},
+		},
+		OutList: []gobtcsign.OutType{
+			{
+				Target: *gobtcsign.NewAddressTuple(testTargetAddress),
+				Amount: 600000,
+			},
+			{
+				Target: *gobtcsign.NewAddressTuple(testP2PKHAddress),
+				Amount: 1000000 - 600000 - 2260,
+			},
+		},
+		RBFInfo: *gobtcsign.NewRBFActive(),
+	}
+	require.Equal(t, int64(2260), int64(param.GetFee()))
+
+	signParam, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	require.NoError(t, gobtcsign.Sign(testP2PKHAddress, testPrivateKeyHex, signParam))
+
+	msgTx := signParam.MsgTx
+	require.NoError(t, gobtcsign.VerifySignV2(msgTx, param.GetInputList(), &netParams))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, &netParams))
+
+	txHash := gobtcsign.GetTxHash(msgTx)
+	t.Log("msg-tx-hash:->", txHash, "<-")
+	require.Equal(t, "9f22a6941d66199fce330a592722d8e5fc88ed9677983a5774143e1addd3b414", txHash)
+
+	signedHex, err := gobtcsign.CvtMsgTxToHex(msgTx)
+	require.NoError(t, err)
+	t.Log("raw-tx-data:->", signedHex, "<-")
+	require.Equal(t, "01000000010befa5cd809bb70ccd0d435106c1c42849af4a207092d4cbc5f4d3654851a357010000006a47304402200d5090e9a50eaf856b403db0a4875b8cc9f34be9a364c264dc2d219ff76811fa022008684004a8b8a898179a84f888b1ed3cacf9e9264818782344ccf3fafc47cb76012102407ea64d7a9e992028a94481af95ea7d8f54870bd73e5878a014da594335ba32fdffffff02c027090000000000160014b4ddb9db68061a0fec90a4bcaef21f82c8cfa1ebac110600000000001976a91462152b40d8b2cbac358541d850c079ea10d1407f88ac00000000", signedHex)
+}
+
+// TestSignLTC_P2WPKH signs fixture P2WPKH tx on Litecoin testnet and pins the signed result
+//
+// TestSignLTC_P2WPKH 在莱特币测试网签名 P2WPKH 固定交易并固定签名结果
+func TestSignLTC_P2WPKH(t *testing.T) {
+	netParams := litecoin.TestNetParams
+
+	param := gobtcsign.BitcoinTxParams{
+		VinList: []gobtcsign.VinType{
+			{
+				OutPoint: *gobtcsign.MustNewOutPoint("af3ec989221c5940bc6fe811b8746f043df7ffd77afd5dd6250d4e82928b8cb4", 2),
+				Sender:   *gobtcsign.NewAddressTuple(testP2WPKHAddress),
+				Amount:   500000,
+				RBFInfo:  *gobtcsign.NewRBFNotUse(),
+			},
+		},
+		OutList: []gobtcsign.OutType{
+			{
+				Target: *gobtcsign.NewAddressTuple(testTargetAddress),
+				Amount: 200000,
+			},
+			{
+				Target: *gobtcsign.NewAddressTuple(testP2WPKHAddress),
+				Amount: 500000 - 200000 - 1410,
+			},
+		},
+		RBFInfo: *gobtcsign.NewRBFActive(),
+	}
+	require.Equal(t, int64(1410), int64(param.GetFee()))
+
+	signParam, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	require.NoError(t, gobtcsign.Sign(testP2WPKHAddress, testPrivateKeyHex, signParam))
+
+	msgTx := signParam.MsgTx
+	require.NoError(t, gobtcsign.VerifySignV2(msgTx, param.GetInputList(), &netParams))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, &netParams))
+
+	txHash := gobtcsign.GetTxHash(msgTx)
+	t.Log("msg-tx-hash:->", txHash, "<-")
+	require.Equal(t, "ddbcd0864ce128fcdfcef25ebe6b627b2f41d2f4de9150321b8b0de22c43cd2f", txHash)
+
+	signedHex, err := gobtcsign.CvtMsgTxToHex(msgTx)
+	require.NoError(t, err)
+	t.Log("raw-tx-data:->", signedHex, "<-")
+	require.Equal(t, "01000000000101b48c8b92824e0d25d65dfd7ad7fff73d046f74b811e86fbc40591c2289c93eaf0200000000fdffffff02400d030000000000160014b4ddb9db68061a0fec90a4bcaef21f82c8cfa1eb5e8e04000000000016001462152b40d8b2cbac358541d850c079ea10d1407f02483045022100a286d5c110c093868ea3a2f0f89a98694fa392334323d859add4cde5a105549802200ce11926e2c099b1a06beb782e9642d2994bd50f6189749c48394999e6cc932b012102407ea64d7a9e992028a94481af95ea7d8f54870bd73e5878a014da594335ba3200000000", signedHex)
+}
+
+// TestSignLTC_MwebTarget validates MWEB destination is rejected when building tx
+//
+// TestSignLTC_MwebTarget 验证构建交易时 MWEB 目标地址会被拒绝
+func TestSignLTC_MwebTarget(t *testing.T) {
+	netParams := litecoin.TestNetParams
+
+	param := gobtcsign.BitcoinTxParams{
+		VinList: []gobtcsign.VinType{
+			{
+				OutPoint: *gobtcsign.MustNewOutPoint("af3ec989221c5940bc6fe811b8746f043df7ffd77afd5dd6250d4e82928b8cb4", 2),
+				Sender:   *gobtcsign.NewAddressTuple(testP2WPKHAddress),
+				Amount:   500000,
+				RBFInfo:  *gobtcsign.NewRBFNotUse(),
+			},
+		},
+		OutList: []gobtcsign.OutType{
+			{
+				Target: *gobtcsign.NewAddressTuple("tmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3"),
+				Amount: 200000,
+			},
+		},
+		RBFInfo: *gobtcsign.NewRBFActive(),
+	}
+	_, err := param.CreateTxSignParams(&netParams)
+	require.Error(t, err)
+
+	_, err = gobtcsign.NewLitecoinTestNetChain().CreateTxSignParams(&param)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, gobtcsign.ErrUnsupportedAddressType))
+	t.Log(err)
+
+	_, err = litecoin.GetAddressPkScript(param.OutList[0].Target.Address, &netParams)
+	require.True(t, errors.Is(err, litecoin.ErrMwebAddress))
+}
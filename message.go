@@ -0,0 +1,328 @@
+package gobtcsign
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// BitcoinMessageMagic is the prefix used by Bitcoin Core signmessage/verifymessage
+// Other chains use their own prefix, such as dogecoin.MessageMagic
+//
+// BitcoinMessageMagic 是 Bitcoin Core signmessage/verifymessage 使用的前缀
+// 其它链使用各自的前缀，比如 dogecoin.MessageMagic
+const BitcoinMessageMagic = "Bitcoin Signed Message:\n"
+
+// SignMessage signs message with legacy signmessage format (compact recoverable ECDSA)
+// Only P2PKH address is supported, same as Bitcoin Core
+//
+// SignMessage 使用传统的 signmessage 格式签名消息（可恢复公钥的紧凑 ECDSA 签名）
+// 和 Bitcoin Core 相同，只支持 P2PKH 地址
+func SignMessage(senderAddress string, privateKeyHex string, message string, netParams *chaincfg.Params) (string, error) {
+	return SignMessageWithMagic(senderAddress, privateKeyHex, message, netParams, BitcoinMessageMagic)
+}
+
+// SignMessageWithMagic signs message with legacy signmessage format and custom prefix
+// Result is base64 text of 65 bytes: recovery flag + r + s
+//
+// SignMessageWithMagic 使用传统的 signmessage 格式和自定义前缀签名消息
+// 结果是 65 字节的 base64 文本：恢复标识 + r + s
+func SignMessageWithMagic(senderAddress string, privateKeyHex string, message string, netParams *chaincfg.Params, magic string) (string, error) {
+	privKeyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong decode private key string")
+	}
+	privKey, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+
+	walletAddress, err := DecodeAddress(senderAddress, netParams)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong from_address")
+	}
+	if _, ok := walletAddress.(*btcutil.AddressPubKeyHash); !ok {
+		return "", errors.Errorf("wrong from address=%s address_type=%s not-support-this-address-type", senderAddress, reflect.TypeOf(walletAddress).String())
+	}
+	// The recovery flag records whether the address uses compressed public key
+	// 恢复标识里会记录地址是否使用压缩公钥
+	compress, err := CheckPKHAddressIsCompress(netParams, pubKey, senderAddress)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong check_from_address_is_compress")
+	}
+	signature := ecdsa.SignCompact(privKey, CalcMessageHash(message, magic), compress)
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// VerifyMessage verifies legacy signmessage signature with Bitcoin prefix
+//
+// VerifyMessage 使用比特币前缀验证传统 signmessage 签名
+func VerifyMessage(address string, signature string, message string, netParams *chaincfg.Params) error {
+	return VerifyMessageWithMagic(address, signature, message, netParams, BitcoinMessageMagic)
+}
+
+// VerifyMessageWithMagic verifies legacy signmessage signature with custom prefix
+// Recovers public key from signature and compares its P2PKH address with the given one
+//
+// VerifyMessageWithMagic 使用自定义前缀验证传统 signmessage 签名
+// 从签名中恢复公钥，再比较其 P2PKH 地址和给定地址是否相同
+func VerifyMessageWithMagic(address string, signature string, message string, netParams *chaincfg.Params, magic string) error {
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.WithMessage(err, "wrong decode signature base64")
+	}
+	pubKey, compress, err := ecdsa.RecoverCompact(signatureBytes, CalcMessageHash(message, magic))
+	if err != nil {
+		return errors.WithMessage(err, "wrong recover-compact")
+	}
+	var pubKeyBytes []byte
+	if compress {
+		pubKeyBytes = pubKey.SerializeCompressed()
+	} else {
+		pubKeyBytes = pubKey.SerializeUncompressed()
+	}
+	recovered, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKeyBytes), netParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong recovered-address")
+	}
+	if recovered.EncodeAddress() != address {
+		return errors.Errorf("wrong signature address mismatch: got %s, expected %s", recovered.EncodeAddress(), address)
+	}
+	return nil
+}
+
+// CalcMessageHash returns double-sha256 of var-string magic and var-string message
+//
+// CalcMessageHash 返回变长编码的前缀和变长编码的消息拼接后的双重 sha256 哈希
+func CalcMessageHash(message string, magic string) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, magic) // bytes.Buffer never returns error // bytes.Buffer 写入不会出错
+	_ = wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// bip322MessageTag is the tag of BIP340 tagged hash used by BIP322 message hash
+// bip322MessageTag 是 BIP322 消息哈希使用的 BIP340 标签哈希的标签
+var bip322MessageTag = []byte("BIP0322-signed-message")
+
+// SignMessageBIP322 signs message with BIP322 "simple" format (base64 witness stack)
+// Supports P2WPKH and P2TR (BIP86 key-path) addresses
+//
+// SignMessageBIP322 使用 BIP322 的 "simple" 格式签名消息（见证栈的 base64 文本）
+// 支持 P2WPKH 和 P2TR（BIP86 密钥路径）地址
+func SignMessageBIP322(senderAddress string, privateKeyHex string, message string, netParams *chaincfg.Params) (string, error) {
+	toSign, err := signBIP322ToSign(senderAddress, privateKeyHex, message, netParams)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong sign-to-sign")
+	}
+	var buf bytes.Buffer
+	if err := writeTxWitness(&buf, toSign.TxIn[0].Witness); err != nil {
+		return "", errors.WithMessage(err, "wrong write-witness")
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// SignMessageBIP322Full signs message with BIP322 "full" format (base64 to_sign transaction)
+//
+// SignMessageBIP322Full 使用 BIP322 的 "full" 格式签名消息（to_sign 交易的 base64 文本）
+func SignMessageBIP322Full(senderAddress string, privateKeyHex string, message string, netParams *chaincfg.Params) (string, error) {
+	toSign, err := signBIP322ToSign(senderAddress, privateKeyHex, message, netParams)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong sign-to-sign")
+	}
+	var buf bytes.Buffer
+	if err := toSign.Serialize(&buf); err != nil {
+		return "", errors.WithMessage(err, "wrong serialize")
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// VerifyMessageBIP322 verifies BIP322 signature in "simple" or "full" format
+// Runs the virtual to_sign transaction through the same script engine used by VerifySign
+//
+// VerifyMessageBIP322 验证 "simple" 或 "full" 格式的 BIP322 签名
+// 把虚拟的 to_sign 交易交给 VerifySign 使用的脚本引擎执行验证
+func VerifyMessageBIP322(address string, signature string, message string, netParams *chaincfg.Params) error {
+	pkScript, err := GetAddressPkScript(address, netParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong address->pk-script")
+	}
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.WithMessage(err, "wrong decode signature base64")
+	}
+	toSpend := NewBIP322ToSpend(message, pkScript)
+
+	var toSign *wire.MsgTx
+	if witness, err := readTxWitness(signatureBytes); err == nil {
+		toSign = NewBIP322ToSign(toSpend)
+		toSign.TxIn[0].Witness = witness
+	} else {
+		toSign = &wire.MsgTx{}
+		if err := toSign.Deserialize(bytes.NewReader(signatureBytes)); err != nil {
+			return errors.WithMessage(err, "wrong signature neither simple nor full")
+		}
+		if err := checkBIP322ToSign(toSign, toSpend); err != nil {
+			return errors.WithMessage(err, "wrong full to_sign")
+		}
+	}
+
+	signParam := &SignParam{
+		MsgTx:     toSign,
+		InputOuts: []*wire.TxOut{toSpend.TxOut[0]},
+		NetParams: netParams,
+	}
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(newPrevOutsMap(signParam))
+	sigHashes := txscript.NewTxSigHashes(toSign, prevOutFetcher)
+	return VerifySign(toSign, signParam.InputOuts, prevOutFetcher, sigHashes)
+}
+
+// CalcBIP322MessageHash returns tagged hash of message used in to_spend transaction
+//
+// CalcBIP322MessageHash 返回 to_spend 交易中使用的消息标签哈希
+func CalcBIP322MessageHash(message string) *chainhash.Hash {
+	return chainhash.TaggedHash(bip322MessageTag, []byte(message))
+}
+
+// NewBIP322ToSpend creates the virtual to_spend transaction committing to message and pkScript
+//
+// NewBIP322ToSpend 创建承诺了消息和公钥脚本的虚拟 to_spend 交易
+func NewBIP322ToSpend(message string, pkScript []byte) *wire.MsgTx {
+	messageHash := CalcBIP322MessageHash(message)
+	// OP_0 PUSH32[message_hash], building it by hand avoids the builder's error return
+	// OP_0 PUSH32[message_hash]，手动拼接避免处理 builder 的错误返回
+	signatureScript := append([]byte{txscript.OP_0, txscript.OP_DATA_32}, messageHash[:]...)
+
+	toSpend := wire.NewMsgTx(0)
+	toSpend.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		SignatureScript:  signatureScript,
+		Sequence:         0,
+	})
+	toSpend.AddTxOut(wire.NewTxOut(0, pkScript))
+	return toSpend
+}
+
+// NewBIP322ToSign creates the unsigned virtual to_sign transaction spending to_spend
+//
+// NewBIP322ToSign 创建花费 to_spend 的未签名虚拟 to_sign 交易
+func NewBIP322ToSign(toSpend *wire.MsgTx) *wire.MsgTx {
+	toSpendHash := toSpend.TxHash()
+
+	toSign := wire.NewMsgTx(0)
+	toSign.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&toSpendHash, 0),
+		Sequence:         0,
+	})
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return toSign
+}
+
+func signBIP322ToSign(senderAddress string, privateKeyHex string, message string, netParams *chaincfg.Params) (*wire.MsgTx, error) {
+	privKeyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode private key string")
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+
+	walletAddress, err := DecodeAddress(senderAddress, netParams)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong from_address")
+	}
+	pkScript, err := txscript.PayToAddrScript(walletAddress)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong get-pk-script")
+	}
+	toSpend := NewBIP322ToSpend(message, pkScript)
+	toSign := NewBIP322ToSign(toSpend)
+
+	signParam := &SignParam{
+		MsgTx:     toSign,
+		InputOuts: []*wire.TxOut{toSpend.TxOut[0]},
+		NetParams: netParams,
+	}
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(newPrevOutsMap(signParam))
+	sigHashes := txscript.NewTxSigHashes(toSign, prevOutFetcher)
+
+	var witness wire.TxWitness
+	switch address := walletAddress; address.(type) {
+	case *btcutil.AddressWitnessPubKeyHash:
+		witness, err = txscript.WitnessSignature(toSign, sigHashes, 0, 0, pkScript, txscript.SigHashAll, privKey, true)
+		if err != nil {
+			return nil, errors.WithMessage(err, "witness_signature is wrong")
+		}
+	case *btcutil.AddressTaproot:
+		witness, err = txscript.TaprootWitnessSignature(toSign, sigHashes, 0, 0, pkScript, txscript.SigHashDefault, privKey)
+		if err != nil {
+			return nil, errors.WithMessage(err, "taproot_witness_signature is wrong")
+		}
+	default:
+		return nil, errors.Errorf("wrong from address=%s address_type=%s not-support-this-address-type", address, reflect.TypeOf(address).String())
+	}
+	toSign.TxIn[0].Witness = witness
+
+	if err := VerifySign(toSign, signParam.InputOuts, prevOutFetcher, sigHashes); err != nil {
+		return nil, errors.WithMessage(err, "wrong verify-sign")
+	}
+	return toSign, nil
+}
+
+// checkBIP322ToSign checks the "full" to_sign transaction really spends to_spend and commits nothing else
+// checkBIP322ToSign 检查 "full" 格式的 to_sign 交易确实花费 to_spend 且不包含其它内容
+func checkBIP322ToSign(toSign *wire.MsgTx, toSpend *wire.MsgTx) error {
+	if len(toSign.TxIn) != 1 {
+		return errors.Errorf("wrong input count: %d", len(toSign.TxIn))
+	}
+	toSpendHash := toSpend.TxHash()
+	if toSign.TxIn[0].PreviousOutPoint != *wire.NewOutPoint(&toSpendHash, 0) {
+		return errors.New("wrong input not-spend-to_spend")
+	}
+	if len(toSign.TxOut) != 1 || toSign.TxOut[0].Value != 0 || !bytes.Equal(toSign.TxOut[0].PkScript, []byte{txscript.OP_RETURN}) {
+		return errors.New("wrong output not-op-return")
+	}
+	return nil
+}
+
+func writeTxWitness(buf *bytes.Buffer, witness wire.TxWitness) error {
+	if err := wire.WriteVarInt(buf, 0, uint64(len(witness))); err != nil {
+		return err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(buf, 0, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTxWitness parses witness stack and requires every byte to be consumed
+// readTxWitness 解析见证栈，并且要求全部字节都被读完
+func readTxWitness(data []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(data)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong read-witness-count")
+	}
+	if count == 0 || count > uint64(len(data)) {
+		return nil, errors.Errorf("wrong witness count: %d", count)
+	}
+	var witness = make(wire.TxWitness, 0, count)
+	for idx := uint64(0); idx < count; idx++ {
+		item, err := wire.ReadVarBytes(reader, 0, uint32(len(data)), "witness-item")
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong read-witness-item")
+		}
+		witness = append(witness, item)
+	}
+	if reader.Len() != 0 {
+		return nil, errors.Errorf("wrong witness trailing bytes: %d", reader.Len())
+	}
+	return witness, nil
+}
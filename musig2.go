@@ -0,0 +1,458 @@
+package gobtcsign
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// NewMuSig2AggregateKey aggregates signer public keys into one Taproot output key (BIP327 + BIP86)
+// Keys are sorted before aggregation, so every party gets the same result regardless of order
+//
+// NewMuSig2AggregateKey 把多个签名者的公钥聚合为一个 Taproot 输出公钥（BIP327 + BIP86）
+// 聚合前会对公钥排序，因此各方无论按什么顺序传入都能得到相同结果
+func NewMuSig2AggregateKey(pubKeys []*btcec.PublicKey) (*btcec.PublicKey, error) {
+	if len(pubKeys) < 2 {
+		return nil, errors.Errorf("wrong musig2 signer count: %d", len(pubKeys))
+	}
+	aggregateKey, _, _, err := musig2.AggregateKeys(pubKeys, true, musig2.WithBIP86KeyTweak())
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong aggregate-keys")
+	}
+	return aggregateKey.FinalKey, nil
+}
+
+// NewMuSig2Address creates the P2TR address controlled by all signers together
+// Coins sent to this address can only be spent with a MuSig2Session signed by every signer
+//
+// NewMuSig2Address 创建由全部签名者共同控制的 P2TR 地址
+// 转入这个地址的币只能通过全部签名者共同完成的 MuSig2Session 花费
+func NewMuSig2Address(pubKeys []*btcec.PublicKey, netParams *chaincfg.Params) (string, error) {
+	aggregateKey, err := NewMuSig2AggregateKey(pubKeys)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong aggregate-key")
+	}
+	address, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(aggregateKey), netParams)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong taproot-address")
+	}
+	return address.EncodeAddress(), nil
+}
+
+// MuSig2Session represents one signer's state in a MuSig2 signing round
+// Each input gets its own nonce pair, signers exchange only PubNonces and PartialSigs
+// Secret nonces live only in memory and are never serialized: signing twice with the same secret nonce,
+// for example from a copy restored from storage, leaks the private key.
+// So PartialSign must run on the session returned by NewMuSig2Session, not on a reloaded copy.
+// The exported fields are hex strings, after PartialSign the session can be saved as JSON to collect and combine signatures
+//
+// MuSig2Session 代表一个签名者在 MuSig2 签名流程中的状态
+// 每个输入都有独立的随机数对，签名者之间只需要交换 PubNonces 和 PartialSigs
+// 秘密随机数只保存在内存中且绝不会被序列化：用同一个秘密随机数签两次，比如从存储中恢复的副本再签一次，会泄露私钥。
+// 因此 PartialSign 必须在 NewMuSig2Session 返回的会话上执行，而不是在重新加载的副本上执行。
+// 导出字段都是 hex 字符串，PartialSign 之后可以把会话以 JSON 格式保存，用于收集和合并签名
+type MuSig2Session struct {
+	SignerPubKeys []string            `json:"signer_pub_keys"`        // Compressed public keys of all signers // 全部签名者的压缩公钥
+	LocalPubKey   string              `json:"local_pub_key"`          // Compressed public key of this signer // 当前签名者的压缩公钥
+	SigHashes     []string            `json:"sig_hashes"`             // Taproot sighash of each input // 每个输入的 Taproot 签名哈希
+	PubNonces     map[string][]string `json:"pub_nonces"`             // Public nonces of each signer // 每个签名者的公开随机数
+	PartialSigs   map[string][]string `json:"partial_sigs"`           // Partial signatures of each signer // 每个签名者的部分签名
+	FinalNonces   []string            `json:"final_nonces,omitempty"` // Aggregated signing nonce R of each input, set once signed // 每个输入聚合后的签名随机数 R，签名后才设置
+
+	secNonces [][musig2.SecNonceSize]byte // Secret nonces of this signer, in memory only and wiped after signing // 当前签名者的秘密随机数，只在内存中且签名后清空
+}
+
+// NewMuSig2Session creates signing session and generates fresh nonces for every input
+// Every input of signParam must be spent from the aggregated P2TR address
+//
+// NewMuSig2Session 创建签名会话并为每个输入生成新的随机数
+// signParam 里的每个输入都必须来自聚合后的 P2TR 地址
+func NewMuSig2Session(localPubKey *btcec.PublicKey, pubKeys []*btcec.PublicKey, signParam *SignParam) (*MuSig2Session, error) {
+	aggregateKey, err := NewMuSig2AggregateKey(pubKeys)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong aggregate-key")
+	}
+	var signerPubKeys = make([]string, 0, len(pubKeys))
+	var localIncluded bool
+	for _, pubKey := range pubKeys {
+		if pubKey.IsEqual(localPubKey) {
+			localIncluded = true
+		}
+		signerPubKeys = append(signerPubKeys, hex.EncodeToString(pubKey.SerializeCompressed()))
+	}
+	if !localIncluded {
+		return nil, errors.New("wrong local-pub-key not-in-signer-pub-keys")
+	}
+
+	msgHashes, err := calcMuSig2SigHashes(signParam, aggregateKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong calc-sig-hashes")
+	}
+
+	var session = &MuSig2Session{
+		SignerPubKeys: signerPubKeys,
+		LocalPubKey:   hex.EncodeToString(localPubKey.SerializeCompressed()),
+		SigHashes:     make([]string, 0, len(msgHashes)),
+		secNonces:     make([][musig2.SecNonceSize]byte, 0, len(msgHashes)),
+		PubNonces:     make(map[string][]string, len(pubKeys)),
+		PartialSigs:   make(map[string][]string, len(pubKeys)),
+	}
+	var pubNonces = make([]string, 0, len(msgHashes))
+	for _, msgHash := range msgHashes {
+		// Nonces must never be reused across inputs or sessions, so generate them one by one
+		// 随机数不能在输入之间或会话之间复用，因此逐个生成
+		nonces, err := musig2.GenNonces(
+			musig2.WithPublicKey(localPubKey),
+			musig2.WithNonceCombinedKeyAux(aggregateKey),
+			musig2.WithNonceMessageAux(msgHash),
+		)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong gen-nonces")
+		}
+		session.SigHashes = append(session.SigHashes, hex.EncodeToString(msgHash[:]))
+		session.secNonces = append(session.secNonces, nonces.SecNonce)
+		pubNonces = append(pubNonces, hex.EncodeToString(nonces.PubNonce[:]))
+	}
+	session.PubNonces[session.LocalPubKey] = pubNonces
+	return session, nil
+}
+
+// GetLocalPubNonces returns public nonces of this signer, send them to other signers
+//
+// GetLocalPubNonces 返回当前签名者的公开随机数，需要发给其它签名者
+func (session *MuSig2Session) GetLocalPubNonces() []string {
+	return session.PubNonces[session.LocalPubKey]
+}
+
+// AddPubNonces records public nonces received from another signer
+// Nonces of this signer itself, or nonces arriving after PartialSign, are rejected
+//
+// AddPubNonces 记录从其它签名者收到的公开随机数
+// 拒绝当前签名者自己的随机数，也拒绝在 PartialSign 之后收到的随机数
+func (session *MuSig2Session) AddPubNonces(signerPubKey string, pubNonces []string) error {
+	if session.isSigned() {
+		return errors.New("wrong pub-nonces after partial-sign")
+	}
+	if signerPubKey == session.LocalPubKey {
+		return errors.New("wrong signer is local-pub-key")
+	}
+	if err := session.checkSigner(signerPubKey); err != nil {
+		return errors.WithMessage(err, "wrong signer")
+	}
+	if len(pubNonces) != len(session.SigHashes) {
+		return errors.Errorf("wrong pub-nonces count: got %d, expected %d", len(pubNonces), len(session.SigHashes))
+	}
+	for idx, pubNonce := range pubNonces {
+		if _, err := decodeMuSig2PubNonce(pubNonce); err != nil {
+			return errors.WithMessagef(err, "wrong pub-nonce. index=%d", idx)
+		}
+	}
+	session.PubNonces[signerPubKey] = pubNonces
+	return nil
+}
+
+// PartialSign creates partial signatures of this signer once all public nonces are collected
+// Checks signParam still produces the same sighashes, then wipes secret nonces to avoid reuse
+// A session reloaded from JSON has no secret nonces and cannot sign
+//
+// PartialSign 在收集到全部公开随机数后创建当前签名者的部分签名
+// 先检查 signParam 得到的签名哈希与会话一致，再清空秘密随机数以避免复用
+// 从 JSON 重新加载的会话没有秘密随机数，不能签名
+func (session *MuSig2Session) PartialSign(privKey *btcec.PrivateKey, signParam *SignParam) error {
+	if hex.EncodeToString(privKey.PubKey().SerializeCompressed()) != session.LocalPubKey {
+		return errors.New("wrong private-key not-match-local-pub-key")
+	}
+	if session.isSigned() {
+		return errors.New("wrong sec-nonces already-used")
+	}
+	if len(session.secNonces) != len(session.SigHashes) {
+		return errors.New("wrong sec-nonces not-in-memory")
+	}
+	pubKeys, err := session.getSignerPubKeys()
+	if err != nil {
+		return errors.WithMessage(err, "wrong signer-pub-keys")
+	}
+	msgHashes, err := session.checkSigHashes(signParam, pubKeys)
+	if err != nil {
+		return errors.WithMessage(err, "wrong sig-hashes")
+	}
+
+	var partialSigs = make([]string, 0, len(msgHashes))
+	var finalNonces = make([]string, 0, len(msgHashes))
+	for idx, msgHash := range msgHashes {
+		combinedNonce, err := session.aggregateNonces(idx)
+		if err != nil {
+			return errors.WithMessagef(err, "wrong aggregate-nonces. index=%d", idx)
+		}
+		partialSig, err := musig2.Sign(session.secNonces[idx], privKey, combinedNonce, pubKeys, msgHash, musig2.WithSortedKeys(), musig2.WithBip86SignTweak())
+		if err != nil {
+			return errors.WithMessagef(err, "wrong musig2-sign. index=%d", idx)
+		}
+		partialSigs = append(partialSigs, encodeMuSig2PartialSig(partialSig))
+		finalNonces = append(finalNonces, hex.EncodeToString(partialSig.R.SerializeCompressed()))
+	}
+	session.secNonces = nil // Never sign twice with the same nonces // 同一组随机数绝不能签两次
+	session.PartialSigs[session.LocalPubKey] = partialSigs
+	session.FinalNonces = finalNonces
+	return nil
+}
+
+// GetLocalPartialSigs returns partial signatures of this signer, send them to the combiner
+//
+// GetLocalPartialSigs 返回当前签名者的部分签名，需要发给负责合并签名的一方
+func (session *MuSig2Session) GetLocalPartialSigs() []string {
+	return session.PartialSigs[session.LocalPubKey]
+}
+
+// AddPartialSigs verifies and records partial signatures received from another signer
+//
+// AddPartialSigs 验证并记录从其它签名者收到的部分签名
+func (session *MuSig2Session) AddPartialSigs(signerPubKey string, partialSigs []string) error {
+	if err := session.checkSigner(signerPubKey); err != nil {
+		return errors.WithMessage(err, "wrong signer")
+	}
+	if len(partialSigs) != len(session.SigHashes) {
+		return errors.Errorf("wrong partial-sigs count: got %d, expected %d", len(partialSigs), len(session.SigHashes))
+	}
+	pubKeys, err := session.getSignerPubKeys()
+	if err != nil {
+		return errors.WithMessage(err, "wrong signer-pub-keys")
+	}
+	signingKey, err := decodeMuSig2PubKey(signerPubKey)
+	if err != nil {
+		return errors.WithMessage(err, "wrong signer-pub-key")
+	}
+	signerPubNonces, ok := session.PubNonces[signerPubKey]
+	if !ok {
+		return errors.New("wrong signer pub-nonces not-exist")
+	}
+	for idx, item := range partialSigs {
+		partialSig, err := decodeMuSig2PartialSig(item)
+		if err != nil {
+			return errors.WithMessagef(err, "wrong partial-sig. index=%d", idx)
+		}
+		pubNonce, err := decodeMuSig2PubNonce(signerPubNonces[idx])
+		if err != nil {
+			return errors.WithMessagef(err, "wrong pub-nonce. index=%d", idx)
+		}
+		combinedNonce, err := session.aggregateNonces(idx)
+		if err != nil {
+			return errors.WithMessagef(err, "wrong aggregate-nonces. index=%d", idx)
+		}
+		msgHash, err := decodeMuSig2Hash(session.SigHashes[idx])
+		if err != nil {
+			return errors.WithMessagef(err, "wrong sig-hash. index=%d", idx)
+		}
+		if !partialSig.Verify(pubNonce, combinedNonce, pubKeys, signingKey, msgHash, musig2.WithSortedKeys(), musig2.WithBip86SignTweak()) {
+			return errors.Errorf("wrong partial-sig verify. index=%d", idx)
+		}
+	}
+	session.PartialSigs[signerPubKey] = partialSigs
+	return nil
+}
+
+// Combine aggregates all partial signatures into Schnorr signatures and sets them as input witnesses
+// Must be called on the session that has done PartialSign, since it holds the final nonces
+//
+// Combine 把全部部分签名聚合为 Schnorr 签名并设置为输入的见证数据
+// 必须在已经执行过 PartialSign 的会话上调用，因为聚合需要用到最终随机数
+func (session *MuSig2Session) Combine(signParam *SignParam) error {
+	if len(session.FinalNonces) != len(session.SigHashes) {
+		return errors.New("wrong final-nonces local-partial-sign-not-done")
+	}
+	pubKeys, err := session.getSignerPubKeys()
+	if err != nil {
+		return errors.WithMessage(err, "wrong signer-pub-keys")
+	}
+	msgHashes, err := session.checkSigHashes(signParam, pubKeys)
+	if err != nil {
+		return errors.WithMessage(err, "wrong sig-hashes")
+	}
+
+	var msgTx = signParam.MsgTx // Pointer pass means this serves as both parameter and return value // 这里是指针传递，因此这个既是参数也是返回值
+	for idx, msgHash := range msgHashes {
+		var partialSigs = make([]*musig2.PartialSignature, 0, len(session.SignerPubKeys))
+		for _, signerPubKey := range session.SignerPubKeys {
+			items, ok := session.PartialSigs[signerPubKey]
+			if !ok {
+				return errors.Errorf("wrong partial-sigs not-exist. signer=%s", signerPubKey)
+			}
+			partialSig, err := decodeMuSig2PartialSig(items[idx])
+			if err != nil {
+				return errors.WithMessagef(err, "wrong partial-sig. index=%d", idx)
+			}
+			partialSigs = append(partialSigs, partialSig)
+		}
+		finalNonce, err := decodeMuSig2PubKey(session.FinalNonces[idx])
+		if err != nil {
+			return errors.WithMessagef(err, "wrong final-nonce. index=%d", idx)
+		}
+		signature := musig2.CombineSigs(finalNonce, partialSigs, musig2.WithBip86TweakedCombine(msgHash, pubKeys, true))
+		// SigHashDefault signatures are 64 bytes without the sighash type suffix
+		// SigHashDefault 的签名是 64 字节，不需要在末尾追加签名类型
+		msgTx.TxIn[idx].Witness = wire.TxWitness{signature.Serialize()}
+	}
+
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(newPrevOutsMap(signParam))
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+	return VerifySign(msgTx, signParam.InputOuts, prevOutFetcher, sigHashes)
+}
+
+// isSigned reports whether PartialSign has been done, FinalNonces are set only then
+// isSigned 判断是否已经执行过 PartialSign，只有那时才会设置 FinalNonces
+func (session *MuSig2Session) isSigned() bool {
+	return len(session.FinalNonces) != 0 || len(session.PartialSigs[session.LocalPubKey]) != 0
+}
+
+func (session *MuSig2Session) checkSigner(signerPubKey string) error {
+	for _, item := range session.SignerPubKeys {
+		if item == signerPubKey {
+			return nil
+		}
+	}
+	return errors.Errorf("wrong signer=%s not-in-signer-pub-keys", signerPubKey)
+}
+
+func (session *MuSig2Session) getSignerPubKeys() ([]*btcec.PublicKey, error) {
+	var pubKeys = make([]*btcec.PublicKey, 0, len(session.SignerPubKeys))
+	for _, item := range session.SignerPubKeys {
+		pubKey, err := decodeMuSig2PubKey(item)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong pub-key=%s", item)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
+
+// checkSigHashes recomputes sighashes to make sure the transaction was not changed after nonce exchange
+// checkSigHashes 重新计算签名哈希，确保交换随机数以后交易没有被修改
+func (session *MuSig2Session) checkSigHashes(signParam *SignParam, pubKeys []*btcec.PublicKey) ([][32]byte, error) {
+	aggregateKey, err := NewMuSig2AggregateKey(pubKeys)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong aggregate-key")
+	}
+	msgHashes, err := calcMuSig2SigHashes(signParam, aggregateKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong calc-sig-hashes")
+	}
+	if len(msgHashes) != len(session.SigHashes) {
+		return nil, errors.Errorf("wrong sig-hashes count: got %d, expected %d", len(msgHashes), len(session.SigHashes))
+	}
+	for idx, msgHash := range msgHashes {
+		if hex.EncodeToString(msgHash[:]) != session.SigHashes[idx] {
+			return nil, errors.Errorf("wrong sig-hash mismatch. index=%d", idx)
+		}
+	}
+	return msgHashes, nil
+}
+
+func (session *MuSig2Session) aggregateNonces(idx int) ([musig2.PubNonceSize]byte, error) {
+	var pubNonces = make([][musig2.PubNonceSize]byte, 0, len(session.SignerPubKeys))
+	for _, signerPubKey := range session.SignerPubKeys {
+		items, ok := session.PubNonces[signerPubKey]
+		if !ok {
+			return [musig2.PubNonceSize]byte{}, errors.Errorf("wrong pub-nonces not-exist. signer=%s", signerPubKey)
+		}
+		pubNonce, err := decodeMuSig2PubNonce(items[idx])
+		if err != nil {
+			return [musig2.PubNonceSize]byte{}, errors.WithMessage(err, "wrong pub-nonce")
+		}
+		pubNonces = append(pubNonces, pubNonce)
+	}
+	return musig2.AggregateNonces(pubNonces)
+}
+
+// calcMuSig2SigHashes computes BIP341 key-spend sighash (SigHashDefault) of each input
+// calcMuSig2SigHashes 计算每个输入的 BIP341 密钥路径签名哈希（SigHashDefault）
+func calcMuSig2SigHashes(signParam *SignParam, aggregateKey *btcec.PublicKey) ([][32]byte, error) {
+	var msgTx = signParam.MsgTx
+	if len(signParam.InputOuts) < len(msgTx.TxIn) {
+		return nil, errors.New("wrong param-outs-length")
+	}
+	pkScript, err := txscript.PayToTaprootScript(aggregateKey)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong taproot-pk-script")
+	}
+
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(newPrevOutsMap(signParam))
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+
+	var msgHashes = make([][32]byte, 0, len(msgTx.TxIn))
+	for idx := range msgTx.TxIn {
+		if !bytes.Equal(signParam.InputOuts[idx].PkScript, pkScript) {
+			return nil, errors.Errorf("wrong input pk-script not-musig2-address. index=%d", idx)
+		}
+		sigHash, err := txscript.CalcTaprootSignatureHash(sigHashes, txscript.SigHashDefault, msgTx, idx, prevOutFetcher)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong taproot-sig-hash. index=%d", idx)
+		}
+		var msgHash [32]byte
+		copy(msgHash[:], sigHash)
+		msgHashes = append(msgHashes, msgHash)
+	}
+	return msgHashes, nil
+}
+
+func decodeMuSig2PubKey(item string) (*btcec.PublicKey, error) {
+	data, err := hex.DecodeString(item)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode-hex")
+	}
+	return btcec.ParsePubKey(data)
+}
+
+func decodeMuSig2Hash(item string) ([32]byte, error) {
+	var res [32]byte
+	data, err := hex.DecodeString(item)
+	if err != nil {
+		return res, errors.WithMessage(err, "wrong decode-hex")
+	}
+	if len(data) != len(res) {
+		return res, errors.Errorf("wrong hash size=%d", len(data))
+	}
+	copy(res[:], data)
+	return res, nil
+}
+
+func decodeMuSig2PubNonce(item string) ([musig2.PubNonceSize]byte, error) {
+	var res [musig2.PubNonceSize]byte
+	data, err := hex.DecodeString(item)
+	if err != nil {
+		return res, errors.WithMessage(err, "wrong decode-hex")
+	}
+	if len(data) != len(res) {
+		return res, errors.Errorf("wrong pub-nonce size=%d", len(data))
+	}
+	copy(res[:], data)
+	return res, nil
+}
+
+func encodeMuSig2PartialSig(partialSig *musig2.PartialSignature) string {
+	var sBytes [32]byte
+	partialSig.S.PutBytes(&sBytes)
+	return hex.EncodeToString(sBytes[:])
+}
+
+func decodeMuSig2PartialSig(item string) (*musig2.PartialSignature, error) {
+	data, err := hex.DecodeString(item)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode-hex")
+	}
+	if len(data) != 32 {
+		return nil, errors.Errorf("wrong partial-sig size=%d", len(data))
+	}
+	var partialSig = &musig2.PartialSignature{}
+	if err := partialSig.Decode(bytes.NewReader(data)); err != nil {
+		return nil, errors.WithMessage(err, "wrong partial-sig decode")
+	}
+	return partialSig, nil
+}
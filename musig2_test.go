@@ -0,0 +1,207 @@
+package gobtcsign
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func caseMuSig2PrivateKey(t *testing.T, privateKeyHex string) *btcec.PrivateKey {
+	privKeyBytes, err := hex.DecodeString(privateKeyHex)
+	require.NoError(t, err)
+	privKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+	return privKey
+}
+
+// caseMuSig2SaveLoad simulates passing the session through JSON storage between two processes
+func caseMuSig2SaveLoad(t *testing.T, session *MuSig2Session) *MuSig2Session {
+	data, err := json.Marshal(session)
+	require.NoError(t, err)
+	var res MuSig2Session
+	require.NoError(t, json.Unmarshal(data, &res))
+	return &res
+}
+
+func TestMuSig2Session_Sign(t *testing.T) {
+	netParams := chaincfg.TestNet3Params
+
+	privKeyA := caseMuSig2PrivateKey(t, "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092")
+	privKeyB := caseMuSig2PrivateKey(t, "5f397bc72377b75db7b008a9c3fcd71651bfb138d6fc2458bb0279b9cfc8442a")
+
+	pubKeysA := []*btcec.PublicKey{privKeyA.PubKey(), privKeyB.PubKey()}
+	pubKeysB := []*btcec.PublicKey{privKeyB.PubKey(), privKeyA.PubKey()}
+
+	senderAddress, err := NewMuSig2Address(pubKeysA, &netParams)
+	require.NoError(t, err)
+	t.Log(senderAddress)
+
+	// The key order does not matter
+	senderAddressB, err := NewMuSig2Address(pubKeysB, &netParams)
+	require.NoError(t, err)
+	require.Equal(t, senderAddress, senderAddressB)
+
+	param := &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   10000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+			{
+				OutPoint: *MustNewOutPoint("fcc889d7f0217694ab46d93f03a200d326c34e317552a6a33cb3fab03aa0b439", 1),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   20000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"),
+				Amount: 12000,
+			},
+			{
+				Target: *NewAddressTuple(senderAddress),
+				Amount: 17000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+
+	// Each party builds the same unsigned transaction independently
+	signParamA, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	signParamB, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+
+	sessionA, err := NewMuSig2Session(privKeyA.PubKey(), pubKeysA, signParamA)
+	require.NoError(t, err)
+	sessionB, err := NewMuSig2Session(privKeyB.PubKey(), pubKeysB, signParamB)
+	require.NoError(t, err)
+
+	// Exchange public nonces
+	require.NoError(t, sessionA.AddPubNonces(sessionB.LocalPubKey, sessionB.GetLocalPubNonces()))
+	require.NoError(t, sessionB.AddPubNonces(sessionA.LocalPubKey, sessionA.GetLocalPubNonces()))
+
+	// Secret nonces are never saved, so a reloaded copy cannot sign
+	require.Error(t, caseMuSig2SaveLoad(t, sessionA).PartialSign(privKeyA, signParamA))
+
+	require.NoError(t, sessionA.PartialSign(privKeyA, signParamA))
+	require.NoError(t, sessionB.PartialSign(privKeyB, signParamB))
+	require.Empty(t, sessionA.secNonces)
+	require.Error(t, sessionA.PartialSign(privKeyA, signParamA))
+
+	// Party A collects the partial signature of party B and combines
+	sessionA = caseMuSig2SaveLoad(t, sessionA)
+	require.NoError(t, sessionA.AddPartialSigs(sessionB.LocalPubKey, sessionB.GetLocalPartialSigs()))
+	require.NoError(t, sessionA.Combine(signParamA))
+
+	msgTx := signParamA.MsgTx
+	require.NoError(t, VerifySignV2(msgTx, param.GetInputList(), &netParams))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, &netParams))
+	t.Log(GetTxHash(msgTx))
+}
+
+func TestMuSig2Session_WrongPartialSig(t *testing.T) {
+	netParams := chaincfg.TestNet3Params
+
+	privKeyA := caseMuSig2PrivateKey(t, "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092")
+	privKeyB := caseMuSig2PrivateKey(t, "5f397bc72377b75db7b008a9c3fcd71651bfb138d6fc2458bb0279b9cfc8442a")
+	pubKeys := []*btcec.PublicKey{privKeyA.PubKey(), privKeyB.PubKey()}
+
+	senderAddress, err := NewMuSig2Address(pubKeys, &netParams)
+	require.NoError(t, err)
+
+	param := &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   10000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"),
+				Amount: 9000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+	signParamA, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	signParamB, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+
+	sessionA, err := NewMuSig2Session(privKeyA.PubKey(), pubKeys, signParamA)
+	require.NoError(t, err)
+	sessionB, err := NewMuSig2Session(privKeyB.PubKey(), pubKeys, signParamB)
+	require.NoError(t, err)
+	require.NoError(t, sessionA.AddPubNonces(sessionB.LocalPubKey, sessionB.GetLocalPubNonces()))
+	require.NoError(t, sessionB.AddPubNonces(sessionA.LocalPubKey, sessionA.GetLocalPubNonces()))
+	require.NoError(t, sessionA.PartialSign(privKeyA, signParamA))
+	require.NoError(t, sessionB.PartialSign(privKeyB, signParamB))
+
+	// Party B sends back party A's own partial signature, which must be rejected
+	require.Error(t, sessionA.AddPartialSigs(sessionB.LocalPubKey, sessionA.GetLocalPartialSigs()))
+
+	// The combiner refuses to sign a changed transaction
+	signParamA.MsgTx.TxOut[0].Value = 8000
+	require.NoError(t, sessionA.AddPartialSigs(sessionB.LocalPubKey, sessionB.GetLocalPartialSigs()))
+	require.Error(t, sessionA.Combine(signParamA))
+}
+
+func TestMuSig2Session_WrongPubNonces(t *testing.T) {
+	netParams := chaincfg.TestNet3Params
+
+	privKeyA := caseMuSig2PrivateKey(t, "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092")
+	privKeyB := caseMuSig2PrivateKey(t, "5f397bc72377b75db7b008a9c3fcd71651bfb138d6fc2458bb0279b9cfc8442a")
+	pubKeys := []*btcec.PublicKey{privKeyA.PubKey(), privKeyB.PubKey()}
+
+	senderAddress, err := NewMuSig2Address(pubKeys, &netParams)
+	require.NoError(t, err)
+
+	param := &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   10000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"),
+				Amount: 9000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+	signParamA, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	signParamB, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+
+	sessionA, err := NewMuSig2Session(privKeyA.PubKey(), pubKeys, signParamA)
+	require.NoError(t, err)
+	sessionB, err := NewMuSig2Session(privKeyB.PubKey(), pubKeys, signParamB)
+	require.NoError(t, err)
+
+	// Counterparty must not overwrite the local nonces
+	localPubNonces := sessionA.GetLocalPubNonces()
+	require.Error(t, sessionA.AddPubNonces(sessionA.LocalPubKey, sessionB.GetLocalPubNonces()))
+	require.Equal(t, localPubNonces, sessionA.GetLocalPubNonces())
+
+	require.NoError(t, sessionA.AddPubNonces(sessionB.LocalPubKey, sessionB.GetLocalPubNonces()))
+	require.NoError(t, sessionA.PartialSign(privKeyA, signParamA))
+
+	// Nonces are frozen once signed, also in the reloaded session
+	require.Error(t, sessionA.AddPubNonces(sessionB.LocalPubKey, sessionB.GetLocalPubNonces()))
+	require.Error(t, caseMuSig2SaveLoad(t, sessionA).AddPubNonces(sessionB.LocalPubKey, sessionB.GetLocalPubNonces()))
+}
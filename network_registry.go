@@ -0,0 +1,147 @@
+package gobtcsign
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/dogecoin"
+	"github.com/yyle88/gobtcsign/litecoin"
+)
+
+// Network represents one chain network known to NetworkRegistry
+//
+// Network 代表 NetworkRegistry 中的一个链网络
+type Network struct {
+	Name      string           // Unique name such as bitcoin-testnet3 // 唯一名称，比如 bitcoin-testnet3
+	Chain     string           // Chain name such as bitcoin, dogecoin, litecoin // 链名称，比如 bitcoin、dogecoin、litecoin
+	NetParams *chaincfg.Params // Network parameters // 网络参数
+}
+
+// NetworkRegistry is library-owned network registry, unlike chaincfg.Register it changes no global state
+// Address decoding goes through DecodeAddress with the params of each network, so nothing needs global registration
+//
+// NetworkRegistry 是库自己的网络注册表，和 chaincfg.Register 不同，它不修改全局状态
+// 地址解析使用每个网络的参数调用 DecodeAddress，因此不需要全局注册
+type NetworkRegistry struct {
+	mutex    sync.RWMutex
+	networks []*Network
+}
+
+// NewNetworkRegistry creates empty NetworkRegistry
+//
+// NewNetworkRegistry 创建空的 NetworkRegistry
+func NewNetworkRegistry() *NetworkRegistry {
+	return &NetworkRegistry{}
+}
+
+// NewDefaultNetworkRegistry creates NetworkRegistry with Bitcoin, Dogecoin and Litecoin networks
+// Every call returns new registry, so registering more networks into it affects nobody else
+//
+// NewDefaultNetworkRegistry 创建包含比特币、狗狗币和莱特币网络的 NetworkRegistry
+// 每次调用都返回新的注册表，因此往里面注册更多网络不会影响其它地方
+func NewDefaultNetworkRegistry() *NetworkRegistry {
+	return &NetworkRegistry{networks: []*Network{
+		{Name: "bitcoin-mainnet", Chain: "bitcoin", NetParams: &chaincfg.MainNetParams},
+		{Name: "bitcoin-testnet3", Chain: "bitcoin", NetParams: &chaincfg.TestNet3Params},
+		{Name: "bitcoin-signet", Chain: "bitcoin", NetParams: &chaincfg.SigNetParams},
+		{Name: "bitcoin-regtest", Chain: "bitcoin", NetParams: &chaincfg.RegressionNetParams},
+		{Name: "dogecoin-mainnet", Chain: "dogecoin", NetParams: &dogecoin.MainNetParams},
+		{Name: "dogecoin-testnet", Chain: "dogecoin", NetParams: &dogecoin.TestNetParams},
+		{Name: "dogecoin-regtest", Chain: "dogecoin", NetParams: &dogecoin.RegressionNetParams},
+		{Name: "litecoin-mainnet", Chain: "litecoin", NetParams: &litecoin.MainNetParams},
+		{Name: "litecoin-testnet4", Chain: "litecoin", NetParams: &litecoin.TestNetParams},
+		{Name: "litecoin-regtest", Chain: "litecoin", NetParams: &litecoin.RegressionNetParams},
+	}}
+}
+
+// Register adds network into registry, names must be unique
+//
+// Register 把网络加入注册表，名称必须唯一
+func (r *NetworkRegistry) Register(network *Network) error {
+	if network.Name == "" || network.NetParams == nil {
+		return errors.New("wrong network without name or params")
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, one := range r.networks {
+		if one.Name == network.Name {
+			return errors.Errorf("wrong network=%s is already registered", network.Name)
+		}
+	}
+	r.networks = append(r.networks, network)
+	return nil
+}
+
+// Lookup returns network with name
+//
+// Lookup 返回指定名称的网络
+func (r *NetworkRegistry) Lookup(name string) (*Network, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, one := range r.networks {
+		if one.Name == name {
+			return one, true
+		}
+	}
+	return nil, false
+}
+
+// Networks returns all networks in registration order
+//
+// Networks 按注册顺序返回全部网络
+func (r *NetworkRegistry) Networks() []*Network {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]*Network(nil), r.networks...)
+}
+
+// AddressCandidate represents one network where address decodes, with its address type
+//
+// AddressCandidate 代表地址能够解析的一个网络，以及地址的类型
+type AddressCandidate struct {
+	Network     *Network        // Network where address decodes // 地址能够解析的网络
+	AddressType string          // Script class such as pubkeyhash or witness_v0_keyhash // 脚本类型，比如 pubkeyhash 或 witness_v0_keyhash
+	Address     btcutil.Address // Decoded address // 解析后的地址
+	PkScript    []byte          // Pk-script of address // 地址的公钥脚本
+}
+
+// DetectAddress returns every network where address decodes, in registration order
+// Version bytes are shared between chains, such as 111 used by Bitcoin testnet, Dogecoin regtest and Litecoin testnet,
+// so one address often has several candidates and caller has to pick the one it expects
+//
+// DetectAddress 按注册顺序返回地址能够解析的全部网络
+// 不同的链会共用版本字节，比如比特币测试网、狗狗币回归测试网和莱特币测试网都使用 111，
+// 因此一个地址经常有多个候选，调用方需要选择它期望的那个
+func (r *NetworkRegistry) DetectAddress(address string) []*AddressCandidate {
+	var candidates []*AddressCandidate
+	for _, network := range r.Networks() {
+		res, err := DecodeAddress(address, network.NetParams)
+		if err != nil || !res.IsForNet(network.NetParams) {
+			continue
+		}
+		pkScript, err := txscript.PayToAddrScript(res)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, &AddressCandidate{
+			Network:     network,
+			AddressType: txscript.GetScriptClass(pkScript).String(),
+			Address:     res,
+			PkScript:    pkScript,
+		})
+	}
+	return candidates
+}
+
+// DetectAddressNetworks returns every candidate network of address in default registry
+//
+// DetectAddressNetworks 返回地址在默认注册表中的全部候选网络
+func DetectAddressNetworks(address string) []*AddressCandidate {
+	return NewDefaultNetworkRegistry().DetectAddress(address)
+}
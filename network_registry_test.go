@@ -0,0 +1,117 @@
+package gobtcsign
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign/dogecoin"
+)
+
+// candidateNames returns network names of candidates
+// candidateNames 返回候选的网络名称
+func candidateNames(candidates []*AddressCandidate) []string {
+	var names []string
+	for _, one := range candidates {
+		names = append(names, one.Network.Name)
+	}
+	return names
+}
+
+// TestDetectAddressNetworks validates every candidate network and address type is returned
+//
+// TestDetectAddressNetworks 验证返回全部候选网络和地址类型
+func TestDetectAddressNetworks(t *testing.T) {
+	testCases := []struct {
+		address     string
+		names       []string
+		addressType string
+	}{
+		{
+			address:     "mpTZshTgxfHtwSA2sQNP7qUCLm9wZvZxAb",
+			names:       []string{"bitcoin-testnet3", "bitcoin-signet", "bitcoin-regtest", "dogecoin-regtest", "litecoin-testnet4", "litecoin-regtest"},
+			addressType: "pubkeyhash",
+		},
+		{
+			address:     "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap",
+			names:       []string{"bitcoin-testnet3", "bitcoin-signet"},
+			addressType: "witness_v0_keyhash",
+		},
+		{
+			address:     "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC",
+			names:       []string{"dogecoin-testnet"},
+			addressType: "pubkeyhash",
+		},
+		{
+			address:     "ltc1qvg2jksxckt96cdv9g8v9psreaggdzsrl4qu57z",
+			names:       []string{"litecoin-mainnet"},
+			addressType: "witness_v0_keyhash",
+		},
+		{
+			address:     "MGqmp5H7ef2T3zekNp3wYBsCwzSQGX7peL",
+			names:       []string{"litecoin-mainnet"},
+			addressType: "scripthash",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			candidates := DetectAddressNetworks(tc.address)
+			require.Equal(t, tc.names, candidateNames(candidates))
+			for _, one := range candidates {
+				require.Equal(t, tc.addressType, one.AddressType)
+				require.Equal(t, tc.address, one.Address.EncodeAddress())
+			}
+		})
+	}
+
+	require.Empty(t, DetectAddressNetworks("ltcmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3"))
+	require.Empty(t, DetectAddressNetworks("not-an-address"))
+}
+
+// TestNetworkRegistry_Register validates custom network registration stays inside the registry
+//
+// TestNetworkRegistry_Register 验证自定义网络的注册只在注册表内部生效
+func TestNetworkRegistry_Register(t *testing.T) {
+	customParams := dogecoin.TestNetParams
+	customParams.Name = "custom"
+	customParams.Bech32HRPSegwit = "custom"
+
+	registry := NewNetworkRegistry()
+	require.NoError(t, registry.Register(&Network{Name: "custom", Chain: "custom", NetParams: &customParams}))
+	require.Error(t, registry.Register(&Network{Name: "custom", Chain: "custom", NetParams: &customParams}))
+
+	network, ok := registry.Lookup("custom")
+	require.True(t, ok)
+	require.Equal(t, "custom", network.Chain)
+
+	candidates := registry.DetectAddress("nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC")
+	require.Equal(t, []string{"custom"}, candidateNames(candidates))
+
+	// Default registries are created fresh and do not see the custom network
+	// 默认注册表每次都是新建的，看不到自定义的网络
+	_, ok = NewDefaultNetworkRegistry().Lookup("custom")
+	require.False(t, ok)
+	_, ok = NewDefaultNetworkRegistry().Lookup("bitcoin-mainnet")
+	require.True(t, ok)
+}
+
+// TestDecodeAddress_WithoutRegistration validates segwit address of params never registered into chaincfg
+//
+// TestDecodeAddress_WithoutRegistration 验证从未注册到 chaincfg 的参数的 segwit 地址
+func TestDecodeAddress_WithoutRegistration(t *testing.T) {
+	customParams := chaincfg.TestNet3Params
+	customParams.Bech32HRPSegwit = "zz"
+
+	witnessAddress, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &customParams)
+	require.NoError(t, err)
+	address := witnessAddress.EncodeAddress()
+
+	res, err := DecodeAddress(address, &customParams)
+	require.NoError(t, err)
+	require.True(t, res.IsForNet(&customParams))
+
+	pkScript, err := GetAddressPkScript(address, &customParams)
+	require.NoError(t, err)
+	require.Len(t, pkScript, 22)
+}
@@ -1,6 +1,9 @@
 package gobtcsign
 
 import (
+	"bytes"
+	"context"
+
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/wire"
@@ -38,8 +41,9 @@ type VinType struct {
 // OutType 代表交易输出信息
 // 包含接收者信息和聪的数量
 type OutType struct {
-	Target AddressTuple // Recipient info (address or pubkey, choose one) // 接收者信息（钱包地址或公钥文本，二选一填写即可）
-	Amount int64        // Amount in satoshis // 聪的数量
+	Target   AddressTuple // Recipient info (address or pubkey, choose one) // 接收者信息（钱包地址或公钥文本，二选一填写即可）
+	Amount   int64        // Amount in satoshis // 聪的数量
+	IsChange bool         // Change back to sender, set by NewCustomParamFromMsgTxV3 for audits // 找零给发送者，由 NewCustomParamFromMsgTxV3 设置以便审计
 }
 
 // CreateTxSignParams 根据用户的输入信息拼接交易
@@ -144,6 +148,37 @@ func (param *BitcoinTxParams) GetFee() btcutil.Amount {
 	return btcutil.Amount(sum)
 }
 
+// CheckFunds 检查全部输入是否足以支付全部输出和费用，不足时返回 InsufficientFundsError
+func (param *BitcoinTxParams) CheckFunds(fee btcutil.Amount) error {
+	var available, required int64
+	for _, v := range param.VinList {
+		available += v.Amount
+	}
+	for _, v := range param.OutList {
+		required += v.Amount
+	}
+	required += int64(fee)
+	if available < required {
+		return &InsufficientFundsError{Available: btcutil.Amount(available), Required: btcutil.Amount(required)}
+	}
+	return nil
+}
+
+// CheckDustOutputs 检查是否有低于灰尘限制的输出，有时返回 DustOutputError
+// 比特币使用 NewDustLimit，狗狗币使用 dogecoin.NewDogeDustLimit
+func (param *BitcoinTxParams) CheckDustOutputs(netParams *chaincfg.Params, dustLimit *DustLimit, relayFeePerKb btcutil.Amount) error {
+	outputs, err := param.GetOutputs(netParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong get-outputs")
+	}
+	for idx, output := range outputs {
+		if dustLimit.IsDustOutput(output, relayFeePerKb) {
+			return &DustOutputError{Index: idx, Amount: output.Value}
+		}
+	}
+	return nil
+}
+
 // GetChangeAmountWithFee 根据交易费用计算出找零数量
 func (param *BitcoinTxParams) GetChangeAmountWithFee(fee btcutil.Amount) btcutil.Amount {
 	return param.GetFee() - fee
@@ -157,6 +192,10 @@ func (param *BitcoinTxParams) EstimateTxFee(netParams *chaincfg.Params, change *
 	return EstimateTxFee(param, netParams, change, feeRatePerKb, dustFee)
 }
 
+func (param *BitcoinTxParams) EstimateTxFeeWithCalculator(netParams *chaincfg.Params, change *ChangeTo, calculator TxFeeCalculator) (btcutil.Amount, error) {
+	return EstimateTxFeeWithCalculator(param, netParams, change, calculator)
+}
+
 // NewCustomParamFromMsgTx 这里提供简易的逻辑把交易的原始参数再拼回来
 // 以校验参数和校验签名等信息
 // 因此该函数的主要作用是校验
@@ -166,18 +205,51 @@ func (param *BitcoinTxParams) EstimateTxFee(netParams *chaincfg.Params, change *
 // 通常是使用 客户端 请求获取前置输出，但也可以使用map把前置输出存起来，因此使用 interface 获取前置输出，提供两种实现方案
 // 在项目中推荐使用 rpc 获取，这样就很方便，而在单元测试中则只需要通过 map 预先配置就行，避免网络请求也避免暴露节点配置
 func NewCustomParamFromMsgTx(msgTx *wire.MsgTx, preImp GetUtxoFromInterface) (*BitcoinTxParams, error) {
-	var vinList = make([]VinType, 0, len(msgTx.TxIn))
-	for _, vin := range msgTx.TxIn {
-		costUtxo := vin.PreviousOutPoint
+	return NewCustomParamFromMsgTxV2(context.Background(), msgTx, NewGetUtxoFromV2(preImp, 1))
+}
 
-		utxoFrom, err := preImp.GetUtxoFrom(costUtxo)
+// NewCustomParamFromMsgTxV2 和 NewCustomParamFromMsgTx 相同，但是一次性批量获取全部前置输出
+// 在大额归集交易（输入很多）时能显著减少请求耗时，而且支持通过 ctx 取消
+func NewCustomParamFromMsgTxV2(ctx context.Context, msgTx *wire.MsgTx, preImp GetUtxoFromInterfaceV2) (*BitcoinTxParams, error) {
+	return NewCustomParamFromMsgTxV3(ctx, msgTx, preImp, nil, NewNoChange())
+}
+
+// NewCustomParamFromMsgTxV3 和 NewCustomParamFromMsgTxV2 相同，但是会根据网络参数从公钥脚本还原出输入和输出的地址
+// 同时把支付给找零脚本的输出标记为找零，以便审计时区分付款和找零
+// 当 netParams 为 nil 时不还原地址，当 change 为 nil 或 NewNoChange 时不标记找零
+func NewCustomParamFromMsgTxV3(ctx context.Context, msgTx *wire.MsgTx, preImp GetUtxoFromInterfaceV2, netParams *chaincfg.Params, change *ChangeTo) (*BitcoinTxParams, error) {
+	var changePkScript []byte
+	if change != nil {
+		pkScript, err := change.GetChangePkScript()
 		if err != nil {
-			return nil, errors.WithMessage(err, "get-utxo-from")
+			return nil, errors.WithMessage(err, "wrong change-pk-script")
 		}
+		changePkScript = pkScript
+	}
+	var utxos = make([]wire.OutPoint, 0, len(msgTx.TxIn))
+	for _, vin := range msgTx.TxIn {
+		utxos = append(utxos, vin.PreviousOutPoint)
+	}
+	utxoFroms, err := preImp.GetUtxoFromList(ctx, utxos)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get-utxo-from")
+	}
+	if len(utxoFroms) != len(utxos) {
+		return nil, errors.Errorf("wrong utxo-from count: got %d, expected %d", len(utxoFroms), len(utxos))
+	}
+
+	var vinList = make([]VinType, 0, len(msgTx.TxIn))
+	for idx, vin := range msgTx.TxIn {
+		costUtxo := vin.PreviousOutPoint
+		utxoFrom := utxoFroms[idx]
 
+		sender := *utxoFrom.sender
+		if netParams != nil && sender.Address == "" && len(sender.PkScript) > 0 {
+			sender = *NewAddressTupleFromPkScript(sender.PkScript, netParams)
+		}
 		vinList = append(vinList, VinType{
 			OutPoint: *wire.NewOutPoint(&costUtxo.Hash, costUtxo.Index),
-			Sender:   *utxoFrom.sender,
+			Sender:   sender,
 			Amount:   utxoFrom.amount,
 			RBFInfo:  *NewRBFConfig(vin.Sequence),
 		})
@@ -185,9 +257,14 @@ func NewCustomParamFromMsgTx(msgTx *wire.MsgTx, preImp GetUtxoFromInterface) (*B
 
 	var outList = make([]OutType, 0, len(msgTx.TxOut))
 	for _, out := range msgTx.TxOut {
+		target := AddressTuple{PkScript: out.PkScript}
+		if netParams != nil {
+			target = *NewAddressTupleFromPkScript(out.PkScript, netParams)
+		}
 		outList = append(outList, OutType{
-			Target: AddressTuple{PkScript: out.PkScript},
-			Amount: out.Value,
+			Target:   target,
+			Amount:   out.Value,
+			IsChange: len(changePkScript) > 0 && bytes.Equal(out.PkScript, changePkScript),
 		})
 	}
 
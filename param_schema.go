@@ -0,0 +1,402 @@
+package gobtcsign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// TxParamsSchemaVersion is the version of TxParamsDoc wire format written by this package
+// Schema v1 has the same fields in JSON and YAML, unknown fields, duplicate outpoints,
+// negative amounts and amounts with more than 8 decimals are rejected:
+//
+//	version            integer, must be 1
+//	inputs[]           at least one
+//	  outpoint         "txid:vout", txid is 64 hex chars in explorer order, vout is decimal
+//	  address          sender address, optional when pk_script is set
+//	  pk_script        sender pk-script hex, optional when address is set, must match address when both are set
+//	  amount           integer satoshis, or decimal string in coins with a dot such as "0.00004900"
+//	  rbf              optional {allow_rbf, sequence}, overrides tx rbf on this input
+//	outputs[]          at least one
+//	  address          receiver address, optional when pk_script is set
+//	  pk_script        receiver pk-script hex, optional when address is set, must match address when both are set
+//	  amount           same as input amount
+//	  is_change        optional, output returns change to sender
+//	rbf                optional {allow_rbf, sequence}, applies to all inputs
+//
+// TxParamsSchemaVersion 是本包写出的 TxParamsDoc 传输格式的版本
+// v1 格式在 JSON 和 YAML 中使用相同的字段，未知字段、重复的 outpoint、
+// 负数数量和超过 8 位小数的数量都会被拒绝：
+//
+//	version            整数，必须为 1
+//	inputs[]           至少一个
+//	  outpoint         "txid:vout"，txid 是区块浏览器顺序的 64 个十六进制字符，vout 是十进制
+//	  address          发送者地址，设置了 pk_script 时可以省略
+//	  pk_script        发送者公钥脚本十六进制，设置了 address 时可以省略，两者都设置时必须匹配
+//	  amount           整数的聪，或者以币为单位且带小数点的十进制字符串，比如 "0.00004900"
+//	  rbf              可选的 {allow_rbf, sequence}，在这个输入上覆盖交易的 rbf
+//	outputs[]          至少一个
+//	  address          接收者地址，设置了 pk_script 时可以省略
+//	  pk_script        接收者公钥脚本十六进制，设置了 address 时可以省略，两者都设置时必须匹配
+//	  amount           和输入的 amount 相同
+//	  is_change        可选，输出是给发送者的找零
+//	rbf                可选的 {allow_rbf, sequence}，作用于全部输入
+const TxParamsSchemaVersion = 1
+
+// ErrInvalidTxParamsDoc is matched by errors of decoding and validating TxParamsDoc, check it with errors.Is
+//
+// ErrInvalidTxParamsDoc 能匹配解析和校验 TxParamsDoc 的错误，使用 errors.Is 检查
+var ErrInvalidTxParamsDoc = errors.New("invalid tx params doc")
+
+// TxParamsDoc represents BitcoinTxParams in versioned JSON and YAML wire format, see TxParamsSchemaVersion
+//
+// TxParamsDoc 代表带版本的 JSON 和 YAML 传输格式的 BitcoinTxParams，参见 TxParamsSchemaVersion
+type TxParamsDoc struct {
+	Version int                  `json:"version" yaml:"version"`             // Schema version // 格式版本
+	Inputs  []*TxParamsDocInput  `json:"inputs" yaml:"inputs"`               // Inputs // 输入
+	Outputs []*TxParamsDocOutput `json:"outputs" yaml:"outputs"`             // Outputs // 输出
+	RBF     *TxParamsDocRBF      `json:"rbf,omitempty" yaml:"rbf,omitempty"` // Tx RBF config // 交易的 RBF 配置
+}
+
+// TxParamsDocInput represents VinType in TxParamsDoc
+//
+// TxParamsDocInput 代表 TxParamsDoc 中的 VinType
+type TxParamsDocInput struct {
+	OutPoint string            `json:"outpoint" yaml:"outpoint"`                       // Spent utxo as txid:vout // 花费的 utxo，格式是 txid:vout
+	Address  string            `json:"address,omitempty" yaml:"address,omitempty"`     // Sender address // 发送者地址
+	PkScript string            `json:"pk_script,omitempty" yaml:"pk_script,omitempty"` // Sender pk-script hex // 发送者公钥脚本十六进制
+	Amount   TxParamsDocAmount `json:"amount" yaml:"amount"`                           // Amount in satoshis // 聪的数量
+	RBF      *TxParamsDocRBF   `json:"rbf,omitempty" yaml:"rbf,omitempty"`             // Input RBF config // 输入的 RBF 配置
+}
+
+// TxParamsDocOutput represents OutType in TxParamsDoc
+//
+// TxParamsDocOutput 代表 TxParamsDoc 中的 OutType
+type TxParamsDocOutput struct {
+	Address  string            `json:"address,omitempty" yaml:"address,omitempty"`     // Receiver address // 接收者地址
+	PkScript string            `json:"pk_script,omitempty" yaml:"pk_script,omitempty"` // Receiver pk-script hex // 接收者公钥脚本十六进制
+	Amount   TxParamsDocAmount `json:"amount" yaml:"amount"`                           // Amount in satoshis // 聪的数量
+	IsChange bool              `json:"is_change,omitempty" yaml:"is_change,omitempty"` // Change back to sender // 找零给发送者
+}
+
+// TxParamsDocRBF represents RBFConfig in TxParamsDoc
+//
+// TxParamsDocRBF 代表 TxParamsDoc 中的 RBFConfig
+type TxParamsDocRBF struct {
+	AllowRBF bool   `json:"allow_rbf" yaml:"allow_rbf"` // Enable RBF // 启用 RBF
+	Sequence uint32 `json:"sequence" yaml:"sequence"`   // Input sequence // 输入的序列号
+}
+
+// TxParamsDocAmount represents amount in satoshis, decoded from integer satoshis or decimal string in coins, encoded as integer
+// Decimal strings need a dot so that "4900" is never taken as 4900 coins by mistake
+//
+// TxParamsDocAmount 代表聪的数量，从整数的聪或以币为单位的十进制字符串解析，编码为整数
+// 十进制字符串需要带小数点，避免把 "4900" 误当成 4900 个币
+type TxParamsDocAmount int64
+
+// satoshiPerCoin is satoshis of one coin, the same on Bitcoin, Dogecoin and Litecoin
+// satoshiPerCoin 是一个币的聪数，在比特币、狗狗币和莱特币上都相同
+const satoshiPerCoin = 100000000
+
+// decimalAmountRegexp matches decimal coin amount with dot and at most 8 decimals
+// decimalAmountRegexp 匹配带小数点且最多 8 位小数的币数量
+var decimalAmountRegexp = regexp.MustCompile(`^(0|[1-9][0-9]*)\.([0-9]{1,8})$`)
+
+// ParseTxParamsDocAmount parses integer satoshis or decimal string in coins exactly, without floating point
+//
+// ParseTxParamsDocAmount 精确解析整数的聪或以币为单位的十进制字符串，不使用浮点数
+func ParseTxParamsDocAmount(text string, isString bool) (TxParamsDocAmount, error) {
+	if !isString {
+		satoshi, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || satoshi < 0 {
+			return 0, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong amount=%s, expected non-negative integer satoshis", text)
+		}
+		return TxParamsDocAmount(satoshi), nil
+	}
+	matches := decimalAmountRegexp.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong amount=%q, expected decimal coins with dot and at most 8 decimals", text)
+	}
+	satoshi, ok := decimalCoinsToSatoshi(matches[1], matches[2])
+	if !ok {
+		return 0, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong amount=%q overflows", text)
+	}
+	return TxParamsDocAmount(satoshi), nil
+}
+
+// decimalCoinsToSatoshi converts integer part and at most 8 fraction digits of coins into satoshis exactly, false on overflow
+// decimalCoinsToSatoshi 把币的整数部分和最多 8 位小数精确转换为聪，溢出时返回 false
+func decimalCoinsToSatoshi(coinsText string, fractionText string) (int64, bool) {
+	coins, err := strconv.ParseInt(coinsText, 10, 64)
+	if err != nil || coins > math.MaxInt64/satoshiPerCoin {
+		return 0, false
+	}
+	var fraction int64
+	if fractionText != "" {
+		fraction, _ = strconv.ParseInt(fractionText+strings.Repeat("0", 8-len(fractionText)), 10, 64)
+	}
+	satoshi := coins * satoshiPerCoin
+	if satoshi > math.MaxInt64-fraction {
+		return 0, false
+	}
+	return satoshi + fraction, true
+}
+
+// MarshalJSON encodes amount as integer satoshis
+// MarshalJSON 把数量编码为整数的聪
+func (a TxParamsDocAmount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(a), 10)), nil
+}
+
+// UnmarshalJSON decodes integer satoshis or decimal string in coins
+// UnmarshalJSON 解析整数的聪或以币为单位的十进制字符串
+func (a *TxParamsDocAmount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	isString := strings.HasPrefix(text, `"`)
+	if isString {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong amount=%s", data)
+		}
+	}
+	amount, err := ParseTxParamsDocAmount(text, isString)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// MarshalYAML encodes amount as integer satoshis
+// MarshalYAML 把数量编码为整数的聪
+func (a TxParamsDocAmount) MarshalYAML() (interface{}, error) {
+	return int64(a), nil
+}
+
+// UnmarshalYAML decodes integer satoshis or decimal string in coins, unquoted floats are rejected
+// UnmarshalYAML 解析整数的聪或以币为单位的十进制字符串，拒绝没有引号的浮点数
+func (a *TxParamsDocAmount) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode || (node.ShortTag() != "!!int" && node.ShortTag() != "!!str") {
+		return errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong amount=%s at line %d, expected integer satoshis or quoted decimal coins", node.Value, node.Line)
+	}
+	amount, err := ParseTxParamsDocAmount(node.Value, node.ShortTag() == "!!str")
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// NewTxParamsDoc converts BitcoinTxParams into TxParamsDoc of the current schema version
+//
+// NewTxParamsDoc 把 BitcoinTxParams 转换为当前格式版本的 TxParamsDoc
+func NewTxParamsDoc(param *BitcoinTxParams) *TxParamsDoc {
+	var doc = &TxParamsDoc{
+		Version: TxParamsSchemaVersion,
+		Inputs:  make([]*TxParamsDocInput, 0, len(param.VinList)),
+		Outputs: make([]*TxParamsDocOutput, 0, len(param.OutList)),
+		RBF:     newTxParamsDocRBF(param.RBFInfo),
+	}
+	for _, input := range param.VinList {
+		doc.Inputs = append(doc.Inputs, &TxParamsDocInput{
+			OutPoint: input.OutPoint.String(),
+			Address:  input.Sender.Address,
+			PkScript: hex.EncodeToString(input.Sender.PkScript),
+			Amount:   TxParamsDocAmount(input.Amount),
+			RBF:      newTxParamsDocRBF(input.RBFInfo),
+		})
+	}
+	for _, output := range param.OutList {
+		doc.Outputs = append(doc.Outputs, &TxParamsDocOutput{
+			Address:  output.Target.Address,
+			PkScript: hex.EncodeToString(output.Target.PkScript),
+			Amount:   TxParamsDocAmount(output.Amount),
+			IsChange: output.IsChange,
+		})
+	}
+	return doc
+}
+
+// newTxParamsDocRBF returns nil for zero RBFConfig, so that omitted rbf decodes back to the same config
+// newTxParamsDocRBF 对零值的 RBFConfig 返回 nil，使省略的 rbf 解析回相同的配置
+func newTxParamsDocRBF(cfg RBFConfig) *TxParamsDocRBF {
+	if cfg == (RBFConfig{}) {
+		return nil
+	}
+	return &TxParamsDocRBF{AllowRBF: cfg.AllowRBF, Sequence: cfg.Sequence}
+}
+
+// ToTxParams validates doc and converts it into BitcoinTxParams
+// Addresses and pk-scripts are checked against netParams, address and pk-script given together must match
+//
+// ToTxParams 校验文档并转换为 BitcoinTxParams
+// 地址和公钥脚本会根据 netParams 检查，同时给出的地址和公钥脚本必须匹配
+func (doc *TxParamsDoc) ToTxParams(netParams *chaincfg.Params) (*BitcoinTxParams, error) {
+	if doc.Version != TxParamsSchemaVersion {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong version=%d, expected %d", doc.Version, TxParamsSchemaVersion)
+	}
+	if len(doc.Inputs) == 0 || len(doc.Outputs) == 0 {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong inputs=%d outputs=%d, expected at least one of each", len(doc.Inputs), len(doc.Outputs))
+	}
+	var param = &BitcoinTxParams{
+		VinList: make([]VinType, 0, len(doc.Inputs)),
+		OutList: make([]OutType, 0, len(doc.Outputs)),
+		RBFInfo: doc.RBF.toRBFConfig(),
+	}
+	var outPoints = make(map[wire.OutPoint]int, len(doc.Inputs))
+	for idx, input := range doc.Inputs {
+		if input == nil {
+			return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong input %d is null", idx)
+		}
+		outPoint, err := ParseOutPoint(input.OutPoint)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong input %d", idx)
+		}
+		if prev, ok := outPoints[*outPoint]; ok {
+			return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong input %d outpoint=%s duplicates input %d", idx, input.OutPoint, prev)
+		}
+		outPoints[*outPoint] = idx
+		sender, err := newTxParamsDocAddressTuple(input.Address, input.PkScript, netParams)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong input %d", idx)
+		}
+		if input.Amount <= 0 {
+			return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong input %d amount=%d, expected positive", idx, input.Amount)
+		}
+		param.VinList = append(param.VinList, VinType{
+			OutPoint: *outPoint,
+			Sender:   *sender,
+			Amount:   int64(input.Amount),
+			RBFInfo:  input.RBF.toRBFConfig(),
+		})
+	}
+	for idx, output := range doc.Outputs {
+		if output == nil {
+			return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong output %d is null", idx)
+		}
+		target, err := newTxParamsDocAddressTuple(output.Address, output.PkScript, netParams)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong output %d", idx)
+		}
+		param.OutList = append(param.OutList, OutType{
+			Target:   *target,
+			Amount:   int64(output.Amount),
+			IsChange: output.IsChange,
+		})
+	}
+	return param, nil
+}
+
+// toRBFConfig converts nil into zero RBFConfig
+// toRBFConfig 把 nil 转换为零值的 RBFConfig
+func (r *TxParamsDocRBF) toRBFConfig() RBFConfig {
+	if r == nil {
+		return RBFConfig{}
+	}
+	return RBFConfig{AllowRBF: r.AllowRBF, Sequence: r.Sequence}
+}
+
+// newTxParamsDocAddressTuple creates AddressTuple from address and pk-script hex and checks them on network
+// newTxParamsDocAddressTuple 根据地址和公钥脚本十六进制创建 AddressTuple，并在网络上检查它们
+func newTxParamsDocAddressTuple(address string, pkScriptHex string, netParams *chaincfg.Params) (*AddressTuple, error) {
+	pkScript, err := hex.DecodeString(pkScriptHex)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong pk_script=%s is not hex", pkScriptHex)
+	}
+	if len(pkScript) == 0 {
+		pkScript = nil
+	}
+	var tuple = &AddressTuple{Address: address, PkScript: pkScript}
+	if _, err := tuple.GetPkScript(netParams); err != nil {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong address=%s pk_script=%s: %v", address, pkScriptHex, err)
+	}
+	return tuple, nil
+}
+
+// ParseOutPoint parses outpoint in txid:vout form, the form of wire.OutPoint.String
+//
+// ParseOutPoint 解析 txid:vout 形式的 outpoint，也就是 wire.OutPoint.String 的形式
+func ParseOutPoint(text string) (*wire.OutPoint, error) {
+	txid, vout, ok := strings.Cut(text, ":")
+	if !ok || len(txid) != chainhash.MaxHashStringSize {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong outpoint=%s, expected txid:vout", text)
+	}
+	hash, err := chainhash.NewHashFromStr(txid)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong outpoint=%s txid", text)
+	}
+	index, err := strconv.ParseUint(vout, 10, 32)
+	if err != nil || (len(vout) > 1 && vout[0] == '0') {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong outpoint=%s vout", text)
+	}
+	return wire.NewOutPoint(hash, uint32(index)), nil
+}
+
+// MarshalTxParamsJSON encodes BitcoinTxParams into indented JSON of the current schema version
+//
+// MarshalTxParamsJSON 把 BitcoinTxParams 编码为当前格式版本的缩进 JSON
+func MarshalTxParamsJSON(param *BitcoinTxParams) ([]byte, error) {
+	data, err := json.MarshalIndent(NewTxParamsDoc(param), "", "  ")
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong marshal tx params json")
+	}
+	return data, nil
+}
+
+// UnmarshalTxParamsJSON decodes JSON of TxParamsDoc strictly and validates it on network
+// Unknown fields and trailing data are rejected
+//
+// UnmarshalTxParamsJSON 严格解析 TxParamsDoc 的 JSON 并在网络上校验
+// 拒绝未知字段和多余的数据
+func UnmarshalTxParamsJSON(data []byte, netParams *chaincfg.Params) (*BitcoinTxParams, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var doc TxParamsDoc
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong decode json: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.WithMessage(ErrInvalidTxParamsDoc, "wrong json has trailing data")
+	}
+	return doc.ToTxParams(netParams)
+}
+
+// MarshalTxParamsYAML encodes BitcoinTxParams into YAML of the current schema version
+//
+// MarshalTxParamsYAML 把 BitcoinTxParams 编码为当前格式版本的 YAML
+func MarshalTxParamsYAML(param *BitcoinTxParams) ([]byte, error) {
+	data, err := yaml.Marshal(NewTxParamsDoc(param))
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong marshal tx params yaml")
+	}
+	return data, nil
+}
+
+// UnmarshalTxParamsYAML decodes YAML of TxParamsDoc strictly and validates it on network
+// Unknown fields and multiple documents are rejected
+//
+// UnmarshalTxParamsYAML 严格解析 TxParamsDoc 的 YAML 并在网络上校验
+// 拒绝未知字段和多个文档
+func UnmarshalTxParamsYAML(data []byte, netParams *chaincfg.Params) (*BitcoinTxParams, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var doc TxParamsDoc
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.WithMessagef(ErrInvalidTxParamsDoc, "wrong decode yaml: %v", err)
+	}
+	var extra yaml.Node
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return nil, errors.WithMessage(ErrInvalidTxParamsDoc, "wrong yaml has more than one document")
+	}
+	return doc.ToTxParams(netParams)
+}
@@ -0,0 +1,175 @@
+package gobtcsign
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalTxParamsJSON(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+
+	param := caseErrsTxParams()
+	pkScript, err := GetAddressPkScript(param.VinList[0].Sender.Address, netParams)
+	require.NoError(t, err)
+	param.VinList[0].Sender.PkScript = pkScript
+	param.OutList[0].IsChange = true
+
+	data, err := MarshalTxParamsJSON(param)
+	require.NoError(t, err)
+	t.Log(string(data))
+	require.JSONEq(t, `{
+  "version": 1,
+  "inputs": [
+    {
+      "outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0",
+      "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap",
+      "pk_script": "`+hex.EncodeToString(pkScript)+`",
+      "amount": 4900,
+      "rbf": {"allow_rbf": false, "sequence": 4294967295}
+    }
+  ],
+  "outputs": [
+    {"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000, "is_change": true}
+  ],
+  "rbf": {"allow_rbf": true, "sequence": 4294967293}
+}`, string(data))
+
+	res, err := UnmarshalTxParamsJSON(data, netParams)
+	require.NoError(t, err)
+	require.Equal(t, param, res)
+
+	yamlData, err := MarshalTxParamsYAML(param)
+	require.NoError(t, err)
+	t.Log(string(yamlData))
+	res, err = UnmarshalTxParamsYAML(yamlData, netParams)
+	require.NoError(t, err)
+	require.Equal(t, param, res)
+}
+
+func TestUnmarshalTxParamsYAML_DecimalAmount(t *testing.T) {
+	const text = `
+version: 1
+inputs:
+  - outpoint: fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0
+    address: tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap
+    amount: "0.000049"
+outputs:
+  - address: tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx
+    amount: 3000
+`
+	param, err := UnmarshalTxParamsYAML([]byte(text), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, int64(4900), param.VinList[0].Amount)
+	require.Equal(t, int64(3000), param.OutList[0].Amount)
+	require.Equal(t, uint32(wire.MaxTxInSequenceNum), param.GetTxInputSequence(param.VinList[0]))
+
+	// Unquoted float is ambiguous and rejected
+	// 没有引号的浮点数含义不明确，会被拒绝
+	_, err = UnmarshalTxParamsYAML([]byte(`
+version: 1
+inputs:
+  - outpoint: fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0
+    address: tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap
+    amount: 0.000049
+outputs:
+  - address: tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx
+    amount: 3000
+`), &chaincfg.TestNet3Params)
+	require.ErrorIs(t, err, ErrInvalidTxParamsDoc)
+}
+
+func TestParseTxParamsDocAmount(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		isString bool
+		expected TxParamsDocAmount
+	}{
+		{text: "0", isString: false, expected: 0},
+		{text: "4900", isString: false, expected: 4900},
+		{text: "0.000049", isString: true, expected: 4900},
+		{text: "1.0", isString: true, expected: 100000000},
+		{text: "0.00000001", isString: true, expected: 1},
+		{text: "20999999.9769", isString: true, expected: 2099999997690000},
+		{text: "92233720368.54775807", isString: true, expected: 9223372036854775807},
+	} {
+		amount, err := ParseTxParamsDocAmount(tc.text, tc.isString)
+		require.NoError(t, err, tc.text)
+		require.Equal(t, tc.expected, amount, tc.text)
+	}
+
+	for _, tc := range []struct {
+		text     string
+		isString bool
+	}{
+		{text: "-1", isString: false},
+		{text: "1.5", isString: false},
+		{text: "1e3", isString: false},
+		{text: "4900", isString: true},        // Decimal string needs a dot // 十进制字符串需要小数点
+		{text: "0.000000001", isString: true}, // More than 8 decimals // 超过 8 位小数
+		{text: "-0.1", isString: true},
+		{text: "01.0", isString: true},
+		{text: ".5", isString: true},
+		{text: "92233720368.54775808", isString: true},
+	} {
+		_, err := ParseTxParamsDocAmount(tc.text, tc.isString)
+		require.ErrorIs(t, err, ErrInvalidTxParamsDoc, tc.text)
+	}
+}
+
+func TestUnmarshalTxParamsJSON_Strict(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+
+	pkScript, err := GetAddressPkScript("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", netParams)
+	require.NoError(t, err)
+	senderPkScript := hex.EncodeToString(pkScript)
+	for name, text := range map[string]string{
+		"version":  `{"version": 2, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"unknown":  `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900, "value": 1}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"trailing": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]} {}`,
+		"outpoint": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"vout":     `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:-1", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"duplicate": `{"version": 1, "inputs": [
+			{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900},
+			{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}
+		], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"no-outputs": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": []}`,
+		"zero-input": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 0}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"float":      `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900.5}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"network":    `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": [{"address": "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", "amount": 3000}]}`,
+		"no-address": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"script-hex": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "pk_script": "zz", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"null-input": `{"version": 1, "inputs": [null], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`,
+		"string-sat": `{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "amount": 4900}], "outputs": [{"pk_script": "` + senderPkScript + `", "amount": "3000"}]}`,
+	} {
+		_, err := UnmarshalTxParamsJSON([]byte(text), netParams)
+		require.ErrorIs(t, err, ErrInvalidTxParamsDoc, name)
+	}
+
+	// Address and pk-script given together must match
+	// 同时给出的地址和公钥脚本必须匹配
+	_, err = UnmarshalTxParamsJSON([]byte(`{"version": 1, "inputs": [{"outpoint": "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0", "address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "pk_script": "`+senderPkScript+`", "amount": 4900}], "outputs": [{"address": "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", "amount": 3000}]}`), netParams)
+	require.ErrorIs(t, err, ErrInvalidTxParamsDoc)
+	require.Contains(t, err.Error(), "mismatch")
+}
+
+func TestParseOutPoint(t *testing.T) {
+	outPoint, err := ParseOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:7")
+	require.NoError(t, err)
+	require.Equal(t, *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 7), *outPoint)
+
+	for _, text := range []string{
+		"fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328",
+		"fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:",
+		"fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:07",
+		"fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:4294967296",
+		"fb87cc:0",
+		"zz87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328:0",
+	} {
+		_, err := ParseOutPoint(text)
+		require.ErrorIs(t, err, ErrInvalidTxParamsDoc, text)
+	}
+}
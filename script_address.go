@@ -0,0 +1,39 @@
+package gobtcsign
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pkg/errors"
+)
+
+// GetPkScriptAddress extracts address from standard pkScript on network
+// Supports P2PKH, P2SH, P2WPKH, P2WSH and P2TR, and Dogecoin when given Dogecoin params
+// Returns ErrUnsupportedAddressType for scripts without single address, such as P2PK, bare multisig and OP_RETURN
+//
+// GetPkScriptAddress 根据网络参数从标准的公钥脚本中提取地址
+// 支持 P2PKH、P2SH、P2WPKH、P2WSH 和 P2TR，传入狗狗币参数时也支持狗狗币
+// 对于没有唯一地址的脚本，比如 P2PK、裸多签和 OP_RETURN，返回 ErrUnsupportedAddressType
+func GetPkScriptAddress(pkScript []byte, netParams *chaincfg.Params) (string, error) {
+	scriptClass, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, netParams)
+	if err != nil {
+		return "", errors.WithMessage(err, "wrong extract-pk-script-addrs")
+	}
+	switch scriptClass {
+	case txscript.PubKeyHashTy, txscript.ScriptHashTy, txscript.WitnessV0PubKeyHashTy, txscript.WitnessV0ScriptHashTy, txscript.WitnessV1TaprootTy:
+		if len(addresses) == 1 {
+			return addresses[0].EncodeAddress(), nil
+		}
+	}
+	return "", errors.WithMessagef(ErrUnsupportedAddressType, "wrong pk-script=%x script_class=%s has no address", pkScript, scriptClass)
+}
+
+// NewAddressTupleFromPkScript creates AddressTuple with pkScript and its address, address stays empty when there is none
+//
+// NewAddressTupleFromPkScript 使用公钥脚本及其地址创建 AddressTuple，没有地址时地址为空
+func NewAddressTupleFromPkScript(pkScript []byte, netParams *chaincfg.Params) *AddressTuple {
+	address, err := GetPkScriptAddress(pkScript, netParams)
+	if err != nil {
+		address = ""
+	}
+	return &AddressTuple{Address: address, PkScript: pkScript}
+}
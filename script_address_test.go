@@ -0,0 +1,95 @@
+package gobtcsign
+
+import (
+	"context"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+	"github.com/yyle88/gobtcsign/dogecoin"
+)
+
+func TestGetPkScriptAddress(t *testing.T) {
+	hash20 := btcutil.Hash160([]byte("hash"))
+	hash32 := chainhash.HashB([]byte("hash"))
+
+	for _, netParams := range []*chaincfg.Params{&chaincfg.MainNetParams, &chaincfg.TestNet3Params, &dogecoin.MainNetParams, &dogecoin.TestNetParams} {
+		var addresses []btcutil.Address
+		p2pkh, err := btcutil.NewAddressPubKeyHash(hash20, netParams)
+		require.NoError(t, err)
+		p2sh, err := btcutil.NewAddressScriptHashFromHash(hash20, netParams)
+		require.NoError(t, err)
+		addresses = append(addresses, p2pkh, p2sh)
+		if netParams.Bech32HRPSegwit != "" {
+			p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(hash20, netParams)
+			require.NoError(t, err)
+			p2wsh, err := btcutil.NewAddressWitnessScriptHash(hash32, netParams)
+			require.NoError(t, err)
+			p2tr, err := btcutil.NewAddressTaproot(hash32, netParams)
+			require.NoError(t, err)
+			addresses = append(addresses, p2wpkh, p2wsh, p2tr)
+		}
+		for _, address := range addresses {
+			res, err := GetPkScriptAddress(MustGetPkScript(address), netParams)
+			require.NoError(t, err)
+			require.Equal(t, address.EncodeAddress(), res)
+		}
+	}
+
+	res, err := GetPkScriptAddress(MustGetPkScript(MustNewAddress("nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", &dogecoin.TestNetParams)), &dogecoin.TestNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC", res)
+
+	nullData, err := txscript.NullDataScript([]byte("hello"))
+	require.NoError(t, err)
+	_, err = GetPkScriptAddress(nullData, &chaincfg.MainNetParams)
+	require.ErrorIs(t, err, ErrUnsupportedAddressType)
+
+	require.Empty(t, NewAddressTupleFromPkScript(nullData, &chaincfg.MainNetParams).Address)
+}
+
+func TestNewCustomParamFromMsgTxV3(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+	msgTx := caseBroadcastMsgTx(t)
+	senderPkScript := MustGetPkScript(MustNewAddress("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", netParams))
+
+	// The source only knows the pk-script, like bitcoind omitting the address
+	utxoFrom := NewSenderAmountUtxoCache(map[wire.OutPoint]*SenderAmountUtxo{
+		msgTx.TxIn[0].PreviousOutPoint: NewSenderAmountUtxo(&AddressTuple{PkScript: senderPkScript}, 13089),
+	})
+
+	param, err := NewCustomParamFromMsgTxV3(context.Background(), msgTx, utxoFrom, netParams, &ChangeTo{PkScript: senderPkScript})
+	require.NoError(t, err)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", param.VinList[0].Sender.Address)
+	require.Equal(t, "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", param.OutList[0].Target.Address)
+	require.False(t, param.OutList[0].IsChange)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", param.OutList[1].Target.Address)
+	require.True(t, param.OutList[1].IsChange)
+	require.Equal(t, int64(11111), int64(param.GetFee()))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, netParams))
+	require.NoError(t, param.VerifyMsgTxSign(msgTx, netParams))
+
+	param, err = NewCustomParamFromMsgTxV2(context.Background(), msgTx, utxoFrom)
+	require.NoError(t, err)
+	require.Empty(t, param.OutList[0].Target.Address)
+	require.False(t, param.OutList[1].IsChange)
+}
+
+func TestNewSenderAmountUtxoFromRawTx_PkScriptHex(t *testing.T) {
+	utxo := *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0)
+	res, err := newSenderAmountUtxoFromRawTx(&btcjson.TxRawResult{
+		Vout: []btcjson.Vout{{Value: 0.000049, ScriptPubKey: btcjson.ScriptPubKeyResult{Hex: "001462152b40d8b2cbac358541d850c079ea10d1407f"}}},
+	}, utxo)
+	require.NoError(t, err)
+	require.Empty(t, res.GetSender().Address)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", NewAddressTupleFromPkScript(res.GetSender().PkScript, &chaincfg.TestNet3Params).Address)
+	require.Equal(t, int64(4900), res.GetAmount())
+
+	_, err = newSenderAmountUtxoFromRawTx(&btcjson.TxRawResult{Vout: []btcjson.Vout{{Value: 0.000049}}}, utxo)
+	require.Error(t, err)
+}
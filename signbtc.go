@@ -13,6 +13,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"reflect"
+	"strconv"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
@@ -20,6 +21,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/dogecoin"
 )
 
 // SignParam represents the transaction information required for signing
@@ -53,9 +55,15 @@ func Sign(senderAddress string, privateKeyHex string, param *SignParam) error {
 	}
 	privKey, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
 
+	// Dogecoin params decode doge1 addresses, but the chain has no SegWit, so witness must be rejected before signing
+	// 狗狗币参数能解析 doge1 地址，但是链上没有 SegWit，因此签名前必须拒绝见证
+	if err := CheckWitnessSupport(param.MsgTx, param.InputOuts, param.NetParams); err != nil {
+		return err
+	}
+
 	// Different networks yield different addresses, so network confirmation is needed
 	// 使用的网络不同，得到的地址也不同，因此需要确认网络
-	walletAddress, err := btcutil.DecodeAddress(senderAddress, param.NetParams)
+	walletAddress, err := DecodeAddress(senderAddress, param.NetParams)
 	if err != nil {
 		return errors.WithMessage(err, "wrong from_address")
 	}
@@ -84,7 +92,63 @@ func Sign(senderAddress string, privateKeyHex string, param *SignParam) error {
 		}
 	default: // Other wallet types not yet supported (no need to support all types)
 		// 其它钱包类型暂不支持（倒是没必要支持太多的类型）
-		return errors.Errorf("wrong from address=%s address_type=%s not-support-this-address-type", address, reflect.TypeOf(address).String())
+		return &UnsupportedAddressTypeError{Address: senderAddress, AddressType: reflect.TypeOf(address).String()}
+	}
+	return nil
+}
+
+// isDogecoinParams tells whether params are Dogecoin params, matched by magic and segwit HRP
+// Magic alone is not enough since Dogecoin regtest shares it with Bitcoin Cash regtest
+// Dogecoin params set Bech32HRPSegwit only to avoid collisions with real addresses
+//
+// isDogecoinParams 判断参数是否为狗狗币参数，按网络标识和 segwit HRP 匹配
+// 只看网络标识是不够的，因为狗狗币回归测试网和比特币现金回归测试网的网络标识相同
+// 狗狗币参数设置 Bech32HRPSegwit 只是为了避免和真实的地址冲突
+func isDogecoinParams(netParams *chaincfg.Params) bool {
+	for _, one := range []*chaincfg.Params{&dogecoin.MainNetParams, &dogecoin.TestNetParams, &dogecoin.RegressionNetParams} {
+		if netParams.Net == one.Net && netParams.Bech32HRPSegwit == one.Bech32HRPSegwit {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSegwitSupported tells whether network supports SegWit, false on Dogecoin params
+// Other chains without SegWit are covered by Chain.SegwitSupported and Chain.Sign
+//
+// IsSegwitSupported 判断网络是否支持 SegWit，狗狗币参数返回 false
+// 其它没有 SegWit 的链由 Chain.SegwitSupported 和 Chain.Sign 覆盖
+func IsSegwitSupported(netParams *chaincfg.Params) bool {
+	return !isDogecoinParams(netParams)
+}
+
+// CheckWitnessSupport rejects witness inputs and outputs on networks without SegWit with ErrUnsupportedAddressType
+//
+// CheckWitnessSupport 在没有 SegWit 的网络上以 ErrUnsupportedAddressType 拒绝见证输入和输出
+func CheckWitnessSupport(msgTx *wire.MsgTx, inputOuts []*wire.TxOut, netParams *chaincfg.Params) error {
+	if IsSegwitSupported(netParams) {
+		return nil
+	}
+	return checkNoWitness(msgTx, inputOuts, "dogecoin "+netParams.Name)
+}
+
+// checkNoWitness rejects witness inputs and outputs, networkName is used in error messages
+// checkNoWitness 拒绝见证输入和输出，networkName 用于错误信息
+func checkNoWitness(msgTx *wire.MsgTx, inputOuts []*wire.TxOut, networkName string) error {
+	for idx, inputOut := range inputOuts {
+		if txscript.IsWitnessProgram(inputOut.PkScript) {
+			return errors.WithMessagef(ErrUnsupportedAddressType, "wrong input %d spends witness script=%x on %s without segwit", idx, inputOut.PkScript, networkName)
+		}
+	}
+	for idx, txIn := range msgTx.TxIn {
+		if len(txIn.Witness) > 0 {
+			return errors.WithMessagef(ErrUnsupportedAddressType, "wrong input %d has witness on %s without segwit", idx, networkName)
+		}
+	}
+	for idx, txOut := range msgTx.TxOut {
+		if txscript.IsWitnessProgram(txOut.PkScript) {
+			return errors.WithMessagef(ErrUnsupportedAddressType, "wrong output %d pays witness script=%x on %s without segwit", idx, txOut.PkScript, networkName)
+		}
 	}
 	return nil
 }
@@ -131,30 +195,13 @@ func SignP2WPKH(signParam *SignParam, privKey *btcec.PrivateKey, compress bool)
 // 创建并执行脚本引擎验证脚本
 // 通过签名验证确保交易合法性和安全性
 func VerifySign(msgTx *wire.MsgTx, inputOuts []*wire.TxOut, prevOutFetcher txscript.PrevOutputFetcher, sigHashes *txscript.TxSigHashes) error {
-	// Setting cache size to input length is optimal (can use global cache for larger computations)
-	// 设置为输入的长度是较好的，当然，更大量的计算时也可使用全局的cache
-	sigCache := txscript.NewSigCache(uint(len(msgTx.TxIn)))
-
-	// Validate inputOuts length to avoid panic and catch missing constraints after refactoring
-	// 在底下的逻辑里虽然也能保证，但在这里做一次判断能避免panic，也能避免哪次重构后遗漏这个隐含的条件，因此认为在这里增加个断言还是很有必要的
-	if len(inputOuts) < len(msgTx.TxIn) {
-		return errors.New("wrong param-outs-length")
-	}
-
-	// Create and execute script engine to verify script validity
-	// This is crucial in Bitcoin transaction verification to ensure transaction legality and security
-	// 这段代码的作用是创建和执行脚本引擎，用于验证指定的脚本是否有效
-	// 这在比特币交易的验证过程中非常重要，以确保交易的合法性和安全性
-	for idx := range msgTx.TxIn {
-		vm, err := txscript.NewEngine(inputOuts[idx].PkScript, msgTx, idx, txscript.StandardVerifyFlags, sigCache, sigHashes, inputOuts[idx].Value, prevOutFetcher)
-		if err != nil {
-			return errors.WithMessagef(err, "wrong new-vm-engine. index=%d", idx)
-		}
-		if err = vm.Execute(); err != nil {
-			return errors.WithMessagef(err, "wrong check-sign-vm-execute. index=%d", idx)
-		}
+	// All inputs are checked by the same logic as VerifySignReport, the first input not valid is returned
+	// 全部输入都使用和 VerifySignReport 相同的逻辑检查，返回第一个无效的输入
+	report, err := VerifySignReport(msgTx, inputOuts, prevOutFetcher, sigHashes)
+	if err != nil {
+		return err
 	}
-	return nil
+	return report.Err()
 }
 
 // newPrevOutsMap creates and fills previous outputs mapping
@@ -230,27 +277,27 @@ func SignP2PKH(signParam *SignParam, privKey *btcec.PrivateKey, compress bool) e
 func (param *BitcoinTxParams) CheckMsgTxParam(msgTx *wire.MsgTx, netParams *chaincfg.Params) error {
 	// 验证输入的长度是否匹配
 	if len(msgTx.TxIn) != len(param.VinList) {
-		return errors.Errorf("input count mismatch: got %d, expected %d", len(msgTx.TxIn), len(param.VinList))
+		return &InputMismatchError{Index: -1, Field: "count", Got: strconv.Itoa(len(msgTx.TxIn)), Expected: strconv.Itoa(len(param.VinList))}
 	}
 	// 验证每个输入的哈希和位置是否匹配
 	for idx, txVin := range msgTx.TxIn {
 		input := param.VinList[idx]
 		// 检查 UTXO 的 OutPoint 是否匹配
 		if txVin.PreviousOutPoint.Hash != input.OutPoint.Hash {
-			return errors.Errorf("input %d outpoint-hash mismatch: got %v, expected %v", idx, txVin.PreviousOutPoint.Hash, input.OutPoint.Hash)
+			return &InputMismatchError{Index: idx, Field: "outpoint-hash", Got: txVin.PreviousOutPoint.Hash.String(), Expected: input.OutPoint.Hash.String()}
 		}
 		// 检查在交易输出中的位置是否完全匹配
 		if txVin.PreviousOutPoint.Index != input.OutPoint.Index {
-			return errors.Errorf("input %d outpoint-index mismatch: got %v, expected %v", idx, txVin.PreviousOutPoint.Index, input.OutPoint.Index)
+			return &InputMismatchError{Index: idx, Field: "outpoint-index", Got: strconv.FormatUint(uint64(txVin.PreviousOutPoint.Index), 10), Expected: strconv.FormatUint(uint64(input.OutPoint.Index), 10)}
 		}
 		// 检查 vin 的 RBF 序号是否完全匹配
 		if seqNo := param.GetTxInputSequence(input); seqNo != txVin.Sequence {
-			return errors.Errorf("input %d tx-in-sequence mismatch: got %v, expected %v", idx, txVin.Sequence, seqNo)
+			return &InputMismatchError{Index: idx, Field: "tx-in-sequence", Got: strconv.FormatUint(uint64(txVin.Sequence), 10), Expected: strconv.FormatUint(uint64(seqNo), 10)}
 		}
 	}
 	// 验证输出数量是否匹配
 	if len(msgTx.TxOut) != len(param.OutList) {
-		return errors.Errorf("output count mismatch: got %d, expected %d", len(msgTx.TxOut), len(param.OutList))
+		return &OutputMismatchError{Index: -1, Field: "count", Got: strconv.Itoa(len(msgTx.TxOut)), Expected: strconv.Itoa(len(param.OutList))}
 	}
 	// 验证每个输出的地址和金额是否匹配
 	for idx, txVout := range msgTx.TxOut {
@@ -258,14 +305,14 @@ func (param *BitcoinTxParams) CheckMsgTxParam(msgTx *wire.MsgTx, netParams *chai
 		// 验证输出地址
 		pkScript, err := output.Target.GetPkScript(netParams)
 		if err != nil {
-			return errors.Errorf("cannot get pkScript of address %s: %v", output.Target.Address, err)
+			return errors.WithMessagef(err, "cannot get pkScript of address %s", output.Target.Address)
 		}
 		if !bytes.Equal(txVout.PkScript, pkScript) {
-			return errors.Errorf("output %d script mismatch: got %x, expected %x", idx, txVout.PkScript, pkScript)
+			return &OutputMismatchError{Index: idx, Field: "script", Got: hex.EncodeToString(txVout.PkScript), Expected: hex.EncodeToString(pkScript)}
 		}
 		// 验证输出金额
 		if txVout.Value != output.Amount {
-			return errors.Errorf("output %d amount mismatch: got %d, expected %d", idx, txVout.Value, output.Amount)
+			return &OutputMismatchError{Index: idx, Field: "amount", Got: strconv.FormatInt(txVout.Value, 10), Expected: strconv.FormatInt(output.Amount, 10)}
 		}
 	}
 	return nil
@@ -176,3 +176,55 @@ func TestSignDOGE(t *testing.T) {
 	//SendRawHexTx(txHex) //通过这个tx-hex就可以发交易，我已经发完交易，你可以在链上看到它
 	t.Log("success")
 }
+
+// TestSignDOGE_WitnessRejected validates witness inputs and outputs are rejected on Dogecoin params
+//
+// TestSignDOGE_WitnessRejected 验证狗狗币参数下见证输入和输出会被拒绝
+func TestSignDOGE_WitnessRejected(t *testing.T) {
+	const senderAddress = "nkgVWbNrUowCG4mkWSzA7HHUDe3XyL2NaC"
+	const witnessAddress = "doget1qknwmnkmgqcdqlmys5j72auslstyvlg0twvv68k" // same key as sender, decodes with doget HRP
+	const privateKeyHex = "5f397bc72377b75db7b008a9c3fcd71651bfb138d6fc2458bb0279b9cfc8442a"
+
+	netParams := dogecoin.TestNetParams
+	require.False(t, gobtcsign.IsSegwitSupported(&netParams))
+	require.False(t, gobtcsign.IsSegwitSupported(&dogecoin.RegressionNetParams))
+	require.True(t, gobtcsign.IsSegwitSupported(&chaincfg.TestNet3Params))
+
+	newParam := func(sender string, target string) *gobtcsign.BitcoinTxParams {
+		return &gobtcsign.BitcoinTxParams{
+			VinList: []gobtcsign.VinType{
+				{
+					OutPoint: *gobtcsign.MustNewOutPoint("57a3514865d3f4c5cbd49270204aaf4928c4c10651430dcd0cb79b80cda5ef0b", 0),
+					Sender:   *gobtcsign.NewAddressTuple(sender),
+					Amount:   6799372,
+					RBFInfo:  *gobtcsign.NewRBFNotUse(),
+				},
+			},
+			OutList: []gobtcsign.OutType{
+				{
+					Target: *gobtcsign.NewAddressTuple(target),
+					Amount: 6000000,
+				},
+			},
+			RBFInfo: *gobtcsign.NewRBFActive(),
+		}
+	}
+
+	t.Run("witness-output", func(t *testing.T) {
+		signParam, err := newParam(senderAddress, witnessAddress).CreateTxSignParams(&netParams)
+		require.NoError(t, err)
+		err = gobtcsign.Sign(senderAddress, privateKeyHex, signParam)
+		require.ErrorIs(t, err, gobtcsign.ErrUnsupportedAddressType)
+		require.ErrorContains(t, err, "dogecoin testnet")
+		t.Log(err)
+	})
+
+	t.Run("witness-input", func(t *testing.T) {
+		signParam, err := newParam(witnessAddress, senderAddress).CreateTxSignParams(&netParams)
+		require.NoError(t, err)
+		err = gobtcsign.Sign(witnessAddress, privateKeyHex, signParam)
+		require.ErrorIs(t, err, gobtcsign.ErrUnsupportedAddressType)
+		require.Empty(t, signParam.MsgTx.TxIn[0].Witness)
+		t.Log(err)
+	})
+}
@@ -0,0 +1,42 @@
+package gobtcsign
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/pkg/errors"
+)
+
+// NewSignetParams creates params of custom signet from block challenge script and seed hosts
+// Address prefixes are the same as testnet (tb, m/n, 2), only network magic is derived from the challenge,
+// so tb1 addresses decode on both networks and AddressTuple must be used with the signet params
+//
+// NewSignetParams 使用区块挑战脚本和种子节点创建自定义 signet 的参数
+// 地址前缀和测试网相同（tb、m/n、2），只有网络标识由挑战脚本得到，
+// 因此 tb1 地址在两个网络上都能解析，AddressTuple 需要和 signet 参数一起使用
+func NewSignetParams(challenge []byte, seeds []string) (*chaincfg.Params, error) {
+	if len(challenge) == 0 {
+		return nil, errors.New("wrong signet challenge is empty")
+	}
+	if _, err := txscript.DisasmString(challenge); err != nil {
+		return nil, errors.WithMessage(err, "wrong signet challenge script")
+	}
+	var dnsSeeds = make([]chaincfg.DNSSeed, 0, len(seeds))
+	for _, seed := range seeds {
+		dnsSeeds = append(dnsSeeds, chaincfg.DNSSeed{Host: seed, HasFiltering: false})
+	}
+	netParams := chaincfg.CustomSignetParams(challenge, dnsSeeds)
+	return &netParams, nil
+}
+
+// NewSignetParamsFromHex creates params of custom signet from hex of challenge script, as in -signetchallenge of bitcoind
+//
+// NewSignetParamsFromHex 使用挑战脚本的十六进制创建自定义 signet 的参数，格式和 bitcoind 的 -signetchallenge 相同
+func NewSignetParamsFromHex(challengeHex string, seeds []string) (*chaincfg.Params, error) {
+	challenge, err := hex.DecodeString(challengeHex)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong decode signet challenge hex")
+	}
+	return NewSignetParams(challenge, seeds)
+}
@@ -0,0 +1,169 @@
+package gobtcsign
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	signetPrivateKeyHex = "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"
+	signetP2WPKHAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+)
+
+// newPrivateSignetParams creates signet params with 1-of-1 multisig challenge of the fixture key
+// newPrivateSignetParams 使用固定密钥的 1-of-1 多签挑战脚本创建 signet 参数
+func newPrivateSignetParams(t *testing.T) *chaincfg.Params {
+	privKeyBytes, err := hex.DecodeString(signetPrivateKeyHex)
+	require.NoError(t, err)
+	_, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+
+	challenge, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).
+		AddData(pubKey.SerializeCompressed()).
+		AddOp(txscript.OP_1).
+		AddOp(txscript.OP_CHECKMULTISIG).
+		Script()
+	require.NoError(t, err)
+
+	netParams, err := NewSignetParams(challenge, []string{"seed.signet.example.com"})
+	require.NoError(t, err)
+	return netParams
+}
+
+// TestNewSignetParams validates default challenge gives default signet magic and custom challenge gives another magic
+//
+// TestNewSignetParams 验证默认挑战脚本得到默认 signet 的网络标识，自定义挑战脚本得到其它网络标识
+func TestNewSignetParams(t *testing.T) {
+	const defaultChallengeHex = "512103ad5e0edad18cb1f0fc0d28a3d4f1f3e445640337489abb10404f2d1e086be430210359ef5021964fe22d6f8e05b2463c9540ce96883fe3b278760f048f5189f2e6c452ae"
+
+	defaultParams, err := NewSignetParamsFromHex(defaultChallengeHex, nil)
+	require.NoError(t, err)
+	require.Equal(t, chaincfg.SigNetParams.Net, defaultParams.Net)
+
+	netParams := newPrivateSignetParams(t)
+	require.NotEqual(t, chaincfg.SigNetParams.Net, netParams.Net)
+	require.Equal(t, "tb", netParams.Bech32HRPSegwit)
+	require.Equal(t, chaincfg.TestNet3Params.PubKeyHashAddrID, netParams.PubKeyHashAddrID)
+	require.Equal(t, chaincfg.TestNet3Params.ScriptHashAddrID, netParams.ScriptHashAddrID)
+	require.Equal(t, "seed.signet.example.com", netParams.DNSSeeds[0].Host)
+
+	_, err = NewSignetParams(nil, nil)
+	require.Error(t, err)
+	_, err = NewSignetParamsFromHex("zz", nil)
+	require.Error(t, err)
+}
+
+// TestSignet_P2WPKH builds, signs and verifies P2WPKH spend on private signet
+// The tb1 address is the same string on testnet, the tuple resolves with signet params and stays consistent
+//
+// TestSignet_P2WPKH 在私有 signet 上构建、签名和验证 P2WPKH 花费
+// tb1 地址在测试网上是相同的字符串，元组使用 signet 参数解析并保持一致
+func TestSignet_P2WPKH(t *testing.T) {
+	netParams := newPrivateSignetParams(t)
+	chain := NewBitcoinChain("bitcoin-signet-private", netParams)
+
+	param := &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", 0),
+				Sender:   *NewAddressTuple(signetP2WPKHAddress),
+				Amount:   100000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"),
+				Amount: 60000,
+			},
+			{
+				Target: *NewAddressTuple(signetP2WPKHAddress),
+				Amount: 39000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+	pkScript, err := param.VinList[0].Sender.GetPkScript(netParams)
+	require.NoError(t, err)
+	param.VinList[0].Sender.PkScript = pkScript
+	require.NoError(t, param.VinList[0].Sender.VerifyMatch(netParams))
+
+	signParam, err := chain.CreateTxSignParams(param)
+	require.NoError(t, err)
+	require.NoError(t, chain.Sign(signetP2WPKHAddress, signetPrivateKeyHex, signParam))
+
+	msgTx := signParam.MsgTx
+	require.NoError(t, VerifySignV2(msgTx, param.GetInputList(), netParams))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, netParams))
+	require.NotEmpty(t, msgTx.TxIn[0].Witness)
+}
+
+// TestSignet_P2TR builds, signs and verifies BIP86 key path P2TR spend on private signet
+//
+// TestSignet_P2TR 在私有 signet 上构建、签名和验证 BIP86 密钥路径的 P2TR 花费
+func TestSignet_P2TR(t *testing.T) {
+	netParams := newPrivateSignetParams(t)
+
+	privKeyBytes, err := hex.DecodeString(signetPrivateKeyHex)
+	require.NoError(t, err)
+	privKey, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+
+	taprootKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+	taprootAddress, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(taprootKey), netParams)
+	require.NoError(t, err)
+	senderAddress := taprootAddress.EncodeAddress()
+
+	param := &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", 1),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   50000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+			{
+				OutPoint: *MustNewOutPoint("e1f05d4ef10d6d4245839364c637cc37f429784883761668978645c67e723919", 2),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   30000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple(signetP2WPKHAddress),
+				Amount: 70000,
+			},
+			{
+				Target: *NewAddressTuple(senderAddress),
+				Amount: 9000,
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+
+	signParam, err := NewBitcoinChain("bitcoin-signet-private", netParams).CreateTxSignParams(param)
+	require.NoError(t, err)
+
+	// Taproot signatures commit to prevouts of all inputs, so sign with fetcher of all of them
+	// Taproot 签名包含全部输入的前置输出，因此使用包含全部前置输出的提取器签名
+	msgTx := signParam.MsgTx
+	prevOutFetcher, err := NewPrevOutFetcherFromInputOuts(msgTx, signParam.InputOuts)
+	require.NoError(t, err)
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+	for idx := range msgTx.TxIn {
+		witness, err := txscript.TaprootWitnessSignature(msgTx, sigHashes, idx, signParam.InputOuts[idx].Value, signParam.InputOuts[idx].PkScript, txscript.SigHashDefault, privKey)
+		require.NoError(t, err)
+		msgTx.TxIn[idx].Witness = witness
+	}
+
+	require.NoError(t, NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).Verify(msgTx))
+	require.NoError(t, VerifySignV2(msgTx, param.GetInputList(), netParams))
+	require.NoError(t, param.CheckMsgTxParam(msgTx, netParams))
+}
@@ -42,7 +42,7 @@ func (one *AddressTuple) GetPkScript(netParams *chaincfg.Params) ([]byte, error)
 			return nil, errors.WithMessage(err, "wrong-address")
 		}
 		if !bytes.Equal(one.PkScript, pkScript) {
-			return nil, errors.New("address-pk-script-mismatch")
+			return nil, &ScriptMismatchError{Address: one.Address, Got: one.PkScript, Expected: pkScript}
 		}
 		return pkScript, nil
 	}
@@ -52,7 +52,7 @@ func (one *AddressTuple) GetPkScript(netParams *chaincfg.Params) ([]byte, error)
 	if one.Address != "" {
 		return GetAddressPkScript(one.Address, netParams) //这里不用做缓存避免增加复杂度
 	}
-	return nil, errors.New("no-pk-script-no-address")
+	return nil, ErrNoPkScriptNoAddress
 }
 
 func (one *AddressTuple) VerifyMatch(netParams *chaincfg.Params) error {
@@ -62,7 +62,7 @@ func (one *AddressTuple) VerifyMatch(netParams *chaincfg.Params) error {
 			return errors.WithMessage(err, "wrong-address")
 		}
 		if !bytes.Equal(one.PkScript, pkScript) {
-			return errors.New("address-pk-script-mismatch")
+			return &ScriptMismatchError{Address: one.Address, Got: one.PkScript, Expected: pkScript}
 		}
 	}
 	return nil
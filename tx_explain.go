@@ -0,0 +1,321 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// TxExplainConfig configures ExplainTx
+// UtxoFrom is optional, without it the input amounts, senders and fee stay unknown
+//
+// TxExplainConfig 是 ExplainTx 的配置
+// UtxoFrom 是可选的，没有它时输入的数量、发送者和费用都是未知的
+type TxExplainConfig struct {
+	NetParams     *chaincfg.Params       // Network to encode addresses // 编码地址所用的网络参数
+	UtxoFrom      GetUtxoFromInterfaceV2 // Prevout source, nil means unknown // 前置输出数据源，为 nil 表示未知
+	DustLimit     *DustLimit             // Dust rule, use dogecoin.NewDogeDustLimit for Dogecoin // 灰尘规则，狗狗币使用 dogecoin.NewDogeDustLimit
+	RelayFeePerKb btcutil.Amount         // Relay fee used by dust rule // 灰尘规则所用的转发费率
+}
+
+// NewTxExplainConfig creates config with Bitcoin dust rule and default relay fee 1000 sat/kvB
+//
+// NewTxExplainConfig 创建使用比特币灰尘规则和默认转发费率 1000 sat/kvB 的配置
+func NewTxExplainConfig(netParams *chaincfg.Params, utxoFrom GetUtxoFromInterfaceV2) *TxExplainConfig {
+	return &TxExplainConfig{
+		NetParams:     netParams,
+		UtxoFrom:      utxoFrom,
+		DustLimit:     NewDustLimit(),
+		RelayFeePerKb: 1000,
+	}
+}
+
+// TxExplain represents human readable explanation of transaction
+//
+// TxExplain 代表交易的可读解释
+type TxExplain struct {
+	Txid            string          `json:"txid"`               // Tx hash // 交易哈希
+	Wtxid           string          `json:"wtxid"`              // Witness tx hash // 带见证的交易哈希
+	Version         int32           `json:"version"`            // Tx version // 交易版本
+	LockTime        uint32          `json:"locktime"`           // Raw lock time // 原始锁定时间
+	LockTimeMeaning string          `json:"locktime_meaning"`   // Lock time semantics // 锁定时间的含义
+	RBF             bool            `json:"rbf"`                // Signals replace-by-fee // 声明可以通过 RBF 替换
+	Size            int             `json:"size"`               // Serialized size in bytes // 序列化后的字节数
+	VSize           int             `json:"vsize"`              // Virtual size // 虚拟大小
+	Weight          int             `json:"weight"`             // Weight units // 重量单位
+	Inputs          []*TxInExplain  `json:"inputs"`             // Inputs // 输入
+	Outputs         []*TxOutExplain `json:"outputs"`            // Outputs // 输出
+	PrevOutsKnown   bool            `json:"prevouts_known"`     // Fields below are set // 以下字段已设置
+	InputAmount     int64           `json:"input_amount"`       // Sum of inputs // 输入的总额
+	OutputAmount    int64           `json:"output_amount"`      // Sum of outputs // 输出的总额
+	Fee             int64           `json:"fee"`                // Inputs minus outputs // 输入减去输出
+	FeeRatePerKvb   int64           `json:"fee_rate_per_kvb"`   // Fee per 1000 vbytes // 每 1000 虚拟字节的费用
+	FeeRateSatPerVb float64         `json:"fee_rate_sat_vb"`    // Fee per vbyte // 每虚拟字节的费用
+	Warnings        []string        `json:"warnings,omitempty"` // Things worth a second look // 值得再看一眼的地方
+}
+
+// TxInExplain represents one input in TxExplain
+//
+// TxInExplain 代表 TxExplain 中的单个输入
+type TxInExplain struct {
+	Index           int    `json:"index"`                 // Input index // 输入的序号
+	OutPoint        string `json:"outpoint"`              // Spent utxo as txid:vout // 花费的 utxo，格式是 txid:vout
+	Sequence        uint32 `json:"sequence"`              // Raw sequence // 原始序号
+	SequenceMeaning string `json:"sequence_meaning"`      // Sequence semantics // 序号的含义
+	ScriptType      string `json:"script_type,omitempty"` // Spent script class, empty when unknown // 花费的脚本类型，未知时为空
+	Address         string `json:"address,omitempty"`     // Sender address, empty when unknown // 发送者地址，未知时为空
+	Amount          int64  `json:"amount"`                // Spent amount, 0 when unknown // 花费的数量，未知时为 0
+}
+
+// TxOutExplain represents one output in TxExplain
+//
+// TxOutExplain 代表 TxExplain 中的单个输出
+type TxOutExplain struct {
+	Index      int    `json:"index"`             // Output index // 输出的序号
+	ScriptType string `json:"script_type"`       // Script class // 脚本类型
+	Address    string `json:"address,omitempty"` // Receiver address, empty when none // 接收者地址，没有时为空
+	Amount     int64  `json:"amount"`            // Amount in satoshis // 聪的数量
+	Dust       bool   `json:"dust"`              // Below dust limit // 低于灰尘限制
+}
+
+// ExplainTxHex decodes tx hex and explains it
+//
+// ExplainTxHex 解析交易的十六进制并解释它
+func ExplainTxHex(ctx context.Context, txHex string, config *TxExplainConfig) (*TxExplain, error) {
+	msgTx, err := NewMsgTxFromHex(strings.TrimSpace(txHex))
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong new-msg-tx-from-hex")
+	}
+	return ExplainTx(ctx, msgTx, config)
+}
+
+// ExplainTx explains tx, fetching prevouts with config.UtxoFrom when set
+// Nil config means Bitcoin mainnet without prevouts, config with nil NetParams is rejected
+//
+// ExplainTx 解释交易，当设置了 config.UtxoFrom 时会获取前置输出
+// config 为 nil 时表示比特币主网且没有前置输出，NetParams 为 nil 的 config 会被拒绝
+func ExplainTx(ctx context.Context, msgTx *wire.MsgTx, config *TxExplainConfig) (*TxExplain, error) {
+	if config == nil {
+		config = NewTxExplainConfig(&chaincfg.MainNetParams, nil)
+	}
+	if config.NetParams == nil {
+		return nil, errors.New("wrong tx-explain-config without net-params")
+	}
+	size := msgTx.SerializeSize()
+	weight := msgTx.SerializeSizeStripped()*3 + size
+	vSize := (weight + 3) / 4
+
+	var res = &TxExplain{
+		Txid:            msgTx.TxHash().String(),
+		Wtxid:           msgTx.WitnessHash().String(),
+		Version:         msgTx.Version,
+		LockTime:        msgTx.LockTime,
+		LockTimeMeaning: explainLockTime(msgTx),
+		Size:            size,
+		VSize:           vSize,
+		Weight:          weight,
+		Inputs:          make([]*TxInExplain, 0, len(msgTx.TxIn)),
+		Outputs:         make([]*TxOutExplain, 0, len(msgTx.TxOut)),
+	}
+
+	var utxoFroms []*SenderAmountUtxo
+	if config.UtxoFrom != nil {
+		var utxos = make([]wire.OutPoint, 0, len(msgTx.TxIn))
+		for _, txIn := range msgTx.TxIn {
+			utxos = append(utxos, txIn.PreviousOutPoint)
+		}
+		results, err := config.UtxoFrom.GetUtxoFromList(ctx, utxos)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong get-utxo-from")
+		}
+		if len(results) != len(utxos) {
+			return nil, errors.Errorf("wrong utxo-from count: got %d, expected %d", len(results), len(utxos))
+		}
+		utxoFroms = results
+		res.PrevOutsKnown = true
+	}
+
+	for idx, txIn := range msgTx.TxIn {
+		one := &TxInExplain{
+			Index:           idx,
+			OutPoint:        txIn.PreviousOutPoint.String(),
+			Sequence:        txIn.Sequence,
+			SequenceMeaning: explainSequence(msgTx.Version, txIn.Sequence),
+		}
+		if txIn.Sequence <= wire.MaxTxInSequenceNum-2 {
+			res.RBF = true
+		}
+		if utxoFroms != nil {
+			utxoFrom := utxoFroms[idx]
+			pkScript, err := utxoFrom.sender.GetPkScript(config.NetParams)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "wrong sender.address->pk-script. index=%d", idx)
+			}
+			one.ScriptType = txscript.GetScriptClass(pkScript).String()
+			one.Address = utxoFrom.sender.Address
+			if one.Address == "" {
+				one.Address = extractExplainAddress(pkScript, config.NetParams)
+			}
+			one.Amount = utxoFrom.amount
+			res.InputAmount += utxoFrom.amount
+		}
+		res.Inputs = append(res.Inputs, one)
+	}
+
+	for idx, txOut := range msgTx.TxOut {
+		scriptClass := txscript.GetScriptClass(txOut.PkScript)
+		one := &TxOutExplain{
+			Index:      idx,
+			ScriptType: scriptClass.String(),
+			Address:    extractExplainAddress(txOut.PkScript, config.NetParams),
+			Amount:     txOut.Value,
+		}
+		// OP_RETURN outputs are unspendable on purpose, they are not dust
+		// OP_RETURN 输出是有意不可花费的，不算灰尘
+		if scriptClass != txscript.NullDataTy && config.DustLimit != nil {
+			one.Dust = config.DustLimit.IsDustOutput(txOut, config.RelayFeePerKb)
+		}
+		if one.Dust {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("output %d amount=%d is dust", idx, txOut.Value))
+		}
+		res.OutputAmount += txOut.Value
+		res.Outputs = append(res.Outputs, one)
+	}
+
+	if res.PrevOutsKnown {
+		res.Fee = res.InputAmount - res.OutputAmount
+		res.FeeRatePerKvb = res.Fee * 1000 / int64(vSize)
+		res.FeeRateSatPerVb = float64(res.Fee) / float64(vSize)
+		if res.Fee < 0 {
+			res.Warnings = append(res.Warnings, "outputs exceed inputs")
+		}
+	}
+	if !isMsgTxSigned(msgTx) {
+		res.Warnings = append(res.Warnings, "tx is not fully signed, sizes grow after signing")
+	}
+	return res, nil
+}
+
+// isMsgTxSigned tells whether every input carries a signature script or witness
+// isMsgTxSigned 判断是否每个输入都带有签名脚本或见证
+func isMsgTxSigned(msgTx *wire.MsgTx) bool {
+	for _, txIn := range msgTx.TxIn {
+		if len(txIn.SignatureScript) == 0 && len(txIn.Witness) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ToJSON returns indented JSON of the explanation
+//
+// ToJSON 返回解释的缩进 JSON
+func (e *TxExplain) ToJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong marshal-explain")
+	}
+	return data, nil
+}
+
+// ToText returns plain text of the explanation, easy to paste into chat
+//
+// ToText 返回解释的纯文本，便于粘贴到聊天工具里
+func (e *TxExplain) ToText() string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "txid:     %s\n", e.Txid)
+	if e.Wtxid != e.Txid {
+		_, _ = fmt.Fprintf(&sb, "wtxid:    %s\n", e.Wtxid)
+	}
+	_, _ = fmt.Fprintf(&sb, "version:  %d\n", e.Version)
+	_, _ = fmt.Fprintf(&sb, "locktime: %d (%s)\n", e.LockTime, e.LockTimeMeaning)
+	_, _ = fmt.Fprintf(&sb, "rbf:      %v\n", e.RBF)
+	_, _ = fmt.Fprintf(&sb, "size:     %d bytes, %d vbytes, %d weight\n", e.Size, e.VSize, e.Weight)
+
+	_, _ = fmt.Fprintf(&sb, "inputs (%d):\n", len(e.Inputs))
+	for _, one := range e.Inputs {
+		_, _ = fmt.Fprintf(&sb, "  #%d %s\n", one.Index, one.OutPoint)
+		_, _ = fmt.Fprintf(&sb, "     sequence 0x%08x (%s)\n", one.Sequence, one.SequenceMeaning)
+		if one.ScriptType != "" {
+			_, _ = fmt.Fprintf(&sb, "     %s %s amount=%d\n", one.ScriptType, one.Address, one.Amount)
+		}
+	}
+	_, _ = fmt.Fprintf(&sb, "outputs (%d):\n", len(e.Outputs))
+	for _, one := range e.Outputs {
+		var dust string
+		if one.Dust {
+			dust = " DUST"
+		}
+		_, _ = fmt.Fprintf(&sb, "  #%d %s %s amount=%d%s\n", one.Index, one.ScriptType, one.Address, one.Amount, dust)
+	}
+	if e.PrevOutsKnown {
+		_, _ = fmt.Fprintf(&sb, "fee:      %d (%.2f sat/vB, %d sat/kvB)\n", e.Fee, e.FeeRateSatPerVb, e.FeeRatePerKvb)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "fee:      unknown (prevouts not fetched)\n")
+	}
+	for _, warning := range e.Warnings {
+		_, _ = fmt.Fprintf(&sb, "warning:  %s\n", warning)
+	}
+	return sb.String()
+}
+
+// explainLockTime describes lock time, which only takes effect when some input is not final
+// explainLockTime 描述锁定时间，只有当存在非 final 的输入时锁定时间才生效
+func explainLockTime(msgTx *wire.MsgTx) string {
+	if msgTx.LockTime == 0 {
+		return "no lock time"
+	}
+	var meaning string
+	if msgTx.LockTime < txscript.LockTimeThreshold {
+		meaning = fmt.Sprintf("not valid before block height %d", msgTx.LockTime)
+	} else {
+		meaning = fmt.Sprintf("not valid before %s", time.Unix(int64(msgTx.LockTime), 0).UTC().Format(time.RFC3339))
+	}
+	for _, txIn := range msgTx.TxIn {
+		if txIn.Sequence != wire.MaxTxInSequenceNum {
+			return meaning
+		}
+	}
+	return meaning + ", disabled since all inputs are final"
+}
+
+// explainSequence describes sequence: final, lock time only, RBF and BIP68 relative lock
+// explainSequence 描述序号：final、仅启用锁定时间、RBF 以及 BIP68 相对锁定
+func explainSequence(version int32, sequence uint32) string {
+	switch sequence {
+	case wire.MaxTxInSequenceNum:
+		return "final"
+	case wire.MaxTxInSequenceNum - 1:
+		return "lock time enabled, no rbf"
+	}
+	var parts = []string{"rbf"}
+	// BIP68 relative lock needs tx version 2 and the disable flag unset
+	// BIP68 相对锁定需要交易版本为 2 并且未设置禁用标志
+	if version >= 2 && sequence&wire.SequenceLockTimeDisabled == 0 {
+		value := sequence & wire.SequenceLockTimeMask
+		if sequence&wire.SequenceLockTimeIsSeconds != 0 {
+			parts = append(parts, fmt.Sprintf("relative lock %d seconds", int64(value)<<wire.SequenceLockTimeGranularity))
+		} else {
+			parts = append(parts, fmt.Sprintf("relative lock %d blocks", value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// extractExplainAddress returns the single address of pkScript, empty when there is none or many
+// extractExplainAddress 返回公钥脚本的唯一地址，没有或有多个时返回空
+func extractExplainAddress(pkScript []byte, netParams *chaincfg.Params) string {
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, netParams)
+	if err != nil || len(addresses) != 1 {
+		return ""
+	}
+	return addresses[0].EncodeAddress()
+}
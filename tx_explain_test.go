@@ -0,0 +1,91 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainTx(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	utxoFrom := NewSenderAmountUtxoCache(map[wire.OutPoint]*SenderAmountUtxo{
+		msgTx.TxIn[0].PreviousOutPoint: NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), 13089),
+	})
+
+	res, err := ExplainTx(context.Background(), msgTx, NewTxExplainConfig(&chaincfg.TestNet3Params, utxoFrom))
+	require.NoError(t, err)
+	t.Log(res.ToText())
+
+	require.Equal(t, "e587e4f65a7fa5dbba6bede6b000e8ece097671bb348db3de0e507c8b36469ad", res.Txid)
+	require.NotEqual(t, res.Txid, res.Wtxid)
+	require.Equal(t, "no lock time", res.LockTimeMeaning)
+	require.True(t, res.RBF)
+	require.Equal(t, 141, res.VSize)
+	require.Equal(t, 562, res.Weight)
+
+	require.Equal(t, "rbf", res.Inputs[0].SequenceMeaning)
+	require.Equal(t, "witness_v0_keyhash", res.Inputs[0].ScriptType)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", res.Inputs[0].Address)
+
+	require.Equal(t, "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", res.Outputs[0].Address)
+	require.Equal(t, int64(1234), res.Outputs[0].Amount)
+	require.False(t, res.Outputs[0].Dust)
+
+	require.True(t, res.PrevOutsKnown)
+	require.Equal(t, int64(11111), res.Fee)
+	require.Equal(t, int64(11111*1000/141), res.FeeRatePerKvb)
+	require.Empty(t, res.Warnings)
+
+	data, err := res.ToJSON()
+	require.NoError(t, err)
+	var decoded TxExplain
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, res.Fee, decoded.Fee)
+	require.Equal(t, res.Outputs[1].Address, decoded.Outputs[1].Address)
+}
+
+func TestExplainTxHex_WithoutUtxoFrom(t *testing.T) {
+	txHex, err := CvtMsgTxToHex(caseBroadcastMsgTx(t))
+	require.NoError(t, err)
+
+	res, err := ExplainTxHex(context.Background(), txHex+"\n", NewTxExplainConfig(&chaincfg.TestNet3Params, nil))
+	require.NoError(t, err)
+	require.False(t, res.PrevOutsKnown)
+	require.Empty(t, res.Inputs[0].ScriptType)
+	require.Contains(t, res.ToText(), "fee:      unknown")
+}
+
+func TestExplainTx_NilConfig(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+
+	res, err := ExplainTx(context.Background(), msgTx, nil)
+	require.NoError(t, err)
+	require.False(t, res.PrevOutsKnown)
+	require.Equal(t, GetTxHash(msgTx), res.Txid)
+
+	_, err = ExplainTx(context.Background(), msgTx, &TxExplainConfig{})
+	require.Error(t, err)
+}
+
+func TestExplainSequence(t *testing.T) {
+	require.Equal(t, "final", explainSequence(2, wire.MaxTxInSequenceNum))
+	require.Equal(t, "lock time enabled, no rbf", explainSequence(2, wire.MaxTxInSequenceNum-1))
+	require.Equal(t, "rbf", explainSequence(1, 10))
+	require.Equal(t, "rbf, relative lock 10 blocks", explainSequence(2, 10))
+	require.Equal(t, "rbf, relative lock 5120 seconds", explainSequence(2, wire.SequenceLockTimeIsSeconds|10))
+}
+
+func TestExplainLockTime(t *testing.T) {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0), nil, nil))
+	msgTx.LockTime = 800000
+	require.Equal(t, "not valid before block height 800000, disabled since all inputs are final", explainLockTime(msgTx))
+
+	msgTx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+	msgTx.LockTime = 1700000000
+	require.Equal(t, "not valid before 2023-11-14T22:13:20Z", explainLockTime(msgTx))
+}
@@ -0,0 +1,262 @@
+package gobtcsign
+
+import (
+	"context"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// TxWatchStatus represents what chain backend knows about transaction at the moment
+//
+// TxWatchStatus 代表链后端此刻对交易的了解
+type TxWatchStatus struct {
+	InMempool    bool   // Tx is in mempool // 交易在内存池中
+	BlockHeight  int64  // Height of block containing tx, 0 when not in block // 包含交易的区块高度，不在区块中时为 0
+	TipHeight    int64  // Current chain tip height // 当前链顶高度
+	ConflictTxid string // Another tx spending one of its inputs, empty when none // 花费其某个输入的其它交易，没有时为空
+}
+
+// TxWatchBackend defines interface to poll transaction status, implemented by EsploraClient
+//
+// TxWatchBackend 定义轮询交易状态的接口，由 EsploraClient 实现
+type TxWatchBackend interface {
+	GetTxWatchStatus(ctx context.Context, msgTx *wire.MsgTx) (*TxWatchStatus, error)
+}
+
+// TxEventType represents kind of TxEvent
+//
+// TxEventType 代表 TxEvent 的类型
+type TxEventType string
+
+const (
+	TxEventSeenInMempool TxEventType = "seen-in-mempool" // Tx appears in mempool // 交易出现在内存池中
+	TxEventConfirmed     TxEventType = "confirmed"       // Tx is in block, sent at each new depth // 交易已上链，每次深度增加时发送
+	TxEventConflicted    TxEventType = "conflicted"      // Input spent by another tx, watching stops // 输入被其它交易花费，停止监听
+	TxEventEvicted       TxEventType = "evicted"         // Tx left mempool without confirming // 交易未确认就离开了内存池
+	TxEventRebroadcast   TxEventType = "rebroadcast"     // Tx was sent again // 交易被重新发送
+	TxEventBumped        TxEventType = "bumped"          // Replacement tx was sent, it is watched together with earlier txs // 替换交易已发送，与之前的交易一起被监听
+	TxEventError         TxEventType = "error"           // Polling or sending failed, watching goes on // 轮询或发送失败，继续监听
+)
+
+// TxEvent represents one change of watched transaction
+//
+// TxEvent 代表被监听交易的一次变化
+type TxEvent struct {
+	Type         TxEventType // Event kind // 事件类型
+	Txid         string      // Watched txid, with TxEventConfirmed the txid mined // 被监听的交易哈希，在 TxEventConfirmed 时是上链的交易哈希
+	Depth        int64       // Confirmations, set with TxEventConfirmed // 确认数，在 TxEventConfirmed 时设置
+	BlockHeight  int64       // Block height, set with TxEventConfirmed // 区块高度，在 TxEventConfirmed 时设置
+	ConflictTxid string      // Set with TxEventConflicted // 在 TxEventConflicted 时设置
+	NewTxid      string      // Replacement txid, set with TxEventBumped // 替换交易的哈希，在 TxEventBumped 时设置
+	Err          error       // Set with TxEventError // 在 TxEventError 时设置
+}
+
+// TxWatcherConfig configures WatchTx
+// Rebroadcast and bump are disabled when Broadcaster is nil or the durations are zero
+//
+// TxWatcherConfig 是 WatchTx 的配置
+// 当 Broadcaster 为 nil 或时长为零时，不会重新广播也不会提高费用
+type TxWatcherConfig struct {
+	PollInterval     time.Duration                                                     // Time between polls // 两次轮询之间的间隔
+	ConfirmDepth     int64                                                             // Stop after this many confirmations, at least 1 // 达到该确认数后停止，至少为 1
+	Broadcaster      Broadcaster                                                       // Sends txs again // 用于再次发送交易
+	RebroadcastAfter time.Duration                                                     // Rebroadcast when not in mempool for this long // 不在内存池中超过该时长时重新广播
+	BumpAfter        time.Duration                                                     // Call Bump when unconfirmed for this long // 未确认超过该时长时调用 Bump
+	Bump             func(ctx context.Context, msgTx *wire.MsgTx) (*wire.MsgTx, error) // Builds RBF replacement with higher fee // 构造费用更高的 RBF 替换交易
+}
+
+// NewTxWatcherConfig creates config polling every pollInterval until confirmDepth confirmations
+//
+// NewTxWatcherConfig 创建每隔 pollInterval 轮询一次、直到 confirmDepth 个确认的配置
+func NewTxWatcherConfig(pollInterval time.Duration, confirmDepth int64) *TxWatcherConfig {
+	return &TxWatcherConfig{
+		PollInterval: pollInterval,
+		ConfirmDepth: confirmDepth,
+	}
+}
+
+// WatchTx polls backend in background and sends events of the tx over the returned channel
+// The channel is closed when tx reaches ConfirmDepth, is conflicted, or ctx is done
+// After a bump the original tx and every replacement stay watched, since any of them may be mined
+// Returns error without starting when PollInterval is not positive
+//
+// WatchTx 在后台轮询后端，并通过返回的通道发送交易的事件
+// 当交易达到 ConfirmDepth、发生冲突或 ctx 结束时关闭通道
+// 提高费用之后，原始交易和每个替换交易都会继续被监听，因为它们中的任何一个都可能上链
+// 当 PollInterval 不是正数时返回错误且不会启动
+func WatchTx(ctx context.Context, backend TxWatchBackend, msgTx *wire.MsgTx, config *TxWatcherConfig) (<-chan *TxEvent, error) {
+	if config == nil || config.PollInterval <= 0 {
+		return nil, errors.New("wrong tx-watcher-config, poll-interval must be positive")
+	}
+	events := make(chan *TxEvent, 16)
+	watcher := &txWatcher{
+		backend: backend,
+		config:  config,
+		events:  events,
+		msgTxs:  []*wire.MsgTx{msgTx},
+	}
+	go func() {
+		defer close(events)
+		watcher.run(ctx)
+	}()
+	return events, nil
+}
+
+type txWatcher struct {
+	backend    TxWatchBackend
+	config     *TxWatcherConfig
+	events     chan<- *TxEvent
+	msgTxs     []*wire.MsgTx // Original tx and its replacements, the last is the current one // 原始交易及其替换交易，最后一个是当前交易
+	seen       bool          // In mempool at last poll // 上次轮询时在内存池中
+	depth      int64         // Confirmations at last poll // 上次轮询时的确认数
+	activeAt   time.Time     // Last time in mempool or sent // 最后一次在内存池中或被发送的时间
+	bumpFromAt time.Time     // Start time of bump timeout // 提高费用超时的起始时间
+}
+
+func (w *txWatcher) run(ctx context.Context) {
+	w.activeAt = time.Now()
+	w.bumpFromAt = time.Now()
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if done := w.poll(ctx); done {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks status once and sends events, returns true when watching is over
+// poll 检查一次状态并发送事件，监听结束时返回 true
+func (w *txWatcher) poll(ctx context.Context) bool {
+	msgTx := w.msgTxs[len(w.msgTxs)-1]
+	txid := GetTxHash(msgTx)
+
+	var status *TxWatchStatus
+	var conflictTxid string
+	// Newest first, the replacement is the one most likely mined
+	// 从最新的开始，替换交易最有可能上链
+	for idx := len(w.msgTxs) - 1; idx >= 0; idx-- {
+		one, err := w.backend.GetTxWatchStatus(ctx, w.msgTxs[idx])
+		if err != nil {
+			if ctx.Err() != nil {
+				return true
+			}
+			return !w.emit(ctx, &TxEvent{Type: TxEventError, Txid: GetTxHash(w.msgTxs[idx]), Err: errors.WithMessage(err, "wrong get-tx-watch-status")})
+		}
+		if one.BlockHeight > 0 {
+			return w.confirmed(ctx, GetTxHash(w.msgTxs[idx]), one)
+		}
+		// Our txs conflict with each other after a bump, only others' txs count as conflict
+		// 提高费用之后我们自己的交易之间互相冲突，只有别人的交易才算冲突
+		if conflictTxid == "" && one.ConflictTxid != "" && !w.isOwnTxid(one.ConflictTxid) {
+			conflictTxid = one.ConflictTxid
+		}
+		if status == nil {
+			status = one
+		}
+	}
+	now := time.Now()
+
+	if conflictTxid != "" {
+		w.emit(ctx, &TxEvent{Type: TxEventConflicted, Txid: txid, ConflictTxid: conflictTxid})
+		return true
+	}
+	if status.InMempool {
+		w.depth = 0
+		w.activeAt = now
+		if !w.seen {
+			w.seen = true
+			if !w.emit(ctx, &TxEvent{Type: TxEventSeenInMempool, Txid: txid}) {
+				return true
+			}
+		}
+	} else if w.seen || w.depth > 0 {
+		// Dropped from mempool, or from block in a reorg
+		// 从内存池中掉出，或在链重组时从区块中掉出
+		w.seen = false
+		w.depth = 0
+		if !w.emit(ctx, &TxEvent{Type: TxEventEvicted, Txid: txid}) {
+			return true
+		}
+	}
+
+	if w.config.Broadcaster == nil {
+		return false
+	}
+	if w.config.Bump != nil && w.config.BumpAfter > 0 && now.Sub(w.bumpFromAt) >= w.config.BumpAfter {
+		return !w.bump(ctx, now)
+	}
+	if !status.InMempool && w.config.RebroadcastAfter > 0 && now.Sub(w.activeAt) >= w.config.RebroadcastAfter {
+		w.activeAt = now
+		if _, err := w.config.Broadcaster.Broadcast(ctx, msgTx); err != nil {
+			return !w.emit(ctx, &TxEvent{Type: TxEventError, Txid: txid, Err: errors.WithMessage(err, "wrong rebroadcast")})
+		}
+		return !w.emit(ctx, &TxEvent{Type: TxEventRebroadcast, Txid: txid})
+	}
+	return false
+}
+
+// confirmed sends TxEventConfirmed of the mined tx at each new depth, returns true when watching is over
+// confirmed 在每次深度增加时发送上链交易的 TxEventConfirmed，监听结束时返回 true
+func (w *txWatcher) confirmed(ctx context.Context, txid string, status *TxWatchStatus) bool {
+	w.seen = false
+	depth := status.TipHeight - status.BlockHeight + 1
+	if depth > w.depth {
+		w.depth = depth
+		if !w.emit(ctx, &TxEvent{Type: TxEventConfirmed, Txid: txid, Depth: depth, BlockHeight: status.BlockHeight}) {
+			return true
+		}
+	}
+	return depth >= max(w.config.ConfirmDepth, 1)
+}
+
+// isOwnTxid checks whether txid is the original tx or one of its replacements
+// isOwnTxid 检查交易哈希是否是原始交易或其替换交易之一
+func (w *txWatcher) isOwnTxid(txid string) bool {
+	for _, msgTx := range w.msgTxs {
+		if GetTxHash(msgTx) == txid {
+			return true
+		}
+	}
+	return false
+}
+
+// bump sends replacement tx and watches it together with earlier txs, returns false when ctx is done
+// bump 发送替换交易并与之前的交易一起监听，ctx 结束时返回 false
+func (w *txWatcher) bump(ctx context.Context, now time.Time) bool {
+	msgTx := w.msgTxs[len(w.msgTxs)-1]
+	txid := GetTxHash(msgTx)
+	w.bumpFromAt = now
+
+	newMsgTx, err := w.config.Bump(ctx, msgTx)
+	if err != nil {
+		return w.emit(ctx, &TxEvent{Type: TxEventError, Txid: txid, Err: errors.WithMessage(err, "wrong bump")})
+	}
+	newTxid, err := w.config.Broadcaster.Broadcast(ctx, newMsgTx)
+	if err != nil {
+		return w.emit(ctx, &TxEvent{Type: TxEventError, Txid: txid, Err: errors.WithMessage(err, "wrong broadcast-bumped-tx")})
+	}
+	w.msgTxs = append(w.msgTxs, newMsgTx)
+	w.seen = false
+	w.activeAt = now
+	return w.emit(ctx, &TxEvent{Type: TxEventBumped, Txid: txid, NewTxid: newTxid})
+}
+
+// emit sends event, returns false when ctx is done before the receiver takes it
+// emit 发送事件，当接收方取走之前 ctx 就结束时返回 false
+func (w *txWatcher) emit(ctx context.Context, event *TxEvent) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case w.events <- event:
+		return true
+	}
+}
@@ -0,0 +1,180 @@
+package gobtcsign
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedWatchBackend returns statuses of txids in order, the last one repeats
+type scriptedWatchBackend struct {
+	mutex    sync.Mutex
+	statuses map[string][]*TxWatchStatus
+}
+
+func (b *scriptedWatchBackend) GetTxWatchStatus(ctx context.Context, msgTx *wire.MsgTx) (*TxWatchStatus, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	statuses := b.statuses[GetTxHash(msgTx)]
+	if len(statuses) == 0 {
+		return nil, errors.New("no status")
+	}
+	status := statuses[0]
+	if len(statuses) > 1 {
+		b.statuses[GetTxHash(msgTx)] = statuses[1:]
+	}
+	return status, nil
+}
+
+func collectTxEvents(t *testing.T, backend TxWatchBackend, msgTx *wire.MsgTx, config *TxWatcherConfig) []*TxEvent {
+	events, err := WatchTx(context.Background(), backend, msgTx, config)
+	require.NoError(t, err)
+	var results []*TxEvent
+	for event := range events {
+		results = append(results, event)
+	}
+	return results
+}
+
+func TestWatchTx_Confirmed(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	txid := GetTxHash(msgTx)
+	backend := &scriptedWatchBackend{statuses: map[string][]*TxWatchStatus{
+		txid: {
+			{},
+			{InMempool: true},
+			{InMempool: true},
+			{BlockHeight: 100, TipHeight: 100},
+			{BlockHeight: 100, TipHeight: 100},
+			{BlockHeight: 100, TipHeight: 102},
+		},
+	}}
+
+	events := collectTxEvents(t, backend, msgTx, NewTxWatcherConfig(time.Millisecond, 3))
+	require.Len(t, events, 3)
+	require.Equal(t, TxEventSeenInMempool, events[0].Type)
+	require.Equal(t, TxEventConfirmed, events[1].Type)
+	require.Equal(t, int64(1), events[1].Depth)
+	require.Equal(t, TxEventConfirmed, events[2].Type)
+	require.Equal(t, int64(3), events[2].Depth)
+	require.Equal(t, int64(100), events[2].BlockHeight)
+}
+
+func TestWatchTx_EvictedAndRebroadcast(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	txid := GetTxHash(msgTx)
+	backend := &scriptedWatchBackend{statuses: map[string][]*TxWatchStatus{
+		txid: {
+			{InMempool: true},
+			{},
+			{},
+			{ConflictTxid: "5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d"},
+		},
+	}}
+	broadcaster := NewFakeBroadcaster()
+
+	config := NewTxWatcherConfig(10*time.Millisecond, 1)
+	config.Broadcaster = broadcaster
+	config.RebroadcastAfter = 15 * time.Millisecond
+
+	events := collectTxEvents(t, backend, msgTx, config)
+	var types []TxEventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	require.Equal(t, []TxEventType{TxEventSeenInMempool, TxEventEvicted, TxEventRebroadcast, TxEventConflicted}, types)
+	require.Equal(t, "5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", events[3].ConflictTxid)
+	require.Equal(t, []string{txid}, broadcaster.ListTxids())
+}
+
+func TestWatchTx_Bump(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	bumpedTx := msgTx.Copy()
+	bumpedTx.TxOut[0].Value -= 500
+	backend := &scriptedWatchBackend{statuses: map[string][]*TxWatchStatus{
+		GetTxHash(msgTx):    {{InMempool: true}},
+		GetTxHash(bumpedTx): {{InMempool: true}, {BlockHeight: 100, TipHeight: 100}},
+	}}
+
+	config := NewTxWatcherConfig(10*time.Millisecond, 1)
+	config.Broadcaster = NewFakeBroadcaster()
+	config.BumpAfter = 25 * time.Millisecond
+	config.Bump = func(ctx context.Context, msgTx *wire.MsgTx) (*wire.MsgTx, error) {
+		return bumpedTx, nil
+	}
+
+	events := collectTxEvents(t, backend, msgTx, config)
+	require.Len(t, events, 4)
+	require.Equal(t, TxEventSeenInMempool, events[0].Type)
+	require.Equal(t, TxEventBumped, events[1].Type)
+	require.Equal(t, GetTxHash(msgTx), events[1].Txid)
+	require.Equal(t, GetTxHash(bumpedTx), events[1].NewTxid)
+	require.Equal(t, TxEventSeenInMempool, events[2].Type)
+	require.Equal(t, GetTxHash(bumpedTx), events[2].Txid)
+	require.Equal(t, TxEventConfirmed, events[3].Type)
+}
+
+func TestWatchTx_BumpThenOriginalConfirmed(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	bumpedTx := msgTx.Copy()
+	bumpedTx.TxOut[0].Value -= 500
+	backend := &scriptedWatchBackend{statuses: map[string][]*TxWatchStatus{
+		GetTxHash(msgTx):    {{InMempool: true}, {BlockHeight: 100, TipHeight: 100}},
+		GetTxHash(bumpedTx): {{ConflictTxid: GetTxHash(msgTx)}},
+	}}
+
+	config := NewTxWatcherConfig(10*time.Millisecond, 1)
+	config.Broadcaster = NewFakeBroadcaster()
+	config.BumpAfter = time.Nanosecond
+	config.Bump = func(ctx context.Context, msgTx *wire.MsgTx) (*wire.MsgTx, error) {
+		return bumpedTx, nil
+	}
+
+	// The replacement is conflicted by our own original, which is mined
+	events := collectTxEvents(t, backend, msgTx, config)
+	require.Len(t, events, 3)
+	require.Equal(t, TxEventSeenInMempool, events[0].Type)
+	require.Equal(t, TxEventBumped, events[1].Type)
+	require.Equal(t, TxEventConfirmed, events[2].Type)
+	require.Equal(t, GetTxHash(msgTx), events[2].Txid)
+	require.Equal(t, int64(100), events[2].BlockHeight)
+}
+
+func TestWatchTx_ContextCanceled(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	backend := &scriptedWatchBackend{statuses: map[string][]*TxWatchStatus{
+		GetTxHash(msgTx): {{InMempool: true}},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := WatchTx(ctx, backend, msgTx, NewTxWatcherConfig(time.Millisecond, 1))
+	require.NoError(t, err)
+	require.Equal(t, TxEventSeenInMempool, (<-events).Type)
+	cancel()
+
+	// The channel is closed soon after cancel
+	select {
+	case _, ok := <-events:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+}
+
+func TestWatchTx_WrongPollInterval(t *testing.T) {
+	msgTx := caseBroadcastMsgTx(t)
+	backend := &scriptedWatchBackend{statuses: map[string][]*TxWatchStatus{
+		GetTxHash(msgTx): {{InMempool: true}},
+	}}
+
+	for _, config := range []*TxWatcherConfig{nil, {}, NewTxWatcherConfig(0, 1), NewTxWatcherConfig(-time.Second, 1)} {
+		events, err := WatchTx(context.Background(), backend, msgTx, config)
+		require.Error(t, err)
+		require.Nil(t, events)
+	}
+}
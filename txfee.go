@@ -20,6 +20,47 @@ import (
 // 参考链接：https://github.com/btcsuite/btcwallet/blob/b4ff60753aaa3cf885fb09586755f67d41954942/wallet/txauthor/author.go#L132
 // 交易里不应该包含找零的 output 信息，否则结果是无意义的
 func EstimateTxFee(param *BitcoinTxParams, netParams *chaincfg.Params, change *ChangeTo, feeRatePerKb btcutil.Amount, dustFee DustFee) (btcutil.Amount, error) {
+	return EstimateTxFeeWithCalculator(param, netParams, change, NewBitcoinFeeCalculator(feeRatePerKb, dustFee))
+}
+
+// TxFeeCalculator converts estimated signed size and outputs into fee, chains differ in how size is charged
+// BitcoinFeeCalculator charges per byte, dogecoin.FeeCalculator charges per started kB
+//
+// TxFeeCalculator 把预估的签名后大小和输出转换为费用，不同的链按大小收费的方式不同
+// BitcoinFeeCalculator 按字节收费，dogecoin.FeeCalculator 按开始的 kB 收费
+type TxFeeCalculator interface {
+	CalcFee(txSize int, outputs []*wire.TxOut) btcutil.Amount
+}
+
+// BitcoinFeeCalculator charges fee per byte like txrules.FeeForSerializeSize, plus soft dust surcharge of DustFee
+//
+// BitcoinFeeCalculator 像 txrules.FeeForSerializeSize 一样按字节收费，再加上 DustFee 的软灰尘附加费
+type BitcoinFeeCalculator struct {
+	FeeRatePerKb btcutil.Amount // Fee rate per kvB // 每 kvB 的费率
+	DustFee      DustFee        // Soft dust surcharge, zero on Bitcoin // 软灰尘附加费，比特币上是零
+}
+
+// NewBitcoinFeeCalculator creates BitcoinFeeCalculator with fee rate and dust fee
+//
+// NewBitcoinFeeCalculator 使用费率和灰尘费用创建 BitcoinFeeCalculator
+func NewBitcoinFeeCalculator(feeRatePerKb btcutil.Amount, dustFee DustFee) *BitcoinFeeCalculator {
+	return &BitcoinFeeCalculator{FeeRatePerKb: feeRatePerKb, DustFee: dustFee}
+}
+
+// CalcFee returns per byte fee of size plus soft dust surcharge of outputs
+//
+// CalcFee 返回按字节计算的费用再加上输出的软灰尘附加费
+func (c *BitcoinFeeCalculator) CalcFee(txSize int, outputs []*wire.TxOut) btcutil.Amount {
+	//有的链比如 DOGE_COIN 有软灰尘的概念，软灰尘需要消耗更高的手续费，而且这个手续费是不能协商的，而是必须交的，就得在这里交灰尘费
+	return txrules.FeeForSerializeSize(c.FeeRatePerKb, txSize) + c.DustFee.SumExtraDustFee(outputs)
+}
+
+// EstimateTxFeeWithCalculator estimates fee like EstimateTxFee, with chain specific fee calculator
+// Pass dogecoin.NewDogeFeeCalculator on Dogecoin, where fee is charged per started kB
+//
+// EstimateTxFeeWithCalculator 像 EstimateTxFee 一样预估费用，但使用链特定的费用计算器
+// 在狗狗币上传入 dogecoin.NewDogeFeeCalculator，狗狗币按开始的 kB 收费
+func EstimateTxFeeWithCalculator(param *BitcoinTxParams, netParams *chaincfg.Params, change *ChangeTo, calculator TxFeeCalculator) (btcutil.Amount, error) {
 	//通过未签名的交易预估出签名后的交易大小，这里预估值会比线上的值略微大些，误差在个位数（具体看vin和out的个数）
 	maxSignedSize, err := EstimateTxSize(param, netParams, change)
 	if err != nil {
@@ -29,8 +70,7 @@ func EstimateTxFee(param *BitcoinTxParams, netParams *chaincfg.Params, change *C
 	if err != nil {
 		return 0, errors.WithMessage(err, "wrong get-outputs")
 	}
-	//有的链比如 DOGE_COIN 有软灰尘的概念，软灰尘需要消耗更高的手续费，而且这个手续费是不能协商的，而是必须交的，就得在这里交灰尘费
-	maxRequiredFee := txrules.FeeForSerializeSize(feeRatePerKb, maxSignedSize) + dustFee.SumExtraDustFee(outputs)
+	maxRequiredFee := calculator.CalcFee(maxSignedSize, outputs)
 	//但是请注意，input-output-maxFee 的结果还可能是个软灰尘，这时候就还得再增加找零的软灰尘费用，这个是后续逻辑需要考虑的
 	return maxRequiredFee, nil
 }
@@ -112,6 +152,21 @@ func (T *ChangeTo) GetChangeScriptSize() (int, error) {
 	return 0, nil //说明不需要找零输出，就返回0
 }
 
+// GetChangePkScript 获得找零的公钥脚本，优先使用公钥脚本，其次根据钱包地址计算，没有找零时返回 nil
+func (T *ChangeTo) GetChangePkScript() ([]byte, error) {
+	if T.PkScript != nil {
+		return T.PkScript, nil
+	}
+	if T.AddressX != nil {
+		pkScript, err := txscript.PayToAddrScript(T.AddressX)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong change_address")
+		}
+		return pkScript, nil
+	}
+	return nil, nil
+}
+
 // CalculateChangeAddressSize 根据钱包地址计算出找零输出的size
 func CalculateChangeAddressSize(address btcutil.Address) (int, error) {
 	pkScript, err := txscript.PayToAddrScript(address)
@@ -137,7 +192,7 @@ func CalculateChangePkScriptSize(pkScript []byte) (int, error) {
 	case txscript.IsPayToTaproot(pkScript):
 		size = txsizes.P2TRPkScriptSize
 	default:
-		return 0, errors.New("UNSUPPORTED ADDRESS TYPE")
+		return 0, errors.WithMessagef(ErrUnsupportedAddressType, "wrong change pk-script=%x", pkScript)
 	}
 	return size, nil
 }
@@ -172,3 +172,46 @@ func TestEstimateTxFee(t *testing.T) {
 	// make sure the tx fee is same
 	require.Equal(t, btcutil.Amount(787500), param.GetFee())
 }
+
+// TestEstimateTxFeeWithCalculator validates Dogecoin fee is charged per started kB while Bitcoin fee is charged per byte
+//
+// TestEstimateTxFeeWithCalculator 验证狗狗币按开始的 kB 收费，而比特币按字节收费
+func TestEstimateTxFeeWithCalculator(t *testing.T) {
+	const senderAddress = "nVnVaL5e4L2GDRha9aQ7KiSXDnqjUUz1K4"
+
+	netParams := dogecoin.TestNetParams
+
+	param := &BitcoinTxParams{
+		VinList: []VinType{
+			{
+				OutPoint: *MustNewOutPoint("5ae74f2d6c4a0513e3c75484a726820c2b0653c2b26352afe97f4bf813dcf859", 0),
+				Sender:   *NewAddressTuple(senderAddress),
+				Amount:   3000000,
+				RBFInfo:  *NewRBFNotUse(),
+			},
+		},
+		OutList: []OutType{
+			{
+				Target: *NewAddressTuple("nhrZGEEh7JgVV3T1ncnUdTDZsByNnkmipc"),
+				Amount: 1000000,
+			},
+			{
+				Target: *NewAddressTuple("nhrZGEEh7JgVV3T1ncnUdTDZsByNnkmipc"),
+				Amount: 500000, // soft dust // 软灰尘
+			},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+
+	size, err := param.EstimateTxSize(&netParams, NewNoChange())
+	require.NoError(t, err)
+	require.Equal(t, 227, size)
+
+	dogeFee, err := param.EstimateTxFeeWithCalculator(&netParams, NewNoChange(), dogecoin.NewDogeFeeCalculator(1000000))
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(1000000+dogecoin.ExtraDustsFee), dogeFee)
+
+	byteFee, err := param.EstimateTxFee(&netParams, NewNoChange(), 1000000, dogecoin.NewDogeDustFee())
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(227000+dogecoin.ExtraDustsFee), byteFee)
+}
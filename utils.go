@@ -13,6 +13,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/pkg/errors"
+	"github.com/yyle88/gobtcsign/internal/addresses"
 )
 
 // GetTxHash returns transaction hash from signed transaction message
@@ -53,10 +54,19 @@ func NewMsgTxFromHex(txHex string) (*wire.MsgTx, error) {
 	return msgTx, nil
 }
 
+// DecodeAddress decodes address on network without the global chaincfg registry
+// Segwit addresses of networks never passed to chaincfg.Register, such as Dogecoin and Litecoin, decode as well
+//
+// DecodeAddress 不依赖全局 chaincfg 注册表解析网络上的地址
+// 没有通过 chaincfg.Register 注册的网络，比如狗狗币和莱特币，它们的 segwit 地址也能解析
+func DecodeAddress(addressString string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	return addresses.DecodeAddress(addressString, netParams)
+}
+
 // GetAddressPkScript generates the corresponding public key script (PkScript) from the address string.
 // GetAddressPkScript 根据地址字符串生成对应的公钥脚本（PkScript），地址和公钥脚本是一对一的
 func GetAddressPkScript(addressString string, netParams *chaincfg.Params) ([]byte, error) {
-	address, err := btcutil.DecodeAddress(addressString, netParams)
+	address, err := DecodeAddress(addressString, netParams)
 	if err != nil {
 		return nil, errors.WithMessage(err, "wrong decode-address")
 	}
@@ -70,7 +80,7 @@ func GetAddressPkScript(addressString string, netParams *chaincfg.Params) ([]byt
 // MustNewAddress decodes the address string and panics if there is an error.
 // MustNewAddress 根据地址字符串生成地址对象，如果出错则抛出异常
 func MustNewAddress(addressString string, netParams *chaincfg.Params) btcutil.Address {
-	address, err := btcutil.DecodeAddress(addressString, netParams)
+	address, err := DecodeAddress(addressString, netParams)
 	if err != nil {
 		panic(errors.WithMessage(err, "wrong decode-address"))
 	}
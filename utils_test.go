@@ -7,6 +7,7 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/stretchr/testify/require"
 	"github.com/yyle88/gobtcsign/dogecoin"
+	"github.com/yyle88/gobtcsign/litecoin"
 )
 
 func TestGetAddressPkScript(t *testing.T) {
@@ -19,6 +20,11 @@ func TestGetAddressPkScript(t *testing.T) {
 	require.Equal(t, pkTarget, pkScript)
 }
 
+func TestGetAddressPkScript_Mweb(t *testing.T) {
+	_, err := GetAddressPkScript("ltcmweb1qq0yq03ewm830ugmkkvrvjmyyeslcpwk8ayd7k27qx63sryy6kx3ksqm3k6jd24ld3r5dp5lzx7rm7uyxfujf8sn7v4nlxeqwrcq6k6xxwqdc6tl3", &litecoin.MainNetParams)
+	require.Error(t, err)
+}
+
 func caseGetAddressPkScript(t *testing.T, rawAddress string, netParams *chaincfg.Params) []byte {
 	pkScript, err := GetAddressPkScript(rawAddress, netParams)
 	require.NoError(t, err)
@@ -1,7 +1,13 @@
 package gobtcsign
 
 import (
+	"context"
+	"encoding/hex"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/pkg/errors"
@@ -16,6 +22,140 @@ type GetUtxoFromInterface interface {
 	GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error)
 }
 
+// GetUtxoFromInterfaceV2 defines context-aware interface to retrieve many UTXOs at once
+// Results are in the same order as the given outpoints
+//
+// GetUtxoFromInterfaceV2 定义支持 context 的接口，一次检索多个 UTXO
+// 结果的顺序和传入的 outpoints 顺序相同
+type GetUtxoFromInterfaceV2 interface {
+	GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error)
+}
+
+// NewGetUtxoFromV2 converts GetUtxoFromInterface to GetUtxoFromInterfaceV2
+// Uses the native batch logic when preImp already implements V2, otherwise runs lookups with bounded concurrency
+//
+// NewGetUtxoFromV2 把 GetUtxoFromInterface 转换为 GetUtxoFromInterfaceV2
+// 当 preImp 已经实现 V2 时使用其原生批量逻辑，否则以有限的并发数执行查询
+func NewGetUtxoFromV2(preImp GetUtxoFromInterface, concurrency int) GetUtxoFromInterfaceV2 {
+	if res, ok := preImp.(GetUtxoFromInterfaceV2); ok {
+		return res
+	}
+	return NewGetUtxoFromBatcher(preImp, concurrency)
+}
+
+// GetUtxoFromBatcher implements GetUtxoFromInterfaceV2 on top of GetUtxoFromInterface
+// Runs single lookups in parallel with at most concurrency goroutines
+//
+// GetUtxoFromBatcher 基于 GetUtxoFromInterface 实现 GetUtxoFromInterfaceV2
+// 最多使用 concurrency 个协程并行执行单个查询
+type GetUtxoFromBatcher struct {
+	preImp      GetUtxoFromInterface // Single UTXO fetcher // 单个 UTXO 获取器
+	concurrency int                  // Max lookups in flight // 同时进行的最大查询数
+}
+
+// NewGetUtxoFromBatcher creates GetUtxoFromBatcher, concurrency less than 1 is treated as 1
+//
+// NewGetUtxoFromBatcher 创建 GetUtxoFromBatcher，concurrency 小于 1 时按 1 处理
+func NewGetUtxoFromBatcher(preImp GetUtxoFromInterface, concurrency int) *GetUtxoFromBatcher {
+	return &GetUtxoFromBatcher{
+		preImp:      preImp,
+		concurrency: max(concurrency, 1),
+	}
+}
+
+// GetUtxoFromList retrieves UTXOs in parallel, returns the first error and stops starting new lookups
+//
+// GetUtxoFromList 并行检索 UTXO，遇到首个错误时返回，并且不再发起新的查询
+func (b *GetUtxoFromBatcher) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	var results = make([]*SenderAmountUtxo, len(utxos))
+	if err := runWithLimit(ctx, len(utxos), b.concurrency, func(ctx context.Context, idx int) error {
+		utxoFrom, err := b.preImp.GetUtxoFrom(utxos[idx])
+		if err != nil {
+			return errors.WithMessagef(err, "wrong get-utxo-from. index=%d", idx)
+		}
+		results[idx] = utxoFrom
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runWithLimit runs fn for indexes [0, count) with at most concurrency goroutines
+// Stops starting new calls at the first error and returns that error
+//
+// runWithLimit 以最多 concurrency 个协程对 [0, count) 的下标执行 fn
+// 遇到首个错误时不再发起新的调用，并返回该错误
+func runWithLimit(ctx context.Context, count int, concurrency int, fn func(ctx context.Context, idx int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var firstErr error
+	var errOnce sync.Once
+	var sem = make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+loop:
+	for idx := 0; idx < count; idx++ {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+			if err := fn(ctx, idx); err != nil {
+				errOnce.Do(func() { firstErr = err })
+				cancel()
+			}
+		}(idx)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// Parent context is done when no call has failed but the loop stopped early
+	// 没有调用失败但循环提前结束，说明外部的 context 已经结束
+	return ctx.Err()
+}
+
+// AddressUtxo represents one spendable output owned by an address
+// Returned by backends listing UTXOs by address (Esplora, Electrum)
+//
+// AddressUtxo 代表某个地址拥有的一个可花费输出
+// 由按地址列出 UTXO 的后端（Esplora、Electrum）返回
+type AddressUtxo struct {
+	OutPoint    wire.OutPoint // UTXO location // UTXO 的位置
+	Sender      AddressTuple  // Owner of the UTXO // UTXO 的持有者
+	Amount      int64         // Amount in satoshis // 数量（单位：聪）
+	Confirmed   bool          // Whether the UTXO is in a block // 是否已经上链
+	BlockHeight int64         // Block height when confirmed, otherwise 0 // 确认时的区块高度，未确认时为 0
+}
+
+// GetVinType converts AddressUtxo to VinType used in BitcoinTxParams
+//
+// GetVinType 把 AddressUtxo 转换为 BitcoinTxParams 里使用的 VinType
+func (u *AddressUtxo) GetVinType(rbfInfo RBFConfig) VinType {
+	return VinType{
+		OutPoint: u.OutPoint,
+		Sender:   u.Sender,
+		Amount:   u.Amount,
+		RBFInfo:  rbfInfo,
+	}
+}
+
+// ListAddressUtxosInterface defines interface to list spendable UTXOs of address
+//
+// ListAddressUtxosInterface 定义列出地址可花费 UTXO 的接口
+type ListAddressUtxosInterface interface {
+	ListAddressUtxos(ctx context.Context, address string) ([]*AddressUtxo, error)
+}
+
 // SenderAmountUtxoClient implements GetUtxoFromInterface using RPC client
 // Fetches UTXO information from Bitcoin node via RPC calls
 //
@@ -23,6 +163,9 @@ type GetUtxoFromInterface interface {
 // 通过 RPC 调用从比特币节点获取 UTXO 信息
 type SenderAmountUtxoClient struct {
 	client *rpcclient.Client // Bitcoin RPC client // 比特币 RPC 客户端
+	batch  bool              // Client created by rpcclient.NewBatch // 客户端是否由 rpcclient.NewBatch 创建
+	limit  int               // Max requests in flight or in one batch // 同时发出或单次批量发出的最大请求数
+	mutex  sync.Mutex        // Batch client shares one request queue // 批量客户端共用一个请求队列
 }
 
 // NewSenderAmountUtxoClient creates SenderAmountUtxoClient with RPC client
@@ -31,7 +174,16 @@ type SenderAmountUtxoClient struct {
 // NewSenderAmountUtxoClient 使用 RPC 客户端创建 SenderAmountUtxoClient
 // 返回查询比特币节点的 UTXO 获取器
 func NewSenderAmountUtxoClient(client *rpcclient.Client) *SenderAmountUtxoClient {
-	return &SenderAmountUtxoClient{client: client}
+	return &SenderAmountUtxoClient{client: client, batch: false, limit: 16}
+}
+
+// NewSenderAmountUtxoBatchClient creates SenderAmountUtxoClient with batch RPC client
+// Client must be created by rpcclient.NewBatch, each batch carries at most batchSize requests
+//
+// NewSenderAmountUtxoBatchClient 使用批量 RPC 客户端创建 SenderAmountUtxoClient
+// 客户端必须由 rpcclient.NewBatch 创建，每个批次最多包含 batchSize 个请求
+func NewSenderAmountUtxoBatchClient(client *rpcclient.Client, batchSize int) *SenderAmountUtxoClient {
+	return &SenderAmountUtxoClient{client: client, batch: true, limit: max(batchSize, 1)}
 }
 
 // GetUtxoFrom retrieves UTXO sender and amount from Bitcoin node
@@ -40,10 +192,102 @@ func NewSenderAmountUtxoClient(client *rpcclient.Client) *SenderAmountUtxoClient
 // GetUtxoFrom 从比特币节点检索 UTXO 发送者和数量
 // 查询前置交易以提取输出详情
 func (uc *SenderAmountUtxoClient) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	if uc.batch {
+		// Batch client only queues requests until Send, so the blocking call never returns
+		// 批量客户端在 Send 之前只会排队请求，因此阻塞调用永远不会返回
+		results, err := uc.GetUtxoFromList(context.Background(), []wire.OutPoint{utxo})
+		if err != nil {
+			return nil, err
+		}
+		return results[0], nil
+	}
 	previousUtxoTx, err := GetRawTransaction(uc.client, utxo.Hash.String())
 	if err != nil {
 		return nil, errors.WithMessage(err, "get-raw-transaction")
 	}
+	return newSenderAmountUtxoFromRawTx(previousUtxoTx, utxo)
+}
+
+// GetUtxoFromList retrieves many UTXOs from Bitcoin node
+// Fetches each previous transaction only once even when several outputs of it are spent
+// Batch client sends requests in JSON-RPC batches, other clients keep limited requests in flight
+//
+// GetUtxoFromList 从比特币节点检索多个 UTXO
+// 即使花费了同一个前置交易的多个输出，每个前置交易也只查询一次
+// 批量客户端以 JSON-RPC 批量请求发送，其它客户端则限制同时进行的请求数
+func (uc *SenderAmountUtxoClient) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	var txHashes = make([]chainhash.Hash, 0, len(utxos))
+	var txResults = make(map[chainhash.Hash]*btcjson.TxRawResult, len(utxos))
+	for _, utxo := range utxos {
+		if _, ok := txResults[utxo.Hash]; !ok {
+			txResults[utxo.Hash] = nil
+			txHashes = append(txHashes, utxo.Hash)
+		}
+	}
+
+	for start := 0; start < len(txHashes); start += uc.limit {
+		chunk := txHashes[start:min(start+uc.limit, len(txHashes))]
+		futures, err := uc.sendChunk(ctx, chunk)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong send-requests")
+		}
+		for idx, future := range futures {
+			txResult, err := receiveWithContext(ctx, future.Receive)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "get-raw-transaction. hash=%s", chunk[idx].String())
+			}
+			txResults[chunk[idx]] = txResult
+		}
+	}
+
+	var results = make([]*SenderAmountUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		utxoFrom, err := newSenderAmountUtxoFromRawTx(txResults[utxo.Hash], utxo)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong raw-tx-output")
+		}
+		results = append(results, utxoFrom)
+	}
+	return results, nil
+}
+
+func (uc *SenderAmountUtxoClient) sendChunk(ctx context.Context, chunk []chainhash.Hash) ([]rpcclient.FutureGetRawTransactionVerboseResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !uc.batch {
+		var futures = make([]rpcclient.FutureGetRawTransactionVerboseResult, 0, len(chunk))
+		for idx := range chunk {
+			futures = append(futures, uc.client.GetRawTransactionVerboseAsync(&chunk[idx]))
+		}
+		return futures, nil
+	}
+	// Queue and send under lock so that concurrent callers never mix their batches
+	// 在锁内排队和发送，避免并发调用时批次相互混杂
+	return receiveWithContext(ctx, func() ([]rpcclient.FutureGetRawTransactionVerboseResult, error) {
+		uc.mutex.Lock()
+		defer uc.mutex.Unlock()
+
+		var futures = make([]rpcclient.FutureGetRawTransactionVerboseResult, 0, len(chunk))
+		for idx := range chunk {
+			futures = append(futures, uc.client.GetRawTransactionVerboseAsync(&chunk[idx]))
+		}
+		if err := uc.client.Send(); err != nil {
+			return nil, errors.WithMessage(err, "wrong batch-send")
+		}
+		return futures, nil
+	})
+}
+
+// newSenderAmountUtxoFromRawTx reads output of previous transaction with bounds check
+// newSenderAmountUtxoFromRawTx 读取前置交易的输出，并检查位置是否越界
+func newSenderAmountUtxoFromRawTx(previousUtxoTx *btcjson.TxRawResult, utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	if previousUtxoTx == nil {
+		return nil, errors.Errorf("wrong utxo[%s:%d] previous-tx-not-exist", utxo.Hash.String(), utxo.Index)
+	}
+	if int64(utxo.Index) >= int64(len(previousUtxoTx.Vout)) {
+		return nil, errors.Errorf("wrong utxo[%s:%d] index-out-of-range vout-count=%d", utxo.Hash.String(), utxo.Index, len(previousUtxoTx.Vout))
+	}
 	previousOutput := previousUtxoTx.Vout[utxo.Index]
 
 	previousAmount, err := btcutil.NewAmount(previousOutput.Value)
@@ -51,11 +295,41 @@ func (uc *SenderAmountUtxoClient) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmount
 		return nil, errors.WithMessage(err, "get-previous-amount")
 	}
 
-	utxoFrom := NewSenderAmountUtxo(
-		NewAddressTuple(previousOutput.ScriptPubKey.Address),
-		int64(previousAmount),
-	)
-	return utxoFrom, nil
+	// Newer bitcoind omits address of some script types, so the script hex is kept as well
+	// 新版本的 bitcoind 对某些脚本类型不返回地址，因此同时保留脚本的十六进制
+	sender := NewAddressTuple(previousOutput.ScriptPubKey.Address)
+	if previousOutput.ScriptPubKey.Hex != "" {
+		pkScript, err := hex.DecodeString(previousOutput.ScriptPubKey.Hex)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong previous-pk-script-hex")
+		}
+		sender.PkScript = pkScript
+	}
+	if sender.Address == "" && len(sender.PkScript) == 0 {
+		return nil, errors.Errorf("wrong utxo[%s:%d] previous-output no-address-no-pk-script", utxo.Hash.String(), utxo.Index)
+	}
+	return NewSenderAmountUtxo(sender, int64(previousAmount)), nil
+}
+
+// receiveWithContext waits for blocking receive function and gives up when context is done
+// receiveWithContext 等待阻塞的接收函数，当 context 结束时放弃等待
+func receiveWithContext[T any](ctx context.Context, receive func() (T, error)) (T, error) {
+	type result struct {
+		res T
+		err error
+	}
+	var resultChan = make(chan result, 1)
+	go func() {
+		res, err := receive()
+		resultChan <- result{res: res, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case one := <-resultChan:
+		return one.res, one.err
+	}
 }
 
 // SenderAmountUtxo represents UTXO sender address and amount information
@@ -80,6 +354,20 @@ func NewSenderAmountUtxo(sender *AddressTuple, amount int64) *SenderAmountUtxo {
 	}
 }
 
+// GetSender returns UTXO sender address
+//
+// GetSender 返回 UTXO 发送者地址
+func (u *SenderAmountUtxo) GetSender() *AddressTuple {
+	return u.sender
+}
+
+// GetAmount returns UTXO amount in satoshis
+//
+// GetAmount 返回 UTXO 数量（单位：聪）
+func (u *SenderAmountUtxo) GetAmount() int64 {
+	return u.amount
+}
+
 // SenderAmountUtxoCache implements GetUtxoFromInterface using in-memory cache
 // Provides fast UTXO lookups without network calls
 //
@@ -110,3 +398,21 @@ func (uc SenderAmountUtxoCache) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUt
 	}
 	return utxoFrom, nil
 }
+
+// GetUtxoFromList retrieves many UTXOs from cache
+//
+// GetUtxoFromList 从缓存中检索多个 UTXO
+func (uc SenderAmountUtxoCache) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	var results = make([]*SenderAmountUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		utxoFrom, err := uc.GetUtxoFrom(utxo)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, utxoFrom)
+	}
+	return results, nil
+}
@@ -1,13 +1,260 @@
 package gobtcsign
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUtxoFromClient_GetUtxoFrom(t *testing.T) {
 	var _ GetUtxoFromInterface = &SenderAmountUtxoClient{}
+	var _ GetUtxoFromInterfaceV2 = &SenderAmountUtxoClient{}
 }
 
 func TestSenderAmountUtxoCache_GetUtxoFrom(t *testing.T) {
 	var _ GetUtxoFromInterface = &SenderAmountUtxoCache{}
+	var _ GetUtxoFromInterfaceV2 = &SenderAmountUtxoCache{}
+}
+
+// fakeBitcoind serves getrawtransaction (verbose) from recorded results, supports JSON-RPC batches
+type fakeBitcoind struct {
+	txResults map[string]*btcjson.TxRawResult
+	mutex     sync.Mutex
+	posts     int            // http requests received
+	calls     map[string]int // getrawtransaction calls per txid
+}
+
+type fakeRpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type fakeRpcResponse struct {
+	ID     json.RawMessage   `json:"id"`
+	Result interface{}       `json:"result"`
+	Error  *btcjson.RPCError `json:"error"`
+}
+
+func (f *fakeBitcoind) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.posts++
+
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var requests []*fakeRpcRequest
+		_ = json.Unmarshal(body, &requests)
+		var responses = make([]*fakeRpcResponse, 0, len(requests))
+		for _, request := range requests {
+			responses = append(responses, f.handle(request))
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+	var request fakeRpcRequest
+	_ = json.Unmarshal(body, &request)
+	_ = json.NewEncoder(w).Encode(f.handle(&request))
+}
+
+func (f *fakeBitcoind) handle(request *fakeRpcRequest) *fakeRpcResponse {
+	var txid string
+	_ = json.Unmarshal(request.Params[0], &txid)
+	f.calls[txid]++
+	txResult, ok := f.txResults[txid]
+	if !ok {
+		return &fakeRpcResponse{ID: request.ID, Error: &btcjson.RPCError{Code: btcjson.ErrRPCNoTxInfo, Message: "No such mempool or blockchain transaction"}}
+	}
+	return &fakeRpcResponse{ID: request.ID, Result: txResult}
+}
+
+func newFakeBitcoind() *fakeBitcoind {
+	return &fakeBitcoind{
+		txResults: map[string]*btcjson.TxRawResult{
+			"fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328": {
+				Txid: "fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328",
+				Vout: []btcjson.Vout{
+					{Value: 0.000049, N: 0, ScriptPubKey: btcjson.ScriptPubKeyResult{Address: "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"}},
+					{Value: 0.001, N: 1, ScriptPubKey: btcjson.ScriptPubKeyResult{Address: "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"}},
+				},
+			},
+			"fcc889d7f0217694ab46d93f03a200d326c34e317552a6a33cb3fab03aa0b439": {
+				Txid: "fcc889d7f0217694ab46d93f03a200d326c34e317552a6a33cb3fab03aa0b439",
+				Vout: []btcjson.Vout{
+					{Value: 0.1, N: 0, ScriptPubKey: btcjson.ScriptPubKeyResult{Address: "tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q"}},
+					{Value: 0.0000432, N: 1, ScriptPubKey: btcjson.ScriptPubKeyResult{Address: "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"}},
+				},
+			},
+		},
+		calls: map[string]int{},
+	}
+}
+
+func newFakeBitcoindConfig(server *httptest.Server) *rpcclient.ConnConfig {
+	return &rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}
+}
+
+func caseFakeUtxos() []wire.OutPoint {
+	return []wire.OutPoint{
+		*MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0),
+		*MustNewOutPoint("fcc889d7f0217694ab46d93f03a200d326c34e317552a6a33cb3fab03aa0b439", 1),
+		*MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 1),
+	}
+}
+
+func TestSenderAmountUtxoClient_GetUtxoFromList(t *testing.T) {
+	fake := newFakeBitcoind()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := rpcclient.New(newFakeBitcoindConfig(server), nil)
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	results, err := NewSenderAmountUtxoClient(client).GetUtxoFromList(context.Background(), caseFakeUtxos())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", results[0].sender.Address)
+	require.Equal(t, int64(4900), results[0].amount)
+	require.Equal(t, int64(4320), results[1].amount)
+	require.Equal(t, "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", results[2].sender.Address)
+	require.Equal(t, int64(100000), results[2].amount)
+
+	// Two outputs of the same tx are fetched with one request
+	require.Equal(t, 1, fake.calls["fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328"])
+	require.Equal(t, 2, fake.posts)
+}
+
+func TestSenderAmountUtxoBatchClient_GetUtxoFromList(t *testing.T) {
+	fake := newFakeBitcoind()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := rpcclient.NewBatch(newFakeBitcoindConfig(server))
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	results, err := NewSenderAmountUtxoBatchClient(client, 100).GetUtxoFromList(context.Background(), caseFakeUtxos())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, int64(4900), results[0].amount)
+	require.Equal(t, int64(4320), results[1].amount)
+	require.Equal(t, int64(100000), results[2].amount)
+
+	// All previous txs are fetched in one http request
+	require.Equal(t, 1, fake.posts)
+}
+
+func TestSenderAmountUtxoBatchClient_GetUtxoFrom(t *testing.T) {
+	fake := newFakeBitcoind()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := rpcclient.NewBatch(newFakeBitcoindConfig(server))
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	utxoClient := NewSenderAmountUtxoBatchClient(client, 100)
+
+	utxoFrom, err := utxoClient.GetUtxoFrom(caseFakeUtxos()[1])
+	require.NoError(t, err)
+	require.Equal(t, int64(4320), utxoFrom.amount)
+
+	// V1 path wraps the client with NewGetUtxoFromV2
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	for _, utxo := range caseFakeUtxos() {
+		msgTx.AddTxIn(wire.NewTxIn(&utxo, nil, nil))
+	}
+	pkScript, err := GetAddressPkScript("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	msgTx.AddTxOut(wire.NewTxOut(100000, pkScript))
+
+	param, err := NewCustomParamFromMsgTx(msgTx, utxoClient)
+	require.NoError(t, err)
+	require.Len(t, param.VinList, 3)
+	require.Equal(t, int64(4900), param.VinList[0].Amount)
+	require.Equal(t, int64(100000), param.VinList[2].Amount)
+}
+
+func TestSenderAmountUtxoClient_IndexOutOfRange(t *testing.T) {
+	fake := newFakeBitcoind()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := rpcclient.New(newFakeBitcoindConfig(server), nil)
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	utxoClient := NewSenderAmountUtxoClient(client)
+
+	wrongUtxo := *MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 5)
+	_, err = utxoClient.GetUtxoFrom(wrongUtxo)
+	require.Error(t, err)
+	t.Log(err)
+
+	_, err = utxoClient.GetUtxoFromList(context.Background(), []wire.OutPoint{wrongUtxo})
+	require.Error(t, err)
+	t.Log(err)
+}
+
+func TestGetUtxoFromBatcher_GetUtxoFromList(t *testing.T) {
+	utxos := caseFakeUtxos()
+	cache := NewSenderAmountUtxoCache(map[wire.OutPoint]*SenderAmountUtxo{
+		utxos[0]: NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), 4900),
+		utxos[1]: NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), 4320),
+		utxos[2]: NewSenderAmountUtxo(NewAddressTuple("tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx"), 100000),
+	})
+
+	// Hide the V2 method of the cache, so that the batcher is used
+	batcher := NewGetUtxoFromV2(struct{ GetUtxoFromInterface }{cache}, 2)
+	require.IsType(t, &GetUtxoFromBatcher{}, batcher)
+
+	results, err := batcher.GetUtxoFromList(context.Background(), utxos)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, int64(4900), results[0].amount)
+	require.Equal(t, int64(4320), results[1].amount)
+	require.Equal(t, int64(100000), results[2].amount)
+
+	_, err = batcher.GetUtxoFromList(context.Background(), append(utxos, *MustNewOutPoint("5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", 0)))
+	require.Error(t, err)
+	t.Log(err)
+}
+
+func TestGetUtxoFromBatcher_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	batcher := NewGetUtxoFromBatcher(slowUtxoFrom{delay: 300 * time.Millisecond}, 1)
+	_, err := batcher.GetUtxoFromList(ctx, caseFakeUtxos())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type slowUtxoFrom struct {
+	delay time.Duration
+}
+
+func (s slowUtxoFrom) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	time.Sleep(s.delay)
+	return NewSenderAmountUtxo(NewAddressTuple(""), 0), nil
 }
@@ -0,0 +1,449 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var utxoStoreBucket = []byte("utxos")
+
+// UtxoStore implements GetUtxoFromInterface using embedded bolt database file
+// Keeps UTXO sender and amount across restarts, with spent and reserved marks
+//
+// UtxoStore 使用嵌入式 bolt 数据库文件实现 GetUtxoFromInterface
+// 重启后仍保留 UTXO 的发送者和数量，并记录已花费和已预留的标记
+type UtxoStore struct {
+	db *bolt.DB // Bolt database // bolt 数据库
+}
+
+// UtxoStoreRecord represents one UTXO saved in UtxoStore
+//
+// UtxoStoreRecord 代表保存在 UtxoStore 里的一个 UTXO
+type UtxoStoreRecord struct {
+	OutPoint   wire.OutPoint // UTXO location // UTXO 的位置
+	Sender     AddressTuple  // UTXO sender address // UTXO 发送者地址
+	Amount     int64         // UTXO amount in satoshis // UTXO 数量（单位：聪）
+	SpentBy    string        // Txid spending the UTXO, empty when unspent // 花费该 UTXO 的交易哈希，未花费时为空
+	ReservedBy string        // Reservation id, empty when free // 预留标识，未预留时为空
+}
+
+// utxoStoreValue is the JSON value saved in database, scripts are in hex
+// utxoStoreValue 是保存在数据库里的 JSON 值，脚本使用十六进制
+type utxoStoreValue struct {
+	Address    string `json:"address,omitempty"`
+	PkScript   string `json:"pk_script,omitempty"`
+	Amount     int64  `json:"amount"`
+	SpentBy    string `json:"spent_by,omitempty"`
+	ReservedBy string `json:"reserved_by,omitempty"`
+}
+
+// OpenUtxoStore opens or creates UtxoStore at path
+// Waits at most one second when the file is locked by another process
+//
+// OpenUtxoStore 打开或创建位于 path 的 UtxoStore
+// 当文件被其它进程锁定时最多等待一秒
+func OpenUtxoStore(path string) (*UtxoStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong open-db")
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(utxoStoreBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, errors.WithMessage(err, "wrong create-bucket")
+	}
+	return &UtxoStore{db: db}, nil
+}
+
+// Close closes the database file
+//
+// Close 关闭数据库文件
+func (s *UtxoStore) Close() error {
+	return s.db.Close()
+}
+
+// Insert saves UTXO sender and amount, existing record keeps its spent and reserved marks
+//
+// Insert 保存 UTXO 发送者和数量，已存在的记录保留其已花费和已预留标记
+func (s *UtxoStore) Insert(utxo wire.OutPoint, utxoFrom *SenderAmountUtxo) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return insertUtxo(tx.Bucket(utxoStoreBucket), utxo, utxoFrom)
+	})
+}
+
+// InsertList saves many UTXOs in one database transaction
+//
+// InsertList 在一个数据库事务中保存多个 UTXO
+func (s *UtxoStore) InsertList(utxos []wire.OutPoint, utxoFroms []*SenderAmountUtxo) error {
+	if len(utxos) != len(utxoFroms) {
+		return errors.Errorf("wrong utxo-from count: got %d, expected %d", len(utxoFroms), len(utxos))
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(utxoStoreBucket)
+		for idx, utxo := range utxos {
+			if err := insertUtxo(bucket, utxo, utxoFroms[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func insertUtxo(bucket *bolt.Bucket, utxo wire.OutPoint, utxoFrom *SenderAmountUtxo) error {
+	value, exists, err := readUtxoValue(bucket, utxo)
+	if err != nil {
+		return err
+	}
+	if exists && value.Amount != utxoFrom.GetAmount() {
+		return errors.Errorf("wrong utxo[%s:%d] amount mismatch: saved %d, got %d", utxo.Hash.String(), utxo.Index, value.Amount, utxoFrom.GetAmount())
+	}
+	value.Address = utxoFrom.GetSender().Address
+	value.PkScript = hex.EncodeToString(utxoFrom.GetSender().PkScript)
+	value.Amount = utxoFrom.GetAmount()
+	return writeUtxoValue(bucket, utxo, value)
+}
+
+// Delete removes UTXO record, does nothing when it does not exist
+//
+// Delete 删除 UTXO 记录，记录不存在时什么也不做
+func (s *UtxoStore) Delete(utxo wire.OutPoint) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(utxoStoreBucket).Delete(utxoStoreKey(utxo))
+	})
+}
+
+// Spend marks UTXO as spent by tx, the reservation is released
+// Returns error when UTXO does not exist or is already spent by another tx
+//
+// Spend 把 UTXO 标记为被 tx 花费，同时释放预留
+// 当 UTXO 不存在或已被其它交易花费时返回错误
+func (s *UtxoStore) Spend(utxo wire.OutPoint, spentBy chainhash.Hash) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(utxoStoreBucket)
+		value, exists, err := readUtxoValue(bucket, utxo)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Errorf("wrong utxo[%s:%d] not-exist-in-store", utxo.Hash.String(), utxo.Index)
+		}
+		if value.SpentBy != "" && value.SpentBy != spentBy.String() {
+			return errors.Errorf("wrong utxo[%s:%d] already spent by %s", utxo.Hash.String(), utxo.Index, value.SpentBy)
+		}
+		value.SpentBy = spentBy.String()
+		value.ReservedBy = ""
+		return writeUtxoValue(bucket, utxo, value)
+	})
+}
+
+// Reserve marks UTXOs as reserved by reserveID, all or nothing
+// Fails when any UTXO does not exist, is spent or is reserved by another id
+//
+// Reserve 把 UTXO 标记为被 reserveID 预留，要么全部成功要么全部失败
+// 当任一 UTXO 不存在、已花费或已被其它标识预留时失败
+func (s *UtxoStore) Reserve(utxos []wire.OutPoint, reserveID string) error {
+	if reserveID == "" {
+		return errors.New("wrong reserve-id is empty")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(utxoStoreBucket)
+		for _, utxo := range utxos {
+			value, exists, err := readUtxoValue(bucket, utxo)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return errors.Errorf("wrong utxo[%s:%d] not-exist-in-store", utxo.Hash.String(), utxo.Index)
+			}
+			if value.SpentBy != "" {
+				return errors.Errorf("wrong utxo[%s:%d] already spent by %s", utxo.Hash.String(), utxo.Index, value.SpentBy)
+			}
+			if value.ReservedBy != "" && value.ReservedBy != reserveID {
+				return errors.Errorf("wrong utxo[%s:%d] already reserved by %s", utxo.Hash.String(), utxo.Index, value.ReservedBy)
+			}
+			value.ReservedBy = reserveID
+			if err := writeUtxoValue(bucket, utxo, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Release clears reservation of all UTXOs reserved by reserveID
+//
+// Release 清除所有被 reserveID 预留的 UTXO 的预留标记
+func (s *UtxoStore) Release(reserveID string) error {
+	if reserveID == "" {
+		return errors.New("wrong reserve-id is empty")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(utxoStoreBucket)
+		var records []*UtxoStoreRecord
+		if err := forEachUtxo(bucket, func(record *UtxoStoreRecord) {
+			if record.ReservedBy == reserveID {
+				records = append(records, record)
+			}
+		}); err != nil {
+			return err
+		}
+		// Bolt does not allow changing the bucket while iterating it
+		// bolt 不允许在遍历时修改桶
+		for _, record := range records {
+			record.ReservedBy = ""
+			if err := writeUtxoValue(bucket, record.OutPoint, newUtxoStoreValue(record)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetRecord returns UTXO record with spent and reserved marks
+//
+// GetRecord 返回包含已花费和已预留标记的 UTXO 记录
+func (s *UtxoStore) GetRecord(utxo wire.OutPoint) (*UtxoStoreRecord, error) {
+	var record *UtxoStoreRecord
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		value, exists, err := readUtxoValue(tx.Bucket(utxoStoreBucket), utxo)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.Errorf("wrong utxo[%s:%d] not-exist-in-store", utxo.Hash.String(), utxo.Index)
+		}
+		record, err = newUtxoStoreRecord(utxo, value)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListUnspent returns UTXOs not spent yet, reserved ones are included when includeReserved is true
+//
+// ListUnspent 返回尚未花费的 UTXO，当 includeReserved 为 true 时包含已预留的
+func (s *UtxoStore) ListUnspent(includeReserved bool) ([]*UtxoStoreRecord, error) {
+	var records []*UtxoStoreRecord
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		return forEachUtxo(tx.Bucket(utxoStoreBucket), func(record *UtxoStoreRecord) {
+			if record.SpentBy == "" && (includeReserved || record.ReservedBy == "") {
+				records = append(records, record)
+			}
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetUtxoFrom retrieves UTXO from store, spent UTXOs are returned too
+// Returns error if UTXO not found in store
+//
+// GetUtxoFrom 从存储中检索 UTXO，已花费的 UTXO 也会返回
+// 如果存储中未找到 UTXO 则返回错误
+func (s *UtxoStore) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	record, err := s.GetRecord(utxo)
+	if err != nil {
+		return nil, err
+	}
+	return NewSenderAmountUtxo(&record.Sender, record.Amount), nil
+}
+
+// GetUtxoFromList retrieves many UTXOs from store in one database transaction
+//
+// GetUtxoFromList 在一个数据库事务中从存储检索多个 UTXO
+func (s *UtxoStore) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	results, missing, err := s.getUtxoFromList(ctx, utxos)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		utxo := utxos[missing[0]]
+		return nil, errors.Errorf("wrong utxo[%s:%d] not-exist-in-store", utxo.Hash.String(), utxo.Index)
+	}
+	return results, nil
+}
+
+// getUtxoFromList returns found UTXOs in place and indexes of missing ones
+// getUtxoFromList 在对应位置返回找到的 UTXO，并返回缺失的下标
+func (s *UtxoStore) getUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, []int, error) {
+	var results = make([]*SenderAmountUtxo, len(utxos))
+	var missing []int
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(utxoStoreBucket)
+		for idx, utxo := range utxos {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			value, exists, err := readUtxoValue(bucket, utxo)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				missing = append(missing, idx)
+				continue
+			}
+			record, err := newUtxoStoreRecord(utxo, value)
+			if err != nil {
+				return err
+			}
+			results[idx] = NewSenderAmountUtxo(&record.Sender, record.Amount)
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	return results, missing, nil
+}
+
+// ReadThroughUtxoStore implements GetUtxoFromInterface reading UtxoStore first
+// Missing UTXOs are fetched from preImp (RPC client, Esplora, Electrum) and saved into the store
+//
+// ReadThroughUtxoStore 实现 GetUtxoFromInterface，优先读取 UtxoStore
+// 缺失的 UTXO 从 preImp（RPC 客户端、Esplora、Electrum）获取并保存到存储中
+type ReadThroughUtxoStore struct {
+	store  *UtxoStore             // Persistent store // 持久化存储
+	preImp GetUtxoFromInterfaceV2 // Source of missing UTXOs // 缺失 UTXO 的数据源
+}
+
+// NewReadThroughUtxoStore creates ReadThroughUtxoStore with store and UTXO source
+//
+// NewReadThroughUtxoStore 使用存储和 UTXO 数据源创建 ReadThroughUtxoStore
+func NewReadThroughUtxoStore(store *UtxoStore, preImp GetUtxoFromInterface) *ReadThroughUtxoStore {
+	return &ReadThroughUtxoStore{
+		store:  store,
+		preImp: NewGetUtxoFromV2(preImp, 8),
+	}
+}
+
+// GetUtxoFrom retrieves UTXO from store, fetches and saves it when missing
+//
+// GetUtxoFrom 从存储中检索 UTXO，缺失时获取并保存
+func (r *ReadThroughUtxoStore) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	results, err := r.GetUtxoFromList(context.Background(), []wire.OutPoint{utxo})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// GetUtxoFromList retrieves UTXOs from store, fetches all missing ones in one batch and saves them
+//
+// GetUtxoFromList 从存储中检索 UTXO，缺失的一次性批量获取并保存
+func (r *ReadThroughUtxoStore) GetUtxoFromList(ctx context.Context, utxos []wire.OutPoint) ([]*SenderAmountUtxo, error) {
+	results, missing, err := r.store.getUtxoFromList(ctx, utxos)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong read-store")
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+	var missingUtxos = make([]wire.OutPoint, 0, len(missing))
+	for _, idx := range missing {
+		missingUtxos = append(missingUtxos, utxos[idx])
+	}
+	fetched, err := r.preImp.GetUtxoFromList(ctx, missingUtxos)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong get-utxo-from")
+	}
+	if len(fetched) != len(missingUtxos) {
+		return nil, errors.Errorf("wrong utxo-from count: got %d, expected %d", len(fetched), len(missingUtxos))
+	}
+	if err := r.store.InsertList(missingUtxos, fetched); err != nil {
+		return nil, errors.WithMessage(err, "wrong write-store")
+	}
+	for pos, idx := range missing {
+		results[idx] = fetched[pos]
+	}
+	return results, nil
+}
+
+// utxoStoreKey is 32 bytes tx hash followed by 4 bytes big-endian output index
+// utxoStoreKey 是 32 字节的交易哈希加上 4 字节大端序的输出位置
+func utxoStoreKey(utxo wire.OutPoint) []byte {
+	var key = make([]byte, chainhash.HashSize+4)
+	copy(key, utxo.Hash[:])
+	binary.BigEndian.PutUint32(key[chainhash.HashSize:], utxo.Index)
+	return key
+}
+
+func readUtxoValue(bucket *bolt.Bucket, utxo wire.OutPoint) (*utxoStoreValue, bool, error) {
+	var value utxoStoreValue
+	data := bucket.Get(utxoStoreKey(utxo))
+	if data == nil {
+		return &value, false, nil
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, false, errors.WithMessagef(err, "wrong utxo[%s:%d] record", utxo.Hash.String(), utxo.Index)
+	}
+	return &value, true, nil
+}
+
+func writeUtxoValue(bucket *bolt.Bucket, utxo wire.OutPoint, value *utxoStoreValue) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.WithMessage(err, "wrong marshal-record")
+	}
+	return bucket.Put(utxoStoreKey(utxo), data)
+}
+
+func forEachUtxo(bucket *bolt.Bucket, run func(record *UtxoStoreRecord)) error {
+	return bucket.ForEach(func(key, data []byte) error {
+		if len(key) != chainhash.HashSize+4 {
+			return errors.Errorf("wrong utxo key length=%d", len(key))
+		}
+		var utxo wire.OutPoint
+		copy(utxo.Hash[:], key[:chainhash.HashSize])
+		utxo.Index = binary.BigEndian.Uint32(key[chainhash.HashSize:])
+
+		var value utxoStoreValue
+		if err := json.Unmarshal(data, &value); err != nil {
+			return errors.WithMessagef(err, "wrong utxo[%s:%d] record", utxo.Hash.String(), utxo.Index)
+		}
+		record, err := newUtxoStoreRecord(utxo, &value)
+		if err != nil {
+			return err
+		}
+		run(record)
+		return nil
+	})
+}
+
+func newUtxoStoreRecord(utxo wire.OutPoint, value *utxoStoreValue) (*UtxoStoreRecord, error) {
+	var pkScript []byte
+	if value.PkScript != "" {
+		data, err := hex.DecodeString(value.PkScript)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong utxo[%s:%d] pk-script", utxo.Hash.String(), utxo.Index)
+		}
+		pkScript = data
+	}
+	return &UtxoStoreRecord{
+		OutPoint:   utxo,
+		Sender:     AddressTuple{Address: value.Address, PkScript: pkScript},
+		Amount:     value.Amount,
+		SpentBy:    value.SpentBy,
+		ReservedBy: value.ReservedBy,
+	}, nil
+}
+
+func newUtxoStoreValue(record *UtxoStoreRecord) *utxoStoreValue {
+	return &utxoStoreValue{
+		Address:    record.Sender.Address,
+		PkScript:   hex.EncodeToString(record.Sender.PkScript),
+		Amount:     record.Amount,
+		SpentBy:    record.SpentBy,
+		ReservedBy: record.ReservedBy,
+	}
+}
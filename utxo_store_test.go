@@ -0,0 +1,133 @@
+package gobtcsign
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUtxoStore_GetUtxoFrom(t *testing.T) {
+	var _ GetUtxoFromInterface = &UtxoStore{}
+	var _ GetUtxoFromInterfaceV2 = &UtxoStore{}
+
+	path := filepath.Join(t.TempDir(), "utxos.db")
+	store, err := OpenUtxoStore(path)
+	require.NoError(t, err)
+
+	utxos := caseFakeUtxos()
+	sender := &AddressTuple{
+		Address:  "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap",
+		PkScript: MustGetPkScript(MustNewAddress("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", &chaincfg.TestNet3Params)),
+	}
+	require.NoError(t, store.Insert(utxos[0], NewSenderAmountUtxo(sender, 4900)))
+	require.NoError(t, store.Insert(utxos[1], NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), 4320)))
+	// Same UTXO with another amount is rejected
+	require.Error(t, store.Insert(utxos[1], NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), 1)))
+
+	_, err = store.GetUtxoFrom(utxos[2])
+	require.Error(t, err)
+	_, err = store.GetUtxoFromList(context.Background(), utxos)
+	require.Error(t, err)
+
+	// Records are kept after reopening the file
+	require.NoError(t, store.Close())
+	store, err = OpenUtxoStore(path)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	results, err := store.GetUtxoFromList(context.Background(), utxos[:2])
+	require.NoError(t, err)
+	require.Equal(t, sender, results[0].GetSender())
+	require.Equal(t, int64(4900), results[0].GetAmount())
+	require.Nil(t, results[1].GetSender().PkScript)
+	require.Equal(t, int64(4320), results[1].GetAmount())
+
+	require.NoError(t, store.Delete(utxos[1]))
+	_, err = store.GetUtxoFrom(utxos[1])
+	require.Error(t, err)
+}
+
+func TestUtxoStore_ReserveAndSpend(t *testing.T) {
+	store, err := OpenUtxoStore(filepath.Join(t.TempDir(), "utxos.db"))
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	utxos := caseFakeUtxos()
+	for idx, utxo := range utxos {
+		require.NoError(t, store.Insert(utxo, NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), int64(1000*(idx+1)))))
+	}
+
+	require.NoError(t, store.Reserve(utxos[:2], "withdraw-1"))
+	// Reserving again with the same id is fine, another id fails and reserves nothing
+	require.NoError(t, store.Reserve(utxos[:1], "withdraw-1"))
+	require.Error(t, store.Reserve([]wire.OutPoint{utxos[2], utxos[1]}, "withdraw-2"))
+
+	records, err := store.ListUnspent(false)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, utxos[2], records[0].OutPoint)
+
+	records, err = store.ListUnspent(true)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	spentBy := MustNewOutPoint("5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", 0).Hash
+	require.NoError(t, store.Spend(utxos[0], spentBy))
+	require.NoError(t, store.Spend(utxos[0], spentBy))
+	require.Error(t, store.Spend(utxos[0], utxos[1].Hash))
+	require.Error(t, store.Reserve(utxos[:1], "withdraw-2"))
+
+	record, err := store.GetRecord(utxos[0])
+	require.NoError(t, err)
+	require.Equal(t, spentBy.String(), record.SpentBy)
+	require.Empty(t, record.ReservedBy)
+
+	// Spent UTXOs are still readable, so signed txs spending them can be verified
+	utxoFrom, err := store.GetUtxoFrom(utxos[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), utxoFrom.GetAmount())
+
+	require.NoError(t, store.Release("withdraw-1"))
+	records, err = store.ListUnspent(false)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+}
+
+func TestReadThroughUtxoStore_GetUtxoFromList(t *testing.T) {
+	fake := newFakeBitcoind()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := rpcclient.New(newFakeBitcoindConfig(server), nil)
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	store, err := OpenUtxoStore(filepath.Join(t.TempDir(), "utxos.db"))
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	utxos := caseFakeUtxos()
+	require.NoError(t, store.Insert(utxos[1], NewSenderAmountUtxo(NewAddressTuple("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"), 4320)))
+
+	readThrough := NewReadThroughUtxoStore(store, NewSenderAmountUtxoClient(client))
+	for round := 0; round < 2; round++ {
+		results, err := readThrough.GetUtxoFromList(context.Background(), utxos)
+		require.NoError(t, err)
+		require.Equal(t, int64(4900), results[0].GetAmount())
+		require.Equal(t, int64(4320), results[1].GetAmount())
+		require.Equal(t, int64(100000), results[2].GetAmount())
+	}
+	// The first round fetches the missing tx once, the second round reads the store only
+	require.Equal(t, 1, fake.calls["fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328"])
+	require.Equal(t, 0, fake.calls["fcc889d7f0217694ab46d93f03a200d326c34e317552a6a33cb3fab03aa0b439"])
+
+	utxoFrom, err := store.GetUtxoFrom(utxos[2])
+	require.NoError(t, err)
+	require.Equal(t, "tb1qk0z8zhsq5hlewplv0039smnz62r2ujscz6gqjx", utxoFrom.GetSender().Address)
+}
@@ -0,0 +1,328 @@
+package gobtcsign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// UtxoTracker keeps spendable UTXOs of watched scripts by applying transactions
+// Both our own signed txs and incoming txs are applied, so the set follows the chain and mempool
+// UTXOs used by in-flight BitcoinTxParams can be locked, so concurrent withdrawals never pick the same coin
+//
+// UtxoTracker 通过应用交易来维护被监听脚本的可花费 UTXO
+// 我们自己签名的交易和收到的交易都需要应用，这样 UTXO 集合就能跟随链上和内存池的变化
+// 进行中的 BitcoinTxParams 所用的 UTXO 可以被锁定，这样并发的提现不会选中同一个币
+type UtxoTracker struct {
+	netParams *chaincfg.Params                    // Network to decode addresses // 解析地址所用的网络参数
+	mutex     sync.Mutex                          // Protects fields below // 保护以下字段
+	watched   map[string]*AddressTuple            // Watched pkScript hex -> owner // 被监听的公钥脚本十六进制 -> 持有者
+	utxos     map[wire.OutPoint]*AddressUtxo      // Unspent outputs // 未花费的输出
+	spentBy   map[wire.OutPoint]chainhash.Hash    // Outpoints spent by unconfirmed txs -> spending txid // 被未确认交易花费的输出 -> 花费它的交易哈希
+	spent     map[wire.OutPoint]*AddressUtxo      // UTXOs spent by unconfirmed txs, restored when the tx is removed // 被未确认交易花费的 UTXO，在交易被移除时恢复
+	txInputs  map[chainhash.Hash][]wire.OutPoint  // Unconfirmed txid -> its inputs // 未确认的交易哈希 -> 它的输入
+	children  map[chainhash.Hash][]chainhash.Hash // Parent txid -> unconfirmed txids spending its outputs // 父交易哈希 -> 花费其输出的未确认交易哈希
+	locks     map[wire.OutPoint]string            // Locked outpoints -> lock id // 已锁定的输出 -> 锁标识
+}
+
+// NewUtxoTracker creates empty UtxoTracker on network
+//
+// NewUtxoTracker 在指定网络上创建空的 UtxoTracker
+func NewUtxoTracker(netParams *chaincfg.Params) *UtxoTracker {
+	return &UtxoTracker{
+		netParams: netParams,
+		watched:   map[string]*AddressTuple{},
+		utxos:     map[wire.OutPoint]*AddressUtxo{},
+		spentBy:   map[wire.OutPoint]chainhash.Hash{},
+		spent:     map[wire.OutPoint]*AddressUtxo{},
+		txInputs:  map[chainhash.Hash][]wire.OutPoint{},
+		children:  map[chainhash.Hash][]chainhash.Hash{},
+		locks:     map[wire.OutPoint]string{},
+	}
+}
+
+// WatchAddress adds address to watched scripts, its outputs in applied txs become tracked UTXOs
+//
+// WatchAddress 把地址加入被监听的脚本，应用交易时其输出会成为被跟踪的 UTXO
+func (t *UtxoTracker) WatchAddress(address string) error {
+	pkScript, err := GetAddressPkScript(address, t.netParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong address")
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.watched[hex.EncodeToString(pkScript)] = &AddressTuple{Address: address, PkScript: pkScript}
+	return nil
+}
+
+// WatchPkScript adds raw pkScript to watched scripts, used for scripts without address
+//
+// WatchPkScript 把原始公钥脚本加入被监听的脚本，用于没有地址的脚本
+func (t *UtxoTracker) WatchPkScript(pkScript []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.watched[hex.EncodeToString(pkScript)] = &AddressTuple{PkScript: bytes.Clone(pkScript)}
+}
+
+// AddUtxo adds known UTXO, such as one listed by Esplora or Electrum when starting
+// Ignored when the outpoint is already known to be spent
+//
+// AddUtxo 添加已知的 UTXO，比如启动时从 Esplora 或 Electrum 列出的 UTXO
+// 当该输出已知被花费时忽略
+func (t *UtxoTracker) AddUtxo(utxo *AddressUtxo) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.spentBy[utxo.OutPoint]; ok {
+		return
+	}
+	one := *utxo
+	t.utxos[utxo.OutPoint] = &one
+}
+
+// ApplyTx applies transaction seen in mempool (blockHeight 0) or in block
+// Inputs spending tracked UTXOs are removed together with their locks,
+// outputs paying watched scripts are added, and applying again with height marks them confirmed
+// Tx spending an input already spent by another tx replaces it, such as RBF bump, the replaced tx is removed as RemoveTx does
+// Spends of confirmed txs are forgotten to keep memory bounded, so apply block txs in block order
+// Returns outpoints of new or updated UTXOs
+//
+// ApplyTx 应用在内存池（blockHeight 为 0）或区块里看到的交易
+// 花费被跟踪 UTXO 的输入会连同其锁一起移除，
+// 支付给被监听脚本的输出会被添加，带高度再次应用时会把它们标记为已确认
+// 花费了已被其它交易花费的输入的交易会替换那个交易，比如 RBF 加速，被替换的交易会像 RemoveTx 那样被移除
+// 为了让内存占用有上限，已确认交易的花费记录不会被保留，因此区块中的交易需要按区块内的顺序应用
+// 返回新增或更新的 UTXO 的位置
+func (t *UtxoTracker) ApplyTx(msgTx *wire.MsgTx, blockHeight int64) []wire.OutPoint {
+	txHash := msgTx.TxHash()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, txIn := range msgTx.TxIn {
+		if spender, ok := t.spentBy[txIn.PreviousOutPoint]; ok && spender != txHash {
+			t.removeTx(spender)
+		}
+	}
+	for _, txIn := range msgTx.TxIn {
+		utxo := txIn.PreviousOutPoint
+		if blockHeight > 0 {
+			// Confirmed tx is never replaced or removed, so nothing about its spends needs to be kept
+			// 已确认的交易不会被替换或移除，因此不需要保留它的任何花费记录
+			delete(t.spentBy, utxo)
+			delete(t.spent, utxo)
+		} else {
+			// Outpoints spent by txs not relevant to us are recorded too, since the child may arrive before the parent
+			// 与我们无关的交易所花费的输出也会被记录，因为子交易可能比父交易先到
+			t.spentBy[utxo] = txHash
+			if one, ok := t.utxos[utxo]; ok {
+				t.spent[utxo] = one
+			}
+			if !slices.Contains(t.children[utxo.Hash], txHash) {
+				t.children[utxo.Hash] = append(t.children[utxo.Hash], txHash)
+			}
+		}
+		delete(t.utxos, utxo)
+		delete(t.locks, utxo)
+	}
+	if blockHeight > 0 {
+		t.unlinkChild(txHash, txOutPoints(msgTx))
+		delete(t.txInputs, txHash)
+	} else {
+		t.txInputs[txHash] = txOutPoints(msgTx)
+	}
+
+	var changes []wire.OutPoint
+	for idx, txOut := range msgTx.TxOut {
+		owner, ok := t.watched[hex.EncodeToString(txOut.PkScript)]
+		if !ok {
+			continue
+		}
+		outPoint := *wire.NewOutPoint(&txHash, uint32(idx))
+		if _, spent := t.spentBy[outPoint]; spent {
+			continue
+		}
+		t.utxos[outPoint] = &AddressUtxo{
+			OutPoint:    outPoint,
+			Sender:      *owner,
+			Amount:      txOut.Value,
+			Confirmed:   blockHeight > 0,
+			BlockHeight: max(blockHeight, 0),
+		}
+		changes = append(changes, outPoint)
+	}
+	return changes
+}
+
+// RemoveTx removes unconfirmed tx evicted from mempool together with its descendants
+// Their outputs are dropped and the tracked UTXOs they spent become spendable again
+//
+// RemoveTx 移除被内存池驱逐的未确认交易及其后代交易
+// 它们的输出会被丢弃，它们花费的被跟踪 UTXO 重新变为可花费
+func (t *UtxoTracker) RemoveTx(txHash chainhash.Hash) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.removeTx(txHash)
+}
+
+// removeTx removes tx and its descendants, caller holds the mutex
+// removeTx 移除交易及其后代交易，调用方需持有锁
+func (t *UtxoTracker) removeTx(txHash chainhash.Hash) {
+	// Children first, so the outputs they restore are dropped below
+	// 先移除子交易，这样它们恢复的输出会在下面被丢弃
+	for _, child := range slices.Clone(t.children[txHash]) {
+		t.removeTx(child)
+	}
+	delete(t.children, txHash)
+
+	utxos := t.txInputs[txHash]
+	for _, utxo := range utxos {
+		if t.spentBy[utxo] != txHash {
+			continue
+		}
+		delete(t.spentBy, utxo)
+		if one, ok := t.spent[utxo]; ok {
+			t.utxos[utxo] = one
+			delete(t.spent, utxo)
+		}
+	}
+	t.unlinkChild(txHash, utxos)
+	delete(t.txInputs, txHash)
+
+	for utxo := range t.utxos {
+		if utxo.Hash == txHash {
+			delete(t.utxos, utxo)
+			delete(t.locks, utxo)
+		}
+	}
+}
+
+// unlinkChild drops txHash from children index of the parents of its inputs, caller holds the mutex
+// unlinkChild 从其输入所属父交易的子交易索引中删除 txHash，调用方需持有锁
+func (t *UtxoTracker) unlinkChild(txHash chainhash.Hash, utxos []wire.OutPoint) {
+	for _, utxo := range utxos {
+		children := slices.DeleteFunc(t.children[utxo.Hash], func(child chainhash.Hash) bool {
+			return child == txHash
+		})
+		if len(children) == 0 {
+			delete(t.children, utxo.Hash)
+		} else {
+			t.children[utxo.Hash] = children
+		}
+	}
+}
+
+// txOutPoints returns previous outpoints of tx inputs
+// txOutPoints 返回交易输入的前置输出位置
+func txOutPoints(msgTx *wire.MsgTx) []wire.OutPoint {
+	var utxos = make([]wire.OutPoint, 0, len(msgTx.TxIn))
+	for _, txIn := range msgTx.TxIn {
+		utxos = append(utxos, txIn.PreviousOutPoint)
+	}
+	return utxos
+}
+
+// LockParams locks all inputs of in-flight BitcoinTxParams with lockID, all or nothing
+// Fails when any input is not a tracked UTXO or is locked with another id
+//
+// LockParams 使用 lockID 锁定进行中的 BitcoinTxParams 的全部输入，要么全部成功要么全部失败
+// 当任一输入不是被跟踪的 UTXO 或已被其它标识锁定时失败
+func (t *UtxoTracker) LockParams(param *BitcoinTxParams, lockID string) error {
+	if lockID == "" {
+		return errors.New("wrong lock-id is empty")
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, vin := range param.VinList {
+		utxo := vin.OutPoint
+		if _, ok := t.utxos[utxo]; !ok {
+			return errors.Errorf("wrong utxo[%s:%d] not-tracked-or-spent", utxo.Hash.String(), utxo.Index)
+		}
+		if owner, ok := t.locks[utxo]; ok && owner != lockID {
+			return errors.Errorf("wrong utxo[%s:%d] already locked by %s", utxo.Hash.String(), utxo.Index, owner)
+		}
+	}
+	for _, vin := range param.VinList {
+		t.locks[vin.OutPoint] = lockID
+	}
+	return nil
+}
+
+// Unlock releases all UTXOs locked with lockID, used when the withdrawal is given up
+//
+// Unlock 释放所有被 lockID 锁定的 UTXO，在放弃提现时使用
+func (t *UtxoTracker) Unlock(lockID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for utxo, owner := range t.locks {
+		if owner == lockID {
+			delete(t.locks, utxo)
+		}
+	}
+}
+
+// ListUnspent returns unlocked UTXOs ordered by outpoint, unconfirmed ones are skipped when confirmedOnly is true
+//
+// ListUnspent 返回按位置排序的未锁定 UTXO，当 confirmedOnly 为 true 时跳过未确认的
+func (t *UtxoTracker) ListUnspent(confirmedOnly bool) []*AddressUtxo {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var results = make([]*AddressUtxo, 0, len(t.utxos))
+	for utxo, one := range t.utxos {
+		if _, locked := t.locks[utxo]; locked {
+			continue
+		}
+		if confirmedOnly && !one.Confirmed {
+			continue
+		}
+		res := *one
+		results = append(results, &res)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if cmp := bytes.Compare(results[i].OutPoint.Hash[:], results[j].OutPoint.Hash[:]); cmp != 0 {
+			return cmp < 0
+		}
+		return results[i].OutPoint.Index < results[j].OutPoint.Index
+	})
+	return results
+}
+
+// GetBalance returns sums of confirmed and unconfirmed UTXOs, locked ones included
+//
+// GetBalance 返回已确认和未确认 UTXO 的总额，包括已锁定的
+func (t *UtxoTracker) GetBalance() (confirmed int64, unconfirmed int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, one := range t.utxos {
+		if one.Confirmed {
+			confirmed += one.Amount
+		} else {
+			unconfirmed += one.Amount
+		}
+	}
+	return confirmed, unconfirmed
+}
+
+// GetUtxoFrom retrieves tracked UTXO, locked ones included
+// Returns error if UTXO is not tracked or already spent
+//
+// GetUtxoFrom 检索被跟踪的 UTXO，包括已锁定的
+// 如果 UTXO 未被跟踪或已被花费则返回错误
+func (t *UtxoTracker) GetUtxoFrom(utxo wire.OutPoint) (*SenderAmountUtxo, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	one, ok := t.utxos[utxo]
+	if !ok {
+		return nil, errors.Errorf("wrong utxo[%s:%d] not-tracked-or-spent", utxo.Hash.String(), utxo.Index)
+	}
+	sender := one.Sender
+	return NewSenderAmountUtxo(&sender, one.Amount), nil
+}
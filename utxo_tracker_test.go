@@ -0,0 +1,230 @@
+package gobtcsign
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUtxoTracker_ApplyTx(t *testing.T) {
+	var _ GetUtxoFromInterface = &UtxoTracker{}
+
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	const privateKeyHex = "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092"
+	netParams := chaincfg.TestNet3Params
+
+	tracker := NewUtxoTracker(&netParams)
+	require.NoError(t, tracker.WatchAddress(senderAddress))
+
+	// An incoming tx pays us two outputs, first seen in mempool then in block
+	incomingTx := caseElectrumPreviousTx(t, &netParams, senderAddress, 4900, 4320)
+	require.Len(t, tracker.ApplyTx(incomingTx, 0), 2)
+	confirmed, unconfirmed := tracker.GetBalance()
+	require.Equal(t, int64(0), confirmed)
+	require.Equal(t, int64(9220), unconfirmed)
+
+	require.Len(t, tracker.ApplyTx(incomingTx, 3503553), 2)
+	confirmed, unconfirmed = tracker.GetBalance()
+	require.Equal(t, int64(9220), confirmed)
+	require.Equal(t, int64(0), unconfirmed)
+
+	utxos := tracker.ListUnspent(true)
+	require.Len(t, utxos, 2)
+	require.Equal(t, int64(3503553), utxos[0].BlockHeight)
+
+	param := &BitcoinTxParams{
+		OutList: []OutType{
+			{Target: *NewAddressTuple("tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q"), Amount: 5000},
+			{Target: *NewAddressTuple(senderAddress), Amount: 3000},
+		},
+		RBFInfo: *NewRBFActive(),
+	}
+	for _, utxo := range utxos {
+		param.VinList = append(param.VinList, utxo.GetVinType(*NewRBFActive()))
+	}
+	require.NoError(t, tracker.LockParams(param, "withdraw-1"))
+	require.Empty(t, tracker.ListUnspent(false))
+
+	signParam, err := param.CreateTxSignParams(&netParams)
+	require.NoError(t, err)
+	require.NoError(t, Sign(senderAddress, privateKeyHex, signParam))
+
+	// The tracker provides previous outputs to verify our own signed tx
+	customParam, err := NewCustomParamFromMsgTx(signParam.MsgTx, tracker)
+	require.NoError(t, err)
+	require.NoError(t, customParam.VerifyMsgTxSign(signParam.MsgTx, &netParams))
+
+	// Our tx spends both inputs and its change output becomes an unconfirmed UTXO
+	changes := tracker.ApplyTx(signParam.MsgTx, 0)
+	require.Equal(t, []wire.OutPoint{*MustNewOutPoint(GetTxHash(signParam.MsgTx), 1)}, changes)
+	confirmed, unconfirmed = tracker.GetBalance()
+	require.Equal(t, int64(0), confirmed)
+	require.Equal(t, int64(3000), unconfirmed)
+	require.Empty(t, tracker.ListUnspent(true))
+	require.Len(t, tracker.ListUnspent(false), 1)
+
+	_, err = tracker.GetUtxoFrom(utxos[0].OutPoint)
+	require.Error(t, err)
+
+	// Applying an old listing of the spent outputs does not bring them back
+	tracker.AddUtxo(utxos[0])
+	require.Len(t, tracker.ListUnspent(false), 1)
+}
+
+func TestUtxoTracker_LockParams(t *testing.T) {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	netParams := chaincfg.TestNet3Params
+
+	tracker := NewUtxoTracker(&netParams)
+	require.NoError(t, tracker.WatchAddress(senderAddress))
+	tracker.ApplyTx(caseElectrumPreviousTx(t, &netParams, senderAddress, 4900, 4320, 10000), 100)
+
+	utxos := tracker.ListUnspent(false)
+	require.Len(t, utxos, 3)
+
+	// Two withdrawals pick overlapping coins at the same time, only one of them locks
+	var wg sync.WaitGroup
+	var errs = make([]error, 2)
+	for idx := range errs {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			param := &BitcoinTxParams{VinList: []VinType{
+				utxos[idx].GetVinType(*NewRBFActive()),
+				utxos[2].GetVinType(*NewRBFActive()),
+			}}
+			errs[idx] = tracker.LockParams(param, []string{"withdraw-1", "withdraw-2"}[idx])
+		}(idx)
+	}
+	wg.Wait()
+	require.True(t, (errs[0] == nil) != (errs[1] == nil))
+	require.Len(t, tracker.ListUnspent(false), 1)
+
+	tracker.Unlock("withdraw-1")
+	tracker.Unlock("withdraw-2")
+	require.Len(t, tracker.ListUnspent(false), 3)
+
+	// Untracked coins can not be locked
+	err := tracker.LockParams(&BitcoinTxParams{VinList: []VinType{{OutPoint: *MustNewOutPoint("5c98431bbb271ea3652168d2b4da8a76573fd8fec104e73f6f6f3a7c6fe6b97d", 0)}}}, "withdraw-3")
+	require.Error(t, err)
+}
+
+func TestUtxoTracker_ChildBeforeParent(t *testing.T) {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	netParams := chaincfg.TestNet3Params
+
+	tracker := NewUtxoTracker(&netParams)
+	tracker.WatchPkScript(MustGetPkScript(MustNewAddress(senderAddress, &netParams)))
+
+	parentTx := caseElectrumPreviousTx(t, &netParams, senderAddress, 4900)
+	parentHash := parentTx.TxHash()
+	childTx := wire.NewMsgTx(wire.TxVersion)
+	childTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&parentHash, 0), nil, nil))
+	childTx.AddTxOut(wire.NewTxOut(4000, MustGetPkScript(MustNewAddress("tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q", &netParams))))
+
+	require.Empty(t, tracker.ApplyTx(childTx, 0))
+	require.Empty(t, tracker.ApplyTx(parentTx, 0))
+	require.Empty(t, tracker.ListUnspent(false))
+}
+
+func TestUtxoTracker_ReplaceAndRemoveTx(t *testing.T) {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	netParams := chaincfg.TestNet3Params
+	senderPkScript := MustGetPkScript(MustNewAddress(senderAddress, &netParams))
+	targetPkScript := MustGetPkScript(MustNewAddress("tb1qlj64u6fqutr0xue85kl55fx0gt4m4urun25p7q", &netParams))
+
+	tracker := NewUtxoTracker(&netParams)
+	require.NoError(t, tracker.WatchAddress(senderAddress))
+	incomingTx := caseElectrumPreviousTx(t, &netParams, senderAddress, 4900, 4320)
+	tracker.ApplyTx(incomingTx, 100)
+	utxos := tracker.ListUnspent(true)
+	require.Len(t, utxos, 2)
+
+	newSpendTx := func(changeAmount int64, utxos ...wire.OutPoint) *wire.MsgTx {
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		for idx := range utxos {
+			msgTx.AddTxIn(wire.NewTxIn(&utxos[idx], nil, nil))
+		}
+		msgTx.AddTxOut(wire.NewTxOut(5000, targetPkScript))
+		msgTx.AddTxOut(wire.NewTxOut(changeAmount, senderPkScript))
+		return msgTx
+	}
+
+	// Our withdrawal and a child spending its change are in mempool
+	originTx := newSpendTx(3000, utxos[0].OutPoint, utxos[1].OutPoint)
+	originChange := tracker.ApplyTx(originTx, 0)
+	require.Len(t, originChange, 1)
+	childTx := newSpendTx(1000, originChange[0])
+	childTx.TxOut[0].Value = 1000
+	require.Len(t, tracker.ApplyTx(childTx, 0), 1)
+	_, unconfirmed := tracker.GetBalance()
+	require.Equal(t, int64(1000), unconfirmed)
+
+	// RBF bump replaces our withdrawal, outputs of the replaced tx and its child are dropped
+	bumpTx := newSpendTx(2500, utxos[0].OutPoint, utxos[1].OutPoint)
+	bumpChange := tracker.ApplyTx(bumpTx, 0)
+	confirmed, unconfirmed := tracker.GetBalance()
+	require.Equal(t, int64(0), confirmed)
+	require.Equal(t, int64(2500), unconfirmed)
+	require.Len(t, tracker.ListUnspent(false), 1)
+	require.Error(t, tracker.LockParams(&BitcoinTxParams{VinList: []VinType{{OutPoint: originChange[0]}}}, "withdraw-1"))
+	require.NoError(t, tracker.LockParams(&BitcoinTxParams{VinList: []VinType{{OutPoint: bumpChange[0]}}}, "withdraw-1"))
+
+	// The bump is evicted, the coins it spent come back
+	tracker.RemoveTx(bumpTx.TxHash())
+	confirmed, unconfirmed = tracker.GetBalance()
+	require.Equal(t, int64(9220), confirmed)
+	require.Equal(t, int64(0), unconfirmed)
+	require.Equal(t, utxos, tracker.ListUnspent(true))
+
+	// Applying the old tx again in block works after eviction
+	require.Len(t, tracker.ApplyTx(originTx, 101), 1)
+	confirmed, _ = tracker.GetBalance()
+	require.Equal(t, int64(3000), confirmed)
+}
+
+func TestUtxoTracker_ConfirmForgetsSpends(t *testing.T) {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	netParams := chaincfg.TestNet3Params
+	senderPkScript := MustGetPkScript(MustNewAddress(senderAddress, &netParams))
+
+	tracker := NewUtxoTracker(&netParams)
+	require.NoError(t, tracker.WatchAddress(senderAddress))
+	incomingTx := caseElectrumPreviousTx(t, &netParams, senderAddress, 4900, 4320)
+	tracker.ApplyTx(incomingTx, 100)
+	utxos := tracker.ListUnspent(true)
+	require.Len(t, utxos, 2)
+
+	originTx := wire.NewMsgTx(wire.TxVersion)
+	originTx.AddTxIn(wire.NewTxIn(&utxos[0].OutPoint, nil, nil))
+	originTx.AddTxIn(wire.NewTxIn(&utxos[1].OutPoint, nil, nil))
+	originTx.AddTxOut(wire.NewTxOut(9000, senderPkScript))
+	originChange := tracker.ApplyTx(originTx, 0)
+	require.Len(t, originChange, 1)
+
+	childTx := wire.NewMsgTx(wire.TxVersion)
+	childTx.AddTxIn(wire.NewTxIn(&originChange[0], nil, nil))
+	childTx.AddTxOut(wire.NewTxOut(8000, senderPkScript))
+	require.Len(t, tracker.ApplyTx(childTx, 0), 1)
+
+	require.Len(t, tracker.spentBy, 3)
+	require.Equal(t, map[chainhash.Hash][]chainhash.Hash{
+		incomingTx.TxHash(): {originTx.TxHash()},
+		originTx.TxHash():   {childTx.TxHash()},
+	}, tracker.children)
+
+	// Once both txs confirm, nothing about their spends is kept
+	tracker.ApplyTx(originTx, 101)
+	tracker.ApplyTx(childTx, 101)
+	require.Empty(t, tracker.spentBy)
+	require.Empty(t, tracker.spent)
+	require.Empty(t, tracker.txInputs)
+	require.Empty(t, tracker.children)
+	confirmed, unconfirmed := tracker.GetBalance()
+	require.Equal(t, int64(8000), confirmed)
+	require.Equal(t, int64(0), unconfirmed)
+}
@@ -0,0 +1,122 @@
+package gobtcsign
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// Verifier verifies signatures of all inputs against prevouts from one PrevOutputFetcher
+// The fetcher must know every prevout of the tx with its real amount:
+// SegWit v0 signatures commit to the spent amount, Taproot signatures commit to amounts and scripts of all inputs
+//
+// Verifier 使用同一个 PrevOutputFetcher 提供的前置输出验证全部输入的签名
+// 提取器必须知道交易的每个前置输出及其真实数量：
+// SegWit v0 的签名包含被花费的数量，Taproot 的签名包含全部输入的数量和脚本
+type Verifier struct {
+	prevOutFetcher txscript.PrevOutputFetcher // Prevouts of all inputs // 全部输入的前置输出
+	flags          txscript.ScriptFlags       // Script engine flags // 脚本引擎的标志
+}
+
+// NewVerifier creates Verifier with prevout fetcher and script flags, txscript.StandardVerifyFlags is the usual choice
+//
+// NewVerifier 使用前置输出提取器和脚本标志创建 Verifier，通常使用 txscript.StandardVerifyFlags
+func NewVerifier(prevOutFetcher txscript.PrevOutputFetcher, flags txscript.ScriptFlags) *Verifier {
+	return &Verifier{prevOutFetcher: prevOutFetcher, flags: flags}
+}
+
+// Verify checks all inputs and returns SignatureInvalidError of the first input not valid
+//
+// Verify 检查全部输入并返回第一个无效输入的 SignatureInvalidError
+func (v *Verifier) Verify(msgTx *wire.MsgTx) error {
+	report, err := v.VerifyReport(msgTx)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+// VerifyReport checks all inputs and reports result of each input
+//
+// VerifyReport 检查全部输入并报告每个输入的结果
+func (v *Verifier) VerifyReport(msgTx *wire.MsgTx) (*VerifyReport, error) {
+	var inputOuts = make([]*wire.TxOut, 0, len(msgTx.TxIn))
+	for idx, txIn := range msgTx.TxIn {
+		prevOut := v.prevOutFetcher.FetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOut == nil {
+			return nil, errors.Errorf("wrong prev-out of input %d utxo[%s] not found", idx, txIn.PreviousOutPoint.String())
+		}
+		inputOuts = append(inputOuts, prevOut)
+	}
+	sigHashes := txscript.NewTxSigHashes(msgTx, v.prevOutFetcher)
+	return verifySignReport(msgTx, inputOuts, v.prevOutFetcher, sigHashes, v.flags)
+}
+
+// NewPrevOutFetcherFromInputOuts creates prevout fetcher from inputOuts in the same order as tx inputs
+//
+// NewPrevOutFetcherFromInputOuts 根据和交易输入顺序相同的 inputOuts 创建前置输出提取器
+func NewPrevOutFetcherFromInputOuts(msgTx *wire.MsgTx, inputOuts []*wire.TxOut) (*txscript.MultiPrevOutFetcher, error) {
+	if len(inputOuts) != len(msgTx.TxIn) {
+		return nil, &InputMismatchError{Index: -1, Field: "count", Got: strconv.Itoa(len(msgTx.TxIn)), Expected: strconv.Itoa(len(inputOuts))}
+	}
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for idx, txIn := range msgTx.TxIn {
+		prevOutFetcher.AddPrevOut(txIn.PreviousOutPoint, inputOuts[idx])
+	}
+	return prevOutFetcher, nil
+}
+
+// NewPrevOutFetcherFromUtxoFrom fetches all prevouts of tx with UTXO source and creates prevout fetcher
+// Use NewGetUtxoFromV2 to pass GetUtxoFromInterface implementations
+//
+// NewPrevOutFetcherFromUtxoFrom 使用 UTXO 数据源获取交易的全部前置输出并创建前置输出提取器
+// 使用 NewGetUtxoFromV2 传入 GetUtxoFromInterface 的实现
+func NewPrevOutFetcherFromUtxoFrom(ctx context.Context, msgTx *wire.MsgTx, preImp GetUtxoFromInterfaceV2, netParams *chaincfg.Params) (*txscript.MultiPrevOutFetcher, error) {
+	var utxos = make([]wire.OutPoint, 0, len(msgTx.TxIn))
+	for _, txIn := range msgTx.TxIn {
+		utxos = append(utxos, txIn.PreviousOutPoint)
+	}
+	utxoFroms, err := preImp.GetUtxoFromList(ctx, utxos)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong get-utxo-from")
+	}
+	if len(utxoFroms) != len(utxos) {
+		return nil, errors.Errorf("wrong utxo-from count: got %d, expected %d", len(utxoFroms), len(utxos))
+	}
+	var inputOuts = make([]*wire.TxOut, 0, len(utxoFroms))
+	for idx, utxoFrom := range utxoFroms {
+		pkScript, err := utxoFrom.sender.GetPkScript(netParams)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "wrong sender.address->pk-script. index=%d", idx)
+		}
+		inputOuts = append(inputOuts, wire.NewTxOut(utxoFrom.amount, pkScript))
+	}
+	return NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+}
+
+// VerifySignWithUtxoFrom verifies tx with prevouts from UTXO source using standard flags
+//
+// VerifySignWithUtxoFrom 使用 UTXO 数据源提供的前置输出和标准标志验证交易
+func VerifySignWithUtxoFrom(ctx context.Context, msgTx *wire.MsgTx, preImp GetUtxoFromInterfaceV2, netParams *chaincfg.Params) error {
+	prevOutFetcher, err := NewPrevOutFetcherFromUtxoFrom(ctx, msgTx, preImp, netParams)
+	if err != nil {
+		return errors.WithMessage(err, "wrong new-prev-out-fetcher")
+	}
+	return NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).Verify(msgTx)
+}
+
+// newInputOutsFromScripts creates inputOuts from pkScripts and amounts, counts must match tx inputs
+// newInputOutsFromScripts 根据公钥脚本和数量创建 inputOuts，数量必须和交易输入相同
+func newInputOutsFromScripts(msgTx *wire.MsgTx, prevScripts [][]byte, inputValues []btcutil.Amount) ([]*wire.TxOut, error) {
+	for _, count := range []int{len(prevScripts), len(inputValues)} {
+		if count != len(msgTx.TxIn) {
+			return nil, &InputMismatchError{Index: -1, Field: "count", Got: strconv.Itoa(len(msgTx.TxIn)), Expected: strconv.Itoa(count)}
+		}
+	}
+	return NewInputOutsV2(prevScripts, inputValues), nil
+}
@@ -0,0 +1,100 @@
+package gobtcsign
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// caseTaprootMsgTx signs tx spending one P2TR and one P2WPKH input, returns tx and prevouts
+func caseTaprootMsgTx(t *testing.T) (*wire.MsgTx, []*wire.TxOut) {
+	netParams := &chaincfg.TestNet3Params
+
+	privKeyBytes, err := hex.DecodeString("54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092")
+	require.NoError(t, err)
+	privKey, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+
+	taprootKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+	taprootAddress, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(taprootKey), netParams)
+	require.NoError(t, err)
+	senderScript := MustGetPkScript(MustNewAddress("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", netParams))
+
+	inputOuts := []*wire.TxOut{
+		wire.NewTxOut(10000, MustGetPkScript(taprootAddress)),
+		wire.NewTxOut(4900, senderScript),
+	}
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", 0), nil, nil))
+	msgTx.AddTxIn(wire.NewTxIn(MustNewOutPoint("fcc889d7f0217694ab46d93f03a200d326c34e317552a6a33cb3fab03aa0b439", 1), nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(14000, senderScript))
+
+	prevOutFetcher, err := NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+	require.NoError(t, err)
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+
+	witness, err := txscript.TaprootWitnessSignature(msgTx, sigHashes, 0, inputOuts[0].Value, inputOuts[0].PkScript, txscript.SigHashDefault, privKey)
+	require.NoError(t, err)
+	msgTx.TxIn[0].Witness = witness
+	witness, err = txscript.WitnessSignature(msgTx, sigHashes, 1, inputOuts[1].Value, inputOuts[1].PkScript, txscript.SigHashAll, privKey, true)
+	require.NoError(t, err)
+	msgTx.TxIn[1].Witness = witness
+	return msgTx, inputOuts
+}
+
+func TestVerifier_Taproot(t *testing.T) {
+	msgTx, inputOuts := caseTaprootMsgTx(t)
+
+	prevOutFetcher, err := NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+	require.NoError(t, err)
+	require.NoError(t, NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).Verify(msgTx))
+
+	report, err := NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).VerifyReport(msgTx)
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	require.Equal(t, "DEFAULT", report.Inputs[0].SigHashType)
+
+	// Taproot signature commits to amounts of all inputs, so a wrong amount of the P2WPKH input breaks both
+	wrongOuts := []*wire.TxOut{inputOuts[0], wire.NewTxOut(0, inputOuts[1].PkScript)}
+	prevOutFetcher, err = NewPrevOutFetcherFromInputOuts(msgTx, wrongOuts)
+	require.NoError(t, err)
+	report, err = NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).VerifyReport(msgTx)
+	require.NoError(t, err)
+	require.Equal(t, InputSignInvalid, report.Inputs[0].Status)
+	require.Equal(t, InputSignInvalid, report.Inputs[1].Status)
+
+	// Every prevout is required
+	partialFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	partialFetcher.AddPrevOut(msgTx.TxIn[0].PreviousOutPoint, inputOuts[0])
+	require.ErrorContains(t, NewVerifier(partialFetcher, txscript.StandardVerifyFlags).Verify(msgTx), "not found")
+}
+
+func TestVerifySignWithUtxoFrom(t *testing.T) {
+	msgTx, inputOuts := caseTaprootMsgTx(t)
+
+	utxoMap := map[wire.OutPoint]*SenderAmountUtxo{}
+	for idx, txIn := range msgTx.TxIn {
+		utxoMap[txIn.PreviousOutPoint] = NewSenderAmountUtxo(&AddressTuple{PkScript: inputOuts[idx].PkScript}, inputOuts[idx].Value)
+	}
+	preImp := NewGetUtxoFromV2(NewSenderAmountUtxoCache(utxoMap), 1)
+	require.NoError(t, VerifySignWithUtxoFrom(context.Background(), msgTx, preImp, &chaincfg.TestNet3Params))
+
+	// VerifySignV4 keeps working as a wrapper
+	var prevScripts [][]byte
+	var inputValues []btcutil.Amount
+	for _, inputOut := range inputOuts {
+		prevScripts = append(prevScripts, inputOut.PkScript)
+		inputValues = append(inputValues, btcutil.Amount(inputOut.Value))
+	}
+	require.NoError(t, VerifySignV4(msgTx, prevScripts, inputValues))
+
+	// Amount 0 is only right for P2PKH
+	require.ErrorIs(t, VerifyP2PKHSign(msgTx, []string{"tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"}, &chaincfg.TestNet3Params), ErrUnsupportedAddressType)
+}
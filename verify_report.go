@@ -0,0 +1,274 @@
+package gobtcsign
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// InputSignStatus represents signing state of one input
+//
+// InputSignStatus 代表单个输入的签名状态
+type InputSignStatus string
+
+const (
+	InputSignUnsigned InputSignStatus = "unsigned" // No signature script and no witness // 没有签名脚本也没有见证
+	InputSignPartial  InputSignStatus = "partial"  // Multisig with fewer signatures than required // 多签的签名数量少于要求
+	InputSignValid    InputSignStatus = "valid"    // Script engine accepts input // 脚本引擎接受了输入
+	InputSignInvalid  InputSignStatus = "invalid"  // Script engine rejects input // 脚本引擎拒绝了输入
+)
+
+// InputVerifyResult represents verification result of one input
+//
+// InputVerifyResult 代表单个输入的验签结果
+type InputVerifyResult struct {
+	Index       int             `json:"index"`                  // Input index // 输入的序号
+	OutPoint    string          `json:"outpoint"`               // Spent utxo as txid:vout // 花费的 utxo，格式是 txid:vout
+	Amount      int64           `json:"amount"`                 // Spent amount in satoshis // 花费的聪的数量
+	PkScript    string          `json:"pk_script"`              // Spent pk-script hex // 花费的公钥脚本十六进制
+	ScriptType  string          `json:"script_type"`            // Script class such as witness_v0_keyhash // 脚本类型，比如 witness_v0_keyhash
+	SigHashType string          `json:"sighash_type,omitempty"` // Sighash of first signature such as ALL // 第一个签名的 sighash，比如 ALL
+	Status      InputSignStatus `json:"status"`                 // Signing state // 签名状态
+	Error       string          `json:"error,omitempty"`        // Engine error text // 引擎错误的文本
+	Err         error           `json:"-"`                      // Engine error // 引擎错误
+}
+
+// VerifyReport represents verification results of all inputs, unlike VerifySign it does not stop at the first failure
+//
+// VerifyReport 代表全部输入的验签结果，和 VerifySign 不同，它不会在第一个失败处停止
+type VerifyReport struct {
+	Txid   string               `json:"txid"`   // Tx hash // 交易哈希
+	Valid  bool                 `json:"valid"`  // All inputs valid // 全部输入都有效
+	Inputs []*InputVerifyResult `json:"inputs"` // Per-input results // 每个输入的结果
+}
+
+// Err returns SignatureInvalidError of the first input not valid, nil when all inputs are valid
+//
+// Err 返回第一个无效输入的 SignatureInvalidError，当全部输入都有效时返回 nil
+func (r *VerifyReport) Err() error {
+	for _, one := range r.Inputs {
+		if one.Status != InputSignValid {
+			err := one.Err
+			if err == nil {
+				err = errors.Errorf("input is %s", one.Status)
+			}
+			return &SignatureInvalidError{Index: one.Index, Err: err}
+		}
+	}
+	return nil
+}
+
+// ToJSON returns indented JSON of the report, used in audit logs
+//
+// ToJSON 返回报告的缩进 JSON，用于审计日志
+func (r *VerifyReport) ToJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong marshal-report")
+	}
+	return data, nil
+}
+
+// VerifySignReport checks every input like VerifySign and reports each result instead of returning the first error
+//
+// VerifySignReport 像 VerifySign 一样检查每个输入，但是报告每个结果而不是返回第一个错误
+func VerifySignReport(msgTx *wire.MsgTx, inputOuts []*wire.TxOut, prevOutFetcher txscript.PrevOutputFetcher, sigHashes *txscript.TxSigHashes) (*VerifyReport, error) {
+	return verifySignReport(msgTx, inputOuts, prevOutFetcher, sigHashes, txscript.StandardVerifyFlags)
+}
+
+// verifySignReport checks every input with script flags, shared by VerifySignReport and Verifier
+// verifySignReport 使用脚本标志检查每个输入，由 VerifySignReport 和 Verifier 共用
+func verifySignReport(msgTx *wire.MsgTx, inputOuts []*wire.TxOut, prevOutFetcher txscript.PrevOutputFetcher, sigHashes *txscript.TxSigHashes, flags txscript.ScriptFlags) (*VerifyReport, error) {
+	if len(inputOuts) < len(msgTx.TxIn) {
+		return nil, &InputMismatchError{Index: -1, Field: "count", Got: strconv.Itoa(len(msgTx.TxIn)), Expected: strconv.Itoa(len(inputOuts))}
+	}
+	sigCache := txscript.NewSigCache(uint(len(msgTx.TxIn)))
+
+	var report = &VerifyReport{
+		Txid:   GetTxHash(msgTx),
+		Valid:  true,
+		Inputs: make([]*InputVerifyResult, 0, len(msgTx.TxIn)),
+	}
+	for idx, txIn := range msgTx.TxIn {
+		inputOut := inputOuts[idx]
+		res := &InputVerifyResult{
+			Index:       idx,
+			OutPoint:    txIn.PreviousOutPoint.String(),
+			Amount:      inputOut.Value,
+			PkScript:    hex.EncodeToString(inputOut.PkScript),
+			ScriptType:  txscript.GetScriptClass(inputOut.PkScript).String(),
+			SigHashType: getInputSigHashType(txIn, inputOut.PkScript),
+		}
+		report.Inputs = append(report.Inputs, res)
+
+		if len(txIn.SignatureScript) == 0 && len(txIn.Witness) == 0 {
+			res.Status = InputSignUnsigned
+			report.Valid = false
+			continue
+		}
+		vm, err := txscript.NewEngine(inputOut.PkScript, msgTx, idx, flags, sigCache, sigHashes, inputOut.Value, prevOutFetcher)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err == nil {
+			res.Status = InputSignValid
+			continue
+		}
+		report.Valid = false
+		res.Err = err
+		res.Error = err.Error()
+		if isPartialMultiSig(txIn, inputOut.PkScript) {
+			res.Status = InputSignPartial
+		} else {
+			res.Status = InputSignInvalid
+		}
+	}
+	return report, nil
+}
+
+// VerifySignReportV2 is the report variant of VerifySignV2
+//
+// VerifySignReportV2 是 VerifySignV2 的报告版本
+func VerifySignReportV2(msgTx *wire.MsgTx, inputList []*VerifyTxInputParam, netParams *chaincfg.Params) (*VerifyReport, error) {
+	var pkScripts = make([][]byte, 0, len(inputList))
+	var inAmounts = make([]btcutil.Amount, 0, len(inputList))
+	for idx := range inputList {
+		pkScript, err := inputList[idx].Sender.GetPkScript(netParams)
+		if err != nil {
+			return nil, errors.WithMessage(err, "wrong address->pk-script")
+		}
+		pkScripts = append(pkScripts, pkScript)
+		inAmounts = append(inAmounts, btcutil.Amount(inputList[idx].Amount))
+	}
+	return VerifySignReportV4(msgTx, pkScripts, inAmounts)
+}
+
+// VerifySignReportV3 is the report variant of VerifySignV3
+//
+// VerifySignReportV3 是 VerifySignV3 的报告版本
+func VerifySignReportV3(msgTx *wire.MsgTx, inputsItem *VerifyTxInputsType) (*VerifyReport, error) {
+	return VerifySignReportV4(msgTx, inputsItem.PkScripts, inputsItem.InAmounts)
+}
+
+// VerifySignReportV4 is the report variant of VerifySignV4
+//
+// VerifySignReportV4 是 VerifySignV4 的报告版本
+func VerifySignReportV4(msgTx *wire.MsgTx, prevScripts [][]byte, inputValues []btcutil.Amount) (*VerifyReport, error) {
+	inputOuts, err := newInputOutsFromScripts(msgTx, prevScripts, inputValues)
+	if err != nil {
+		return nil, err
+	}
+	prevOutFetcher, err := NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
+	if err != nil {
+		return nil, errors.WithMessage(err, "wrong new-prev-out-fetcher")
+	}
+	return NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).VerifyReport(msgTx)
+}
+
+// getInputSigHashType returns sighash type of the first signature found in input, empty when none
+// getInputSigHashType 返回输入中第一个签名的 sighash 类型，没有签名时返回空
+func getInputSigHashType(txIn *wire.TxIn, pkScript []byte) string {
+	for _, sig := range getInputSignatures(txIn, pkScript) {
+		if txscript.IsPayToTaproot(pkScript) && len(sig) == 64 {
+			return "DEFAULT" // Schnorr signature without sighash byte // 没有 sighash 字节的 Schnorr 签名
+		}
+		return formatSigHashType(txscript.SigHashType(sig[len(sig)-1]))
+	}
+	return ""
+}
+
+// formatSigHashType formats sighash type like ALL|ANYONECANPAY
+// formatSigHashType 把 sighash 类型格式化成 ALL|ANYONECANPAY 这样的形式
+func formatSigHashType(hashType txscript.SigHashType) string {
+	var res string
+	switch hashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashAll:
+		res = "ALL"
+	case txscript.SigHashNone:
+		res = "NONE"
+	case txscript.SigHashSingle:
+		res = "SINGLE"
+	default:
+		res = "UNKNOWN(" + strconv.Itoa(int(hashType)) + ")"
+	}
+	if hashType&txscript.SigHashAnyOneCanPay != 0 {
+		res += "|ANYONECANPAY"
+	}
+	return res
+}
+
+// getInputSignatures returns non-empty signature pushes of input, scripts and pubkeys are skipped
+// getInputSignatures 返回输入中非空的签名，脚本和公钥会被跳过
+func getInputSignatures(txIn *wire.TxIn, pkScript []byte) [][]byte {
+	var items [][]byte
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		// Key path spend has one signature, script path spend ends with script and control block
+		// 密钥路径花费只有一个签名，脚本路径花费以脚本和控制块结尾
+		items = txIn.Witness
+		if len(items) > 2 {
+			items = items[:len(items)-2]
+		}
+	case len(txIn.Witness) > 0:
+		items = txIn.Witness
+	default:
+		pushes, err := txscript.PushedData(txIn.SignatureScript)
+		if err != nil {
+			return nil
+		}
+		items = pushes
+	}
+	var sigs [][]byte
+	for _, item := range items {
+		if isSignatureLike(item, txscript.IsPayToTaproot(pkScript)) {
+			sigs = append(sigs, item)
+		}
+	}
+	return sigs
+}
+
+// isSignatureLike tells whether the push looks like DER signature with sighash byte, or Schnorr signature in taproot
+// isSignatureLike 判断数据是否像带 sighash 字节的 DER 签名，或 taproot 中的 Schnorr 签名
+func isSignatureLike(item []byte, taproot bool) bool {
+	if taproot {
+		return len(item) == 64 || len(item) == 65
+	}
+	return len(item) >= 9 && len(item) <= 73 && item[0] == 0x30 && int(item[1]) == len(item)-3
+}
+
+// isPartialMultiSig tells whether input spends multisig and carries fewer signatures than required
+// isPartialMultiSig 判断输入是否花费多签，并且携带的签名少于要求的数量
+func isPartialMultiSig(txIn *wire.TxIn, pkScript []byte) bool {
+	var script = pkScript
+	switch {
+	case txscript.IsPayToScriptHash(pkScript):
+		pushes, err := txscript.PushedData(txIn.SignatureScript)
+		if err != nil || len(pushes) == 0 {
+			return false
+		}
+		script = pushes[len(pushes)-1]
+		if txscript.IsPayToWitnessScriptHash(script) && len(txIn.Witness) > 0 {
+			script = txIn.Witness[len(txIn.Witness)-1]
+		}
+	case txscript.IsPayToWitnessScriptHash(pkScript):
+		if len(txIn.Witness) == 0 {
+			return false
+		}
+		script = txIn.Witness[len(txIn.Witness)-1]
+	}
+	if ok, err := txscript.IsMultisigScript(script); err != nil || !ok {
+		return false
+	}
+	_, required, err := txscript.CalcMultiSigStats(script)
+	if err != nil {
+		return false
+	}
+	count := len(getInputSignatures(txIn, pkScript))
+	return count > 0 && count < required
+}
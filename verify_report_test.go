@@ -0,0 +1,120 @@
+package gobtcsign
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignReportV4(t *testing.T) {
+	netParams := &chaincfg.TestNet3Params
+
+	privKeyBytes, err := hex.DecodeString("54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092")
+	require.NoError(t, err)
+	privKey, pubKey := btcec.PrivKeyFromBytes(privKeyBytes)
+	otherKeyBytes := sha256.Sum256([]byte("other"))
+	_, otherPubKey := btcec.PrivKeyFromBytes(otherKeyBytes[:])
+
+	senderScript := MustGetPkScript(MustNewAddress("tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap", netParams))
+
+	// 2-of-2 multisig in P2WSH, only one key signs
+	pubKeyA, err := btcutil.NewAddressPubKey(pubKey.SerializeCompressed(), netParams)
+	require.NoError(t, err)
+	pubKeyB, err := btcutil.NewAddressPubKey(otherPubKey.SerializeCompressed(), netParams)
+	require.NoError(t, err)
+	multiSigScript, err := txscript.MultiSigScript([]*btcutil.AddressPubKey{pubKeyA, pubKeyB}, 2)
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(multiSigScript)
+	multiSigAddress, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], netParams)
+	require.NoError(t, err)
+	multiSigPkScript := MustGetPkScript(multiSigAddress)
+
+	prevScripts := [][]byte{senderScript, multiSigPkScript, senderScript, senderScript}
+	inputValues := []btcutil.Amount{4900, 10000, 4320, 4560}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	for idx := range prevScripts {
+		msgTx.AddTxIn(wire.NewTxIn(MustNewOutPoint("fb87cc4010bd4a34cb4be86f37182fada63c9923ae8eae5d2f793cb5f50c6328", uint32(idx)), nil, nil))
+	}
+	msgTx.AddTxOut(wire.NewTxOut(20000, senderScript))
+
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for idx, txIn := range msgTx.TxIn {
+		prevOutFetcher.AddPrevOut(txIn.PreviousOutPoint, wire.NewTxOut(int64(inputValues[idx]), prevScripts[idx]))
+	}
+	sigHashes := txscript.NewTxSigHashes(msgTx, prevOutFetcher)
+
+	// input 0 valid
+	witness, err := txscript.WitnessSignature(msgTx, sigHashes, 0, int64(inputValues[0]), senderScript, txscript.SigHashAll, privKey, true)
+	require.NoError(t, err)
+	msgTx.TxIn[0].Witness = witness
+	// input 1 partial
+	signature, err := txscript.RawTxInWitnessSignature(msgTx, sigHashes, 1, int64(inputValues[1]), multiSigScript, txscript.SigHashAll, privKey)
+	require.NoError(t, err)
+	msgTx.TxIn[1].Witness = wire.TxWitness{nil, signature, nil, multiSigScript}
+	// input 2 unsigned
+	// input 3 invalid, signed with wrong amount
+	witness, err = txscript.WitnessSignature(msgTx, sigHashes, 3, int64(inputValues[3])+1, senderScript, txscript.SigHashAll|txscript.SigHashAnyOneCanPay, privKey, true)
+	require.NoError(t, err)
+	msgTx.TxIn[3].Witness = witness
+
+	report, err := VerifySignReportV4(msgTx, prevScripts, inputValues)
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Len(t, report.Inputs, 4)
+
+	require.Equal(t, InputSignValid, report.Inputs[0].Status)
+	require.Equal(t, "witness_v0_keyhash", report.Inputs[0].ScriptType)
+	require.Equal(t, "ALL", report.Inputs[0].SigHashType)
+
+	require.Equal(t, InputSignPartial, report.Inputs[1].Status)
+	require.Equal(t, "witness_v0_scripthash", report.Inputs[1].ScriptType)
+	require.Error(t, report.Inputs[1].Err)
+
+	require.Equal(t, InputSignUnsigned, report.Inputs[2].Status)
+	require.Empty(t, report.Inputs[2].SigHashType)
+
+	require.Equal(t, InputSignInvalid, report.Inputs[3].Status)
+	require.Equal(t, "ALL|ANYONECANPAY", report.Inputs[3].SigHashType)
+	require.NotEmpty(t, report.Inputs[3].Error)
+
+	err = report.Err()
+	require.ErrorIs(t, err, ErrSignatureInvalid)
+	var signErr *SignatureInvalidError
+	require.True(t, errors.As(err, &signErr))
+	require.Equal(t, 1, signErr.Index)
+
+	data, err := report.ToJSON()
+	require.NoError(t, err)
+	t.Log(string(data))
+
+	var decoded VerifyReport
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, report.Txid, decoded.Txid)
+	require.Equal(t, InputSignPartial, decoded.Inputs[1].Status)
+	require.Equal(t, report.Inputs[3].Error, decoded.Inputs[3].Error)
+}
+
+func TestVerifySignReportV2(t *testing.T) {
+	const senderAddress = "tb1qvg2jksxckt96cdv9g8v9psreaggdzsrlm6arap"
+	netParams := &chaincfg.TestNet3Params
+	param := caseErrsTxParams()
+	signParam, err := param.CreateTxSignParams(netParams)
+	require.NoError(t, err)
+	require.NoError(t, Sign(senderAddress, "54bb1426611226077889d63c65f4f1fa212bcb42c2141c81e0c5409324711092", signParam))
+
+	report, err := VerifySignReportV2(signParam.MsgTx, param.GetInputList(), netParams)
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	require.NoError(t, report.Err())
+	require.Equal(t, InputSignValid, report.Inputs[0].Status)
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
-	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/pkg/errors"
 )
 
@@ -50,6 +49,10 @@ func VerifyP2PKHSign(msgTx *wire.MsgTx, senders []string, netParams *chaincfg.Pa
 			if err != nil {
 				return errors.WithMessage(err, "cannot get pk-script")
 			}
+			//数量填0只对 P2PKH 成立，SegWit 和 Taproot 的签名都包含数量，需要使用 Verifier 并提供真实的前置输出
+			if !txscript.IsPayToPubKeyHash(script) {
+				return errors.WithMessagef(ErrUnsupportedAddressType, "wrong address=%s is not P2PKH, use Verifier with real prevouts", address)
+			}
 			pksCache[address] = script
 			pkScript = script
 		}
@@ -107,18 +110,18 @@ func VerifySignV3(msgTx *wire.MsgTx, inputsItem *VerifyTxInputsType) error {
 	return VerifySignV4(msgTx, prevScripts, inputValues)
 }
 
-// VerifySignV4 这是验证签名的函数，代码主要参考这里
+// VerifySignV4 这是验证签名的函数，现在是 Verifier 的简易包装，代码主要参考这里
 // https://github.com/btcsuite/btcwallet/blob/b4ff60753aaa3cf885fb09586755f67d41954942/wallet/createtx.go#L503
 // 这个 github 官方包 是非常重要的参考资料
 // https://github.com/btcsuite/btcwallet/blob/master/wallet/createtx.go
 func VerifySignV4(msgTx *wire.MsgTx, prevScripts [][]byte, inputValues []btcutil.Amount) error {
-	inputFetcher, err := txauthor.TXPrevOutFetcher(msgTx, prevScripts, inputValues)
+	inputOuts, err := newInputOutsFromScripts(msgTx, prevScripts, inputValues)
+	if err != nil {
+		return err
+	}
+	prevOutFetcher, err := NewPrevOutFetcherFromInputOuts(msgTx, inputOuts)
 	if err != nil {
 		return errors.WithMessage(err, "wrong cannot-create-pre-out-cache")
 	}
-	sigHashCache := txscript.NewTxSigHashes(msgTx, inputFetcher)
-
-	inputOuts := NewInputOutsV2(prevScripts, inputValues)
-
-	return VerifySign(msgTx, inputOuts, inputFetcher, sigHashCache)
+	return NewVerifier(prevOutFetcher, txscript.StandardVerifyFlags).Verify(msgTx)
 }